@@ -6,10 +6,11 @@ package files
 
 import (
 	"bufio"
+	"bytes"
 	"io"
-	"log"
 	"os"
 
+	"github.com/Cray-HPE/csm-common/go/pkg/logging"
 	"github.com/spf13/viper"
 )
 
@@ -25,16 +26,22 @@ type decoder func(io.Reader, interface{}) error
 
 // WriteConfig encodes an object to the specified file
 func WriteConfig(enc encoder, path string, conf interface{}) error {
+	var bs bytes.Buffer
+	if err := enc(&bs, conf); err != nil {
+		return err
+	}
+	if planWrite(path, int64(bs.Len())) {
+		return nil
+	}
 	f, err := os.Create(path)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-	w := bufio.NewWriter(f)
-	enc(w, conf)
-	w.Flush()
-	info, _ := f.Stat()
-	log.Printf("wrote %d bytes to %s\n", info.Size(), path)
+	if _, err := f.Write(bs.Bytes()); err != nil {
+		return err
+	}
+	logging.Infof("wrote %d bytes to %s", bs.Len(), path)
 	return nil
 }
 
@@ -50,6 +57,9 @@ func ReadConfig(dec decoder, path string, conf interface{}) error {
 
 // Generic and safe-ish file writing code
 func writeFile(path string, contents string) error {
+	if planWrite(path, int64(len(contents))) {
+		return nil
+	}
 	f, err := os.Create(path)
 	if err != nil {
 		return err
@@ -61,6 +71,6 @@ func writeFile(path string, contents string) error {
 		return err
 	}
 	w.Flush()
-	log.Printf("wrote %d bytes to %s\n", size, path)
+	logging.Infof("wrote %d bytes to %s", size, path)
 	return nil
 }
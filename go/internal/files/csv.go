@@ -0,0 +1,21 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package files
+
+import (
+	"io"
+
+	"github.com/gocarina/gocsv"
+)
+
+// EncodeCSV encodes a slice of structs to writer using their csv tags for the header
+func EncodeCSV(f io.Writer, v interface{}) error {
+	return gocsv.Marshal(v, f)
+}
+
+// WriteCSVConfig marshals a slice of structs to csv and writes the result to the path indicated
+func WriteCSVConfig(path string, conf interface{}) error {
+	return WriteConfig(EncodeCSV, path, conf)
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package files
+
+import (
+	"sync"
+)
+
+// PlannedWrite is a file that would have been written in dry-run mode
+type PlannedWrite struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+var dryRun struct {
+	sync.Mutex
+	enabled bool
+	writes  []PlannedWrite
+}
+
+// SetDryRun turns dry-run mode on or off and forgets any planned writes.
+// In dry-run mode nothing is written to disk, the writes are recorded instead.
+func SetDryRun(enabled bool) {
+	dryRun.Lock()
+	defer dryRun.Unlock()
+	dryRun.enabled = enabled
+	dryRun.writes = nil
+}
+
+// DryRun reports whether dry-run mode is on
+func DryRun() bool {
+	dryRun.Lock()
+	defer dryRun.Unlock()
+	return dryRun.enabled
+}
+
+// PlannedWrites returns the files that would have been written in dry-run mode, in the order they were written
+func PlannedWrites() []PlannedWrite {
+	dryRun.Lock()
+	defer dryRun.Unlock()
+	return append([]PlannedWrite(nil), dryRun.writes...)
+}
+
+// planWrite records a write when dry-run mode is on and reports whether the write should be skipped
+func planWrite(path string, size int64) bool {
+	dryRun.Lock()
+	defer dryRun.Unlock()
+	if !dryRun.enabled {
+		return false
+	}
+	dryRun.writes = append(dryRun.writes, PlannedWrite{Path: path, Size: size})
+	return true
+}
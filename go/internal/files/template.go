@@ -6,7 +6,7 @@ package files
 
 import (
 	"bytes"
-	"log"
+	"fmt"
 	"text/template"
 )
 
@@ -15,7 +15,7 @@ func WriteTemplate(path string, tpl *template.Template, conf interface{}) error
 	var bs bytes.Buffer
 	err := tpl.Execute(&bs, conf)
 	if err != nil {
-		log.Printf("The error executing the template is %v \n", err)
+		return fmt.Errorf("unable to execute the template for %s: %v", path, err)
 	}
 	// log.Printf("calling writefile with %v, %v", path, bs.String())
 	return writeFile(path, bs.String())
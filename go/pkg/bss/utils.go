@@ -2,12 +2,12 @@ package bss
 
 import (
 	"bytes"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 
+	"github.com/Cray-HPE/csm-common/go/pkg/httpretry"
 	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
 )
 
@@ -18,14 +18,12 @@ type UtilsClient struct {
 	token      string
 }
 
-// NewBSSClient - Creates a new BSS client.
-func NewBSSClient(baseURL string, httpClient *http.Client, token string) *UtilsClient {
+// NewBSSClient - Creates a new BSS client. Without an httpClient the requests time out and are retried
+// as httpretry.NewClient describes, the options set the timeout and retries, e.g. from --http-timeout and
+// --http-retries.
+func NewBSSClient(baseURL string, httpClient *http.Client, token string, options ...httpretry.Option) *UtilsClient {
 	if httpClient == nil {
-		transport := http.DefaultTransport.(*http.Transport).Clone()
-		transport.TLSClientConfig = &tls.Config{
-			InsecureSkipVerify: true,
-		}
-		httpClient = &http.Client{Transport: transport}
+		httpClient = httpretry.NewClient(options...)
 	}
 
 	return &UtilsClient{
@@ -0,0 +1,66 @@
+//
+//  MIT License
+//
+//  (C) Copyright 2022 Hewlett Packard Enterprise Development LP
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a
+//  copy of this software and associated documentation files (the "Software"),
+//  to deal in the Software without restriction, including without limitation
+//  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//  and/or sell copies of the Software, and to permit persons to whom the
+//  Software is furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included
+//  in all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+//  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+//  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+//  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+//  OTHER DEALINGS IN THE SOFTWARE.
+
+package bss
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+)
+
+// ValidateBootParams checks that a BSS entry has the fields an NCN needs to boot
+func ValidateBootParams(bssEntry bssTypes.BootParams) error {
+	var missing []string
+	if bssEntry.Kernel == "" {
+		missing = append(missing, "kernel")
+	}
+	if bssEntry.Initrd == "" {
+		missing = append(missing, "initrd")
+	}
+	if len(bssEntry.CloudInit.MetaData) == 0 {
+		missing = append(missing, "cloud-init meta-data")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// VerifyBootparametersForXnames fetches the BSS entry of every xname and returns an error for each one
+// that could not be retrieved or is missing required data.
+func (utilsClient *UtilsClient) VerifyBootparametersForXnames(xnames []string) []error {
+	var errs []error
+	for _, xname := range xnames {
+		bssEntry, err := utilsClient.GetBSSBootparametersForXname(xname)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", xname, err))
+			continue
+		}
+		if err := ValidateBootParams(*bssEntry); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", xname, err))
+		}
+	}
+	return errs
+}
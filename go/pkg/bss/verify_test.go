@@ -0,0 +1,57 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package bss
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Cray-HPE/hms-bss/pkg/bssTypes"
+	"github.com/stretchr/testify/suite"
+)
+
+type BSSVerifyTestSuite struct {
+	suite.Suite
+}
+
+func (suite *BSSVerifyTestSuite) TestVerifyBootparametersForXnames() {
+	entries := map[string]bssTypes.BootParams{
+		"x3000c0s1b0n0": {
+			Hosts:     []string{"x3000c0s1b0n0"},
+			Kernel:    "s3://boot-images/k8s/0.2.0/kernel",
+			Initrd:    "s3://boot-images/k8s/0.2.0/initrd",
+			CloudInit: bssTypes.CloudInit{MetaData: map[string]interface{}{"local-hostname": "ncn-m001"}},
+		},
+		"x3000c0s2b0n0": {
+			Hosts:  []string{"x3000c0s2b0n0"},
+			Kernel: "s3://boot-images/k8s/0.2.0/kernel",
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry, ok := entries[r.URL.Query().Get("name")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not found"))
+			return
+		}
+		json.NewEncoder(w).Encode([]bssTypes.BootParams{entry})
+	}))
+	defer server.Close()
+
+	client := NewBSSClient(server.URL, server.Client(), "")
+	errs := client.VerifyBootparametersForXnames([]string{"x3000c0s1b0n0", "x3000c0s2b0n0", "x3000c0s3b0n0"})
+	suite.Len(errs, 2)
+	suite.EqualError(errs[0], "x3000c0s2b0n0: missing initrd, cloud-init meta-data")
+	suite.EqualError(errs[1], "x3000c0s3b0n0: failed to get BSS entry: not found")
+}
+
+func TestBSSVerifyTestSuite(t *testing.T) {
+	suite.Run(t, new(BSSVerifyTestSuite))
+}
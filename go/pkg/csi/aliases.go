@@ -0,0 +1,121 @@
+//
+//  MIT License
+//
+//  (C) Copyright 2022 Hewlett Packard Enterprise Development LP
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a
+//  copy of this software and associated documentation files (the "Software"),
+//  to deal in the Software without restriction, including without limitation
+//  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//  and/or sell copies of the Software, and to permit persons to whom the
+//  Software is furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included
+//  in all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+//  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+//  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+//  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+//  OTHER DEALINGS IN THE SOFTWARE.
+
+package csi
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+)
+
+// NCNAliasTemplates maps an NCN subrole and a network name to text/template alias strings for its reservations.
+// The templates are evaluated with NCNAliasTemplateData.
+//
+//	Storage:
+//	  HMN:
+//	    - "{{.NCN.Hostname}}-hmn"
+//	    - "{{.NCN.Xname}}.hmn"
+type NCNAliasTemplates map[string]map[string][]string
+
+// NCNAliasTemplateData is in scope when an alias template is evaluated
+type NCNAliasTemplateData struct {
+	NCN         LogicalNCN
+	Reservation IPReservation
+	Network     string
+}
+
+// LoadNCNAliasTemplates loads the alias templates from the filesystem
+func LoadNCNAliasTemplates(path string) (NCNAliasTemplates, error) {
+	var templates NCNAliasTemplates
+	err := csiFiles.ReadYAMLConfig(path, &templates)
+	return templates, err
+}
+
+// lookup returns the templates for the subrole and network, matched without regard to case
+func (templates NCNAliasTemplates) lookup(subrole, network string) ([]string, bool) {
+	for templateSubrole, networks := range templates {
+		if !strings.EqualFold(templateSubrole, subrole) {
+			continue
+		}
+		for templateNetwork, aliases := range networks {
+			if strings.EqualFold(templateNetwork, network) {
+				return aliases, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// UpdateNCNReservationsWithTemplates is like UpdateNCNReservations, but the aliases come from the templates
+// for the subrole and network of each reservation when there are any
+func (iSubnet *IPV4Subnet) UpdateNCNReservationsWithTemplates(ncns []LogicalNCN, templates NCNAliasTemplates) error {
+	netName := strings.ToLower(iSubnet.NetName)
+	for i := range iSubnet.IPReservations {
+		reservation := &iSubnet.IPReservations[i]
+		for _, ncn := range ncns {
+			if reservation.Comment != ncn.Xname || ncn.Hostname == "" {
+				continue
+			}
+			reservation.Name = ncn.Hostname
+
+			aliases := defaultNCNAliases(ncn.Hostname, netName)
+			if aliasTemplates, ok := templates.lookup(ncn.Subrole, iSubnet.NetName); ok {
+				rendered, err := renderNCNAliases(aliasTemplates, NCNAliasTemplateData{
+					NCN:         ncn,
+					Reservation: *reservation,
+					Network:     iSubnet.NetName,
+				})
+				if err != nil {
+					return fmt.Errorf("unable to render the aliases of %s on the %s network: %v", ncn.Hostname, iSubnet.NetName, err)
+				}
+				aliases = rendered
+			}
+			for _, alias := range aliases {
+				reservation.AddReservationAlias(alias)
+			}
+		}
+	}
+	return nil
+}
+
+func renderNCNAliases(aliasTemplates []string, data NCNAliasTemplateData) ([]string, error) {
+	var aliases []string
+	for _, aliasTemplate := range aliasTemplates {
+		tpl, err := template.New("alias").Option("missingkey=error").Parse(aliasTemplate)
+		if err != nil {
+			return nil, err
+		}
+		var bs bytes.Buffer
+		if err := tpl.Execute(&bs, data); err != nil {
+			return nil, err
+		}
+		if alias := strings.TrimSpace(bs.String()); alias != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases, nil
+}
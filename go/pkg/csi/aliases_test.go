@@ -0,0 +1,79 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type NCNAliasTemplateTestSuite struct {
+	suite.Suite
+}
+
+func testHMNBootstrapSubnet() *IPV4Subnet {
+	_, cidr, _ := net.ParseCIDR("10.254.1.0/24")
+	subnet := &IPV4Subnet{Name: "bootstrap_dhcp", NetName: "HMN", CIDR: *cidr}
+	subnet.AddReservation("x3000c0s1b0n0", "x3000c0s1b0n0")
+	subnet.AddReservation("x3000c0s13b0n0", "x3000c0s13b0n0")
+	return subnet
+}
+
+func testAliasNCNs() []LogicalNCN {
+	return []LogicalNCN{
+		{Xname: "x3000c0s1b0n0", Hostname: "ncn-m001", Subrole: "Master"},
+		{Xname: "x3000c0s13b0n0", Hostname: "ncn-s001", Subrole: "Storage"},
+	}
+}
+
+func (suite *NCNAliasTemplateTestSuite) TestUpdateNCNReservationsWithTemplates_Storage() {
+	dir, err := ioutil.TempDir("", "aliases")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ncn_alias_template.yaml")
+	suite.NoError(ioutil.WriteFile(path, []byte(`
+Storage:
+  HMN:
+    - "{{.NCN.Hostname}}.hmn.example.com"
+    - "ceph-{{.NCN.Xname}}-{{.Reservation.IPAddress}}"
+`), 0644))
+
+	templates, err := LoadNCNAliasTemplates(path)
+	suite.NoError(err)
+
+	subnet := testHMNBootstrapSubnet()
+	suite.NoError(subnet.UpdateNCNReservationsWithTemplates(testAliasNCNs(), templates))
+
+	// The master has no template on the HMN and keeps the default aliases
+	suite.Equal([]string{"ncn-m001-hmn"}, subnet.LookupReservation("ncn-m001").Aliases)
+	suite.Equal([]string{"ncn-s001.hmn.example.com", "ceph-x3000c0s13b0n0-10.254.1.3"}, subnet.LookupReservation("ncn-s001").Aliases)
+}
+
+func (suite *NCNAliasTemplateTestSuite) TestUpdateNCNReservationsWithTemplates_Default() {
+	withoutTemplates := testHMNBootstrapSubnet()
+	suite.NoError(withoutTemplates.UpdateNCNReservationsWithTemplates(testAliasNCNs(), nil))
+
+	subnet := testHMNBootstrapSubnet()
+	subnet.UpdateNCNReservations(testAliasNCNs())
+	suite.Equal(subnet.IPReservations, withoutTemplates.IPReservations)
+}
+
+func (suite *NCNAliasTemplateTestSuite) TestUpdateNCNReservationsWithTemplates_Invalid() {
+	templates := NCNAliasTemplates{"Storage": {"HMN": {"{{.NCN.Missing}}"}}}
+	err := testHMNBootstrapSubnet().UpdateNCNReservationsWithTemplates(testAliasNCNs(), templates)
+	suite.EqualError(err, `unable to render the aliases of ncn-s001 on the HMN network: template: alias:1:6: executing "alias" at <.NCN.Missing>: can't evaluate field Missing in type csi.LogicalNCN`)
+}
+
+func TestNCNAliasTemplateTestSuite(t *testing.T) {
+	suite.Run(t, new(NCNAliasTemplateTestSuite))
+}
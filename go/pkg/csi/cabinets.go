@@ -5,6 +5,9 @@ Copyright 2021 Hewlett Packard Enterprise Development LP
 package csi
 
 import (
+	"fmt"
+	"strings"
+
 	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
 )
 
@@ -61,6 +64,60 @@ func (cgd *CabinetGroupDetail) Length() int {
 	return len(cgd.CabinetDetails)
 }
 
+// ValidateCabinetIDs ensures that no cabinet id is used by more than one cabinet since each id becomes the x<id> xname
+func ValidateCabinetIDs(cabinetDetails []CabinetGroupDetail) error {
+	kinds := make(map[int]string)
+	for _, cabinetGroup := range cabinetDetails {
+		for _, id := range cabinetGroup.CabinetIDs() {
+			if kind, ok := kinds[id]; ok {
+				return fmt.Errorf("cabinet x%d is defined by both the %s and %s cabinets", id, kind, cabinetGroup.Kind)
+			}
+			kinds[id] = cabinetGroup.Kind
+		}
+	}
+	return nil
+}
+
+// CabinetClassRanges are the inclusive ranges of cabinet ids HSM expects for each cabinet class.
+// A cabinet outside the ranges of its class gets an xname that contradicts its SLS Class.
+var CabinetClassRanges = map[string][][2]int{
+	"river":    {{3000, 4999}},
+	"mountain": {{1000, 2999}, {5000, 8999}},
+	"hill":     {{9000, 9999}},
+}
+
+func cabinetClassRangesString(ranges [][2]int) string {
+	var out []string
+	for _, idRange := range ranges {
+		out = append(out, fmt.Sprintf("%d-%d", idRange[0], idRange[1]))
+	}
+	return strings.Join(out, ", ")
+}
+
+// ValidateCabinetClasses ensures that every cabinet id is in the range of its class.
+// Cabinet types without a known class are not checked.
+func ValidateCabinetClasses(cabinetDetails []CabinetGroupDetail) error {
+	for _, cabinetGroup := range cabinetDetails {
+		ranges, ok := CabinetClassRanges[strings.ToLower(cabinetGroup.Kind)]
+		if !ok {
+			continue
+		}
+		for _, id := range cabinetGroup.CabinetIDs() {
+			inRange := false
+			for _, idRange := range ranges {
+				if id >= idRange[0] && id <= idRange[1] {
+					inRange = true
+					break
+				}
+			}
+			if !inRange {
+				return fmt.Errorf("%s cabinet x%d is outside the %s range of %s cabinet ids", cabinetGroup.Kind, id, cabinetClassRangesString(ranges), strings.ToLower(cabinetGroup.Kind))
+			}
+		}
+	}
+	return nil
+}
+
 // CabinetTypes returns a list of cabinet types from the file
 func (cdf *CabinetDetailFile) CabinetTypes() []string {
 	var out []string
@@ -81,3 +138,54 @@ func LoadCabinetDetailFile(path string) (CabinetDetailFile, error) {
 	err := csiFiles.ReadYAMLConfig(path, &cabDetailFile)
 	return cabDetailFile, err
 }
+
+// CabinetSubnet describes the per-cabinet subnet of a single network
+type CabinetSubnet struct {
+	Network string `json:"network"`
+	VlanID  int16  `json:"vlan_id"`
+	CIDR    string `json:"cidr"`
+	Gateway string `json:"gateway"`
+}
+
+// CabinetNetworks holds the NMN and HMN subnets assigned to a cabinet
+type CabinetNetworks struct {
+	NMN *CabinetSubnet `json:"nmn,omitempty"`
+	HMN *CabinetSubnet `json:"hmn,omitempty"`
+}
+
+// BuildCabinetNetworkMap maps each cabinet xname to the NMN and HMN vlans and subnets generated for it
+func BuildCabinetNetworkMap(networks map[string]*IPV4Network) map[string]CabinetNetworks {
+	cabinetMap := map[string]CabinetNetworks{}
+	for netName, network := range networks {
+		isNMN := strings.HasPrefix(netName, "NMN") && netName != "NMNLB"
+		isHMN := strings.HasPrefix(netName, "HMN") && netName != "HMNLB"
+		if !isNMN && !isHMN {
+			continue
+		}
+		for _, subnet := range network.Subnets {
+			if !strings.HasPrefix(subnet.Name, "cabinet_") {
+				continue
+			}
+			xname := fmt.Sprintf("x%s", strings.TrimPrefix(subnet.Name, "cabinet_"))
+			cabinetSubnet := &CabinetSubnet{
+				Network: netName,
+				VlanID:  subnet.VlanID,
+				CIDR:    subnet.CIDR.String(),
+				Gateway: subnet.Gateway.String(),
+			}
+			entry := cabinetMap[xname]
+			if isNMN {
+				entry.NMN = cabinetSubnet
+			} else {
+				entry.HMN = cabinetSubnet
+			}
+			cabinetMap[xname] = entry
+		}
+	}
+	return cabinetMap
+}
+
+// WriteCabinetNetworkMap writes the cabinet to vlan/subnet mapping as JSON for switch automation
+func WriteCabinetNetworkMap(path string, networks map[string]*IPV4Network) error {
+	return csiFiles.WriteJSONConfig(path, BuildCabinetNetworkMap(networks))
+}
@@ -8,11 +8,13 @@ Copyright 2021 Hewlett Packard Enterprise Development LP
 package csi
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v2"
 )
 
@@ -92,3 +94,37 @@ func TestUnMarshalCabinetsFile(t *testing.T) {
 		log.Fatalln("Unable to Unmarshal the fake Yaml", err)
 	}
 }
+
+func TestValidateCabinetIDs(t *testing.T) {
+	river := CabinetGroupDetail{Kind: "river", Cabinets: 3, StartingCabinet: 3000}
+	river.PopulateIds()
+	hill := CabinetGroupDetail{Kind: "hill", Cabinets: 2, StartingCabinet: 9000}
+	hill.PopulateIds()
+	assert.NoError(t, ValidateCabinetIDs([]CabinetGroupDetail{river, hill}))
+}
+
+func TestValidateCabinetIDs_Overlapping(t *testing.T) {
+	river := CabinetGroupDetail{Kind: "river", Cabinets: 3, StartingCabinet: 3000}
+	river.PopulateIds()
+	hill := CabinetGroupDetail{Kind: "hill", Cabinets: 2, StartingCabinet: 3002}
+	hill.PopulateIds()
+	assert.Equal(t, errors.New("cabinet x3002 is defined by both the river and hill cabinets"), ValidateCabinetIDs([]CabinetGroupDetail{river, hill}))
+}
+
+func TestValidateCabinetClasses(t *testing.T) {
+	river := CabinetGroupDetail{Kind: "river", Cabinets: 2, StartingCabinet: 3000}
+	river.PopulateIds()
+	mountain := CabinetGroupDetail{Kind: "Mountain", Cabinets: 2, StartingCabinet: 1000}
+	mountain.PopulateIds()
+	hill := CabinetGroupDetail{Kind: "hill", Cabinets: 2, StartingCabinet: 9000}
+	hill.PopulateIds()
+	other := CabinetGroupDetail{Kind: "kind_1", Cabinets: 1, StartingCabinet: 9}
+	other.PopulateIds()
+	assert.NoError(t, ValidateCabinetClasses([]CabinetGroupDetail{river, mountain, hill, other}))
+}
+
+func TestValidateCabinetClasses_MountainInRiverRange(t *testing.T) {
+	mountain := CabinetGroupDetail{Kind: "mountain", Cabinets: 2, StartingCabinet: 3000}
+	mountain.PopulateIds()
+	assert.Equal(t, errors.New("mountain cabinet x3000 is outside the 1000-2999, 5000-8999 range of mountain cabinet ids"), ValidateCabinetClasses([]CabinetGroupDetail{mountain}))
+}
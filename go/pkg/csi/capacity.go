@@ -0,0 +1,227 @@
+//
+//  MIT License
+//
+//  (C) Copyright 2022 Hewlett Packard Enterprise Development LP
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a
+//  copy of this software and associated documentation files (the "Software"),
+//  to deal in the Software without restriction, including without limitation
+//  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//  and/or sell copies of the Software, and to permit persons to whom the
+//  Software is furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included
+//  in all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+//  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+//  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+//  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+//  OTHER DEALINGS IN THE SOFTWARE.
+
+package csi
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+	"github.com/Cray-HPE/csm-common/go/pkg/ipam"
+)
+
+// DefaultCapacityThreshold is the utilization percentage at which a subnet is flagged as near capacity
+const DefaultCapacityThreshold = 90.0
+
+// SubnetCapacity summarizes the address usage of a single subnet
+type SubnetCapacity struct {
+	Network      string  `json:"network"`
+	Subnet       string  `json:"subnet"`
+	CIDR         string  `json:"cidr"`
+	Total        int     `json:"total"`
+	Usable       int     `json:"usable"`
+	Reserved     int     `json:"reserved"`
+	Free         int     `json:"free"`
+	DHCPRange    int     `json:"dhcp_range"`
+	Utilization  float64 `json:"utilization_percent"`
+	NearCapacity bool    `json:"near_capacity"`
+	// Overfull subnets have more reservations than usable addresses
+	Overfull bool `json:"overfull"`
+}
+
+// CapacityReport calculates the address usage of every subnet in the networks, sorted by network and subnet name.
+// Subnets at or above threshold percent utilization are flagged as near capacity.
+func CapacityReport(networks map[string]*IPV4Network, threshold float64) []SubnetCapacity {
+	var report []SubnetCapacity
+	for netName, network := range networks {
+		for _, subnet := range network.Subnets {
+			usable := subnet.UsableHostAddresses()
+			reserved := len(subnet.ReservedIPs())
+			capacity := SubnetCapacity{
+				Network:   netName,
+				Subnet:    subnet.Name,
+				CIDR:      subnet.CIDR.String(),
+				Total:     subnet.TotalIPAddresses(),
+				Usable:    usable,
+				Reserved:  reserved,
+				Free:      usable - reserved,
+				DHCPRange: dhcpRangeSize(subnet),
+				Overfull:  reserved > usable,
+			}
+			if usable > 0 {
+				capacity.Utilization = float64(reserved) / float64(usable) * 100
+			}
+			capacity.NearCapacity = capacity.Utilization >= threshold
+			report = append(report, capacity)
+		}
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Network != report[j].Network {
+			return report[i].Network < report[j].Network
+		}
+		return report[i].Subnet < report[j].Subnet
+	})
+	return report
+}
+
+// dhcpRangeSize counts the addresses from DHCPStart to DHCPEnd, subnets without a DHCP range have none
+func dhcpRangeSize(subnet *IPV4Subnet) int {
+	if subnet.DHCPStart.To4() == nil || subnet.DHCPEnd.To4() == nil {
+		return 0
+	}
+	start := binary.BigEndian.Uint32(subnet.DHCPStart.To4())
+	end := binary.BigEndian.Uint32(subnet.DHCPEnd.To4())
+	if end < start {
+		return 0
+	}
+	return int(end-start) + 1
+}
+
+// OverfullSubnets returns an error naming every subnet in the report with more reservations than usable addresses
+func OverfullSubnets(report []SubnetCapacity) error {
+	var overfull []string
+	for _, capacity := range report {
+		if capacity.Overfull {
+			overfull = append(overfull, fmt.Sprintf("%s %s (%s) has %d reservations and only %d usable addresses",
+				capacity.Network, capacity.Subnet, capacity.CIDR, capacity.Reserved, capacity.Usable))
+		}
+	}
+	if len(overfull) > 0 {
+		return fmt.Errorf("subnets are over capacity: %s", strings.Join(overfull, "; "))
+	}
+	return nil
+}
+
+// ReadNetworksDirectory loads the networks/*.yaml files of a generated configuration directory keyed by network name
+func ReadNetworksDirectory(dir string) (map[string]*IPV4Network, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no network files found in %s", dir)
+	}
+	networks := make(map[string]*IPV4Network)
+	for _, path := range paths {
+		var network IPV4Network
+		if err := csiFiles.ReadYAMLConfig(path, &network); err != nil {
+			return nil, fmt.Errorf("unable to read %s: %v", path, err)
+		}
+		if network.Name == "" {
+			network.Name = strings.TrimSuffix(filepath.Base(path), ".yaml")
+		}
+		networks[network.Name] = &network
+	}
+	return networks, nil
+}
+
+// WriteCapacityReport writes the report to w as either a table or json
+func WriteCapacityReport(w io.Writer, report []SubnetCapacity, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NETWORK\tSUBNET\tCIDR\tTOTAL\tUSABLE\tRESERVED\tFREE\tDHCP\tUTILIZATION\t")
+		for _, capacity := range report {
+			flag := ""
+			if capacity.Overfull {
+				flag = " !"
+			} else if capacity.NearCapacity {
+				flag = " *"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%.1f%%%s\t\n", capacity.Network, capacity.Subnet, capacity.CIDR,
+				capacity.Total, capacity.Usable, capacity.Reserved, capacity.Free, capacity.DHCPRange, capacity.Utilization, flag)
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unknown capacity report format %q, must be table or json", format)
+}
+
+// NetworkFreeSpace lists the CIDR blocks of a network that no subnet has been allocated from.
+// The blocks are the largest aligned ones, so any of them can be used as is for a new subnet.
+type NetworkFreeSpace struct {
+	Network string   `json:"network"`
+	CIDR    string   `json:"cidr"`
+	Free    []string `json:"free"`
+}
+
+// FreeSpaceReport walks the subnets of every network and returns its free CIDR blocks, sorted by network name.
+// This is the space AddSubnet and AddBiggestSubnet allocate from.
+func FreeSpaceReport(networks map[string]*IPV4Network) ([]NetworkFreeSpace, error) {
+	var names []string
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := []NetworkFreeSpace{}
+	for _, name := range names {
+		network := networks[name]
+		_, networkNet, err := net.ParseCIDR(network.CIDR)
+		if err != nil {
+			return report, fmt.Errorf("unable to parse the CIDR of the %s network: %v", name, err)
+		}
+		free := NetworkFreeSpace{Network: name, CIDR: networkNet.String(), Free: []string{}}
+		for _, block := range ipam.FreeBlocks(*networkNet, network.AllocatedSubnets()) {
+			free.Free = append(free.Free, block.String())
+		}
+		report = append(report, free)
+	}
+	return report, nil
+}
+
+// WriteFreeSpaceReport writes the report to w as either a table, with a row for each free block, or json
+func WriteFreeSpaceReport(w io.Writer, report []NetworkFreeSpace, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NETWORK\tCIDR\tFREE\tADDRESSES\t")
+		for _, network := range report {
+			if len(network.Free) == 0 {
+				fmt.Fprintf(tw, "%s\t%s\t-\t0\t\n", network.Network, network.CIDR)
+			}
+			for _, block := range network.Free {
+				_, blockNet, _ := net.ParseCIDR(block)
+				ones, bits := blockNet.Mask.Size()
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t\n", network.Network, network.CIDR, block, 1<<uint(bits-ones))
+			}
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unknown free space report format %q, must be table or json", format)
+}
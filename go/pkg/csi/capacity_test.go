@@ -0,0 +1,162 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+	"github.com/stretchr/testify/suite"
+)
+
+type CapacityReportTestSuite struct {
+	suite.Suite
+}
+
+func testCapacityNetworks() map[string]*IPV4Network {
+	_, smallNet, _ := net.ParseCIDR("10.254.0.0/28")
+	small := &IPV4Subnet{Name: "network_hardware", CIDR: *smallNet}
+	for i := 0; i < 13; i++ {
+		small.AddReservation(fmt.Sprintf("sw-%03d", i), "")
+	}
+
+	_, bigNet, _ := net.ParseCIDR("10.254.1.0/24")
+	big := &IPV4Subnet{Name: "bootstrap_dhcp", CIDR: *bigNet}
+	for i := 0; i < 127; i++ {
+		big.AddReservation(fmt.Sprintf("ncn-%03d", i), "")
+	}
+
+	return map[string]*IPV4Network{
+		"HMN": {Name: "HMN", CIDR: "10.254.0.0/17", Subnets: []*IPV4Subnet{small, big}},
+	}
+}
+
+func (suite *CapacityReportTestSuite) TestCapacityReport() {
+	report := CapacityReport(testCapacityNetworks(), DefaultCapacityThreshold)
+	suite.Equal([]SubnetCapacity{{
+		Network:     "HMN",
+		Subnet:      "bootstrap_dhcp",
+		CIDR:        "10.254.1.0/24",
+		Total:       256,
+		Usable:      254,
+		Reserved:    127,
+		Free:        127,
+		Utilization: 50,
+	}, {
+		Network:      "HMN",
+		Subnet:       "network_hardware",
+		CIDR:         "10.254.0.0/28",
+		Total:        16,
+		Usable:       14,
+		Reserved:     13,
+		Free:         1,
+		Utilization:  float64(13) / float64(14) * 100,
+		NearCapacity: true,
+	}}, report)
+}
+
+func (suite *CapacityReportTestSuite) TestWriteCapacityReport_Table() {
+	var bs bytes.Buffer
+	suite.NoError(WriteCapacityReport(&bs, CapacityReport(testCapacityNetworks(), DefaultCapacityThreshold), "table"))
+	suite.Contains(bs.String(), "92.9% *")
+	suite.Contains(bs.String(), "50.0%")
+}
+
+func (suite *CapacityReportTestSuite) TestWriteCapacityReport_InvalidFormat() {
+	var bs bytes.Buffer
+	err := WriteCapacityReport(&bs, nil, "xml")
+	suite.Equal(errors.New(`unknown capacity report format "xml", must be table or json`), err)
+}
+
+func (suite *CapacityReportTestSuite) TestCapacityReport_DHCPRange() {
+	_, cidr, _ := net.ParseCIDR("10.252.1.0/24")
+	subnet := &IPV4Subnet{Name: "bootstrap_dhcp", CIDR: *cidr}
+	suite.NoError(subnet.UpdateDHCPRange(false))
+	networks := map[string]*IPV4Network{"NMN": {Name: "NMN", Subnets: []*IPV4Subnet{subnet}}}
+
+	report := CapacityReport(networks, DefaultCapacityThreshold)
+	suite.Equal(245, report[0].DHCPRange)
+}
+
+func (suite *CapacityReportTestSuite) TestReadNetworksDirectory_Overfull() {
+	dir, err := ioutil.TempDir("", "networks")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+
+	networks := testCapacityNetworks()
+	hardware, _ := networks["HMN"].LookUpSubnet("network_hardware")
+	// Reservations written by hand can exceed what AddReservation allows
+	hardware.IPReservations = append(hardware.IPReservations, IPReservation{Name: "sw-013"}, IPReservation{Name: "sw-014"})
+	suite.NoError(csiFiles.WriteYAMLConfig(filepath.Join(dir, "HMN.yaml"), networks["HMN"]))
+
+	loaded, err := ReadNetworksDirectory(dir)
+	suite.NoError(err)
+	report := CapacityReport(loaded, DefaultCapacityThreshold)
+	suite.False(report[0].Overfull)
+	suite.True(report[1].Overfull)
+	suite.Equal(errors.New("subnets are over capacity: HMN network_hardware (10.254.0.0/28) has 15 reservations and only 14 usable addresses"), OverfullSubnets(report))
+
+	var bs bytes.Buffer
+	suite.NoError(WriteCapacityReport(&bs, report, "table"))
+	suite.Contains(bs.String(), "107.1% !")
+}
+
+func (suite *CapacityReportTestSuite) TestReadNetworksDirectory_Empty() {
+	dir, err := ioutil.TempDir("", "networks")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+
+	_, err = ReadNetworksDirectory(dir)
+	suite.Equal(fmt.Errorf("no network files found in %s", dir), err)
+}
+
+func (suite *CapacityReportTestSuite) TestFreeSpaceReport() {
+	networks := testCapacityNetworks()
+	_, cabinetNet, _ := net.ParseCIDR("10.254.4.0/22")
+	networks["HMN"].Subnets = append(networks["HMN"].Subnets, &IPV4Subnet{Name: "cabinet_3000", CIDR: *cabinetNet})
+	networks["NMNLB"] = &IPV4Network{Name: "NMNLB", CIDR: "10.92.100.0/24"}
+
+	report, err := FreeSpaceReport(networks)
+	suite.NoError(err)
+	suite.Equal([]NetworkFreeSpace{
+		{
+			Network: "HMN",
+			CIDR:    "10.254.0.0/17",
+			Free: []string{
+				"10.254.0.16/28", "10.254.0.32/27", "10.254.0.64/26", "10.254.0.128/25",
+				"10.254.2.0/23", "10.254.8.0/21", "10.254.16.0/20", "10.254.32.0/19", "10.254.64.0/18",
+			},
+		},
+		{Network: "NMNLB", CIDR: "10.92.100.0/24", Free: []string{"10.92.100.0/24"}},
+	}, report)
+
+	var bs bytes.Buffer
+	suite.NoError(WriteFreeSpaceReport(&bs, report, "table"))
+	suite.Contains(bs.String(), "10.254.2.0/23")
+	suite.Contains(bs.String(), "512")
+}
+
+func (suite *CapacityReportTestSuite) TestFreeSpaceReport_Full() {
+	_, full, _ := net.ParseCIDR("10.92.100.0/24")
+	networks := map[string]*IPV4Network{"NMNLB": {Name: "NMNLB", CIDR: "10.92.100.0/24", Subnets: []*IPV4Subnet{{Name: "nmn_metallb_address_pool", CIDR: *full}}}}
+
+	report, err := FreeSpaceReport(networks)
+	suite.NoError(err)
+	suite.Empty(report[0].Free)
+}
+
+func TestCapacityReportTestSuite(t *testing.T) {
+	suite.Run(t, new(CapacityReportTestSuite))
+}
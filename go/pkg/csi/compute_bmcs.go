@@ -0,0 +1,101 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
+	"github.com/mitchellh/mapstructure"
+)
+
+// RiverComputeBMCs returns the BMC xnames of the River compute nodes in the hardware of an SLS state, keyed by
+// cabinet.  Each BMC maps to the node it manages.
+func RiverComputeBMCs(hardware map[string]sls_common.GenericHardware) (map[string]map[string]string, error) {
+	bmcs := map[string]map[string]string{}
+	for xname, node := range hardware {
+		if node.Type != sls_common.Node || node.Class != sls_common.ClassRiver {
+			continue
+		}
+		var extra sls_common.ComptypeNode
+		if err := mapstructure.Decode(node.ExtraPropertiesRaw, &extra); err != nil {
+			return nil, err
+		}
+		if extra.Role != "Compute" {
+			continue
+		}
+		cabinet, err := CabinetForXname(node.Parent)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := bmcs[cabinet]; !ok {
+			bmcs[cabinet] = map[string]string{}
+		}
+		if first, ok := bmcs[cabinet][node.Parent]; !ok || xname < first {
+			bmcs[cabinet][node.Parent] = xname
+		}
+	}
+	return bmcs, nil
+}
+
+// ReserveRiverComputeBMCs reserves an address for the BMC of every River compute node in the cabinet_<id> subnet
+// of its cabinet, so compute BMCs get a fixed address instead of relying on dynamic DHCP.  The hardware comes from
+// the SLS state generated from the SHCD.  Every cabinet is checked for room before anything is reserved, and the
+// DHCP range of each subnet is moved past its new reservations.  BMCs that are already reserved are left alone.
+func ReserveRiverComputeBMCs(network *IPV4Network, hardware map[string]sls_common.GenericHardware) error {
+	bmcs, err := RiverComputeBMCs(hardware)
+	if err != nil {
+		return err
+	}
+	var cabinets []string
+	for cabinet := range bmcs {
+		cabinets = append(cabinets, cabinet)
+	}
+	sort.Strings(cabinets)
+
+	subnets := map[string]*IPV4Subnet{}
+	for _, cabinet := range cabinets {
+		name := "cabinet_" + strings.TrimPrefix(cabinet, "x")
+		subnet, err := network.LookUpSubnet(name)
+		if err != nil {
+			return fmt.Errorf("unable to reserve the compute BMCs of %s: no %s subnet in the %s network", cabinet, name, network.Name)
+		}
+		reserved := subnet.ReservationsByName()
+		needed := 0
+		for bmc := range bmcs[cabinet] {
+			if _, ok := reserved[bmc]; !ok {
+				needed++
+			}
+		}
+		// The gateway takes the first usable address
+		room := subnet.UsableHostAddresses() - 1 - len(subnet.ReservedIPs())
+		if needed > room {
+			return fmt.Errorf("the %s subnet %v of the %s network has room for %d more reservations but %d compute BMCs in %s need one",
+				name, subnet.CIDR.String(), network.Name, room, needed, cabinet)
+		}
+		subnets[cabinet] = subnet
+	}
+
+	for _, cabinet := range cabinets {
+		var xnames []string
+		for bmc := range bmcs[cabinet] {
+			xnames = append(xnames, bmc)
+		}
+		sort.Strings(xnames)
+
+		subnet := subnets[cabinet]
+		for _, bmc := range xnames {
+			if _, _, err := subnet.EnsureReservation(bmc, bmcs[cabinet][bmc]); err != nil {
+				return err
+			}
+		}
+		if err := subnet.UpdateDHCPRange(false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
+	"github.com/stretchr/testify/suite"
+)
+
+type ComputeBMCsTestSuite struct {
+	suite.Suite
+}
+
+func testComputeNode(xname, parent string, class sls_common.CabinetType, role string) sls_common.GenericHardware {
+	return sls_common.GenericHardware{
+		Parent:             parent,
+		Xname:              xname,
+		Type:               sls_common.Node,
+		Class:              class,
+		ExtraPropertiesRaw: sls_common.ComptypeNode{Role: role},
+	}
+}
+
+func testComputeHardware() map[string]sls_common.GenericHardware {
+	return map[string]sls_common.GenericHardware{
+		"x3000c0s19b2n0": testComputeNode("x3000c0s19b2n0", "x3000c0s19b2", sls_common.ClassRiver, "Compute"),
+		"x3000c0s19b1n0": testComputeNode("x3000c0s19b1n0", "x3000c0s19b1", sls_common.ClassRiver, "Compute"),
+		"x3000c0s1b0n0":  testComputeNode("x3000c0s1b0n0", "x3000c0s1b0", sls_common.ClassRiver, "Management"),
+		"x1000c0s0b0n0":  testComputeNode("x1000c0s0b0n0", "x1000c0s0b0", sls_common.ClassMountain, "Compute"),
+	}
+}
+
+func testRiverCabinetNetwork(cidr string) *IPV4Network {
+	_, cabinet, _ := net.ParseCIDR(cidr)
+	subnet := &IPV4Subnet{Name: "cabinet_3000", CIDR: *cabinet}
+	return &IPV4Network{Name: "HMN_RVR", CIDR: "10.107.0.0/17", Subnets: []*IPV4Subnet{subnet}}
+}
+
+func (suite *ComputeBMCsTestSuite) TestReserveRiverComputeBMCs() {
+	network := testRiverCabinetNetwork("10.107.0.0/22")
+	suite.NoError(ReserveRiverComputeBMCs(network, testComputeHardware()))
+
+	subnet := network.Subnets[0]
+	suite.Equal([]IPReservation{
+		{Name: "x3000c0s19b1", IPAddress: net.ParseIP("10.107.0.2").To4(), Comment: "x3000c0s19b1n0"},
+		{Name: "x3000c0s19b2", IPAddress: net.ParseIP("10.107.0.3").To4(), Comment: "x3000c0s19b2n0"},
+	}, subnet.IPReservations)
+	suite.Equal("10.107.0.10", subnet.DHCPStart.String())
+
+	// Reserving again leaves the subnet as it is
+	suite.NoError(ReserveRiverComputeBMCs(network, testComputeHardware()))
+	suite.Len(subnet.IPReservations, 2)
+}
+
+func (suite *ComputeBMCsTestSuite) TestReserveRiverComputeBMCs_Capacity() {
+	network := testRiverCabinetNetwork("10.107.0.0/30")
+	err := ReserveRiverComputeBMCs(network, testComputeHardware())
+	suite.Equal(errors.New("the cabinet_3000 subnet 10.107.0.0/30 of the HMN_RVR network has room for 1 more reservations but 2 compute BMCs in x3000 need one"), err)
+	suite.Empty(network.Subnets[0].IPReservations)
+}
+
+func (suite *ComputeBMCsTestSuite) TestReserveRiverComputeBMCs_MissingSubnet() {
+	network := &IPV4Network{Name: "HMN_RVR", CIDR: "10.107.0.0/17"}
+	err := ReserveRiverComputeBMCs(network, testComputeHardware())
+	suite.Equal(errors.New("unable to reserve the compute BMCs of x3000: no cabinet_3000 subnet in the HMN_RVR network"), err)
+}
+
+func TestComputeBMCsTestSuite(t *testing.T) {
+	suite.Run(t, new(ComputeBMCsTestSuite))
+}
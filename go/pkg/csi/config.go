@@ -5,7 +5,13 @@ Copyright 2021 Hewlett Packard Enterprise Development LP
 package csi
 
 import (
+	"errors"
+	"fmt"
 	"net"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
 )
 
 // BootstrapSwitchMetadata is a type that matches the switch_metadata.csv file as
@@ -54,3 +60,61 @@ type InstallConfig struct {
 	ChartRepo           string `desc:"Upstream chart repo for use during the install" valid:"url"`
 	DockerImageRegistry string `desc:"Upstream docker registry for use during the install" valid:"url"`
 }
+
+// privateIPV4Blocks are the address ranges that are reachable without internet access
+var privateIPV4Blocks = []string{
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+}
+
+// ParseIPV4Resolvers trims and validates a list of resolvers, preserving their order.
+// Entries may themselves be comma separated lists. When airGapped is set, a warning is returned
+// for every resolver outside of the private address ranges since it will not be reachable.
+func ParseIPV4Resolvers(resolvers []string, airGapped bool) ([]string, []string, error) {
+	var parsed []string
+	var warnings []string
+	for _, entry := range resolvers {
+		for _, resolver := range strings.Split(entry, ",") {
+			resolver = strings.TrimSpace(resolver)
+			if resolver == "" {
+				continue
+			}
+			ip := net.ParseIP(resolver)
+			if ip == nil || ip.To4() == nil {
+				return nil, nil, fmt.Errorf("invalid ipv4 resolver: %q", resolver)
+			}
+			if airGapped && !isPrivateIPV4(ip) {
+				warnings = append(warnings, fmt.Sprintf("public resolver %v will not be reachable on an air-gapped system", ip))
+			}
+			parsed = append(parsed, ip.String())
+		}
+	}
+	return parsed, warnings, nil
+}
+
+func isPrivateIPV4(ip net.IP) bool {
+	for _, block := range privateIPV4Blocks {
+		_, privateNet, _ := net.ParseCIDR(block)
+		if privateNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// OutputDirectory resolves the directory config init writes into, output-dir when it is set and otherwise a
+// directory named after system-name in the current working directory.  The path is absolute so it can be
+// reported as is.
+func OutputDirectory(v *viper.Viper) (string, error) {
+	dir := v.GetString("output-dir")
+	if dir == "" {
+		dir = v.GetString("system-name")
+	}
+	if dir == "" {
+		return "", errors.New("either output-dir or system-name must be set")
+	}
+	return filepath.Abs(dir)
+}
@@ -0,0 +1,64 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+)
+
+type SystemConfigTestSuite struct {
+	suite.Suite
+}
+
+func (suite *SystemConfigTestSuite) TestParseIPV4Resolvers() {
+	resolvers, warnings, err := ParseIPV4Resolvers([]string{" 10.92.100.225 ,8.8.8.8,  ", "9.9.9.9"}, false)
+	suite.NoError(err)
+	suite.Empty(warnings)
+	suite.Equal([]string{"10.92.100.225", "8.8.8.8", "9.9.9.9"}, resolvers)
+}
+
+func (suite *SystemConfigTestSuite) TestParseIPV4Resolvers_Invalid() {
+	_, _, err := ParseIPV4Resolvers([]string{"8.8.8.8,  dns.example.com "}, false)
+	suite.Equal(errors.New(`invalid ipv4 resolver: "dns.example.com"`), err)
+}
+
+func (suite *SystemConfigTestSuite) TestParseIPV4Resolvers_AirGapped() {
+	resolvers, warnings, err := ParseIPV4Resolvers([]string{"10.92.100.225, 8.8.8.8"}, true)
+	suite.NoError(err)
+	suite.Equal([]string{"10.92.100.225", "8.8.8.8"}, resolvers)
+	suite.Equal([]string{"public resolver 8.8.8.8 will not be reachable on an air-gapped system"}, warnings)
+}
+
+func (suite *SystemConfigTestSuite) TestOutputDirectory() {
+	cwd, err := os.Getwd()
+	suite.NoError(err)
+
+	v := viper.New()
+	v.Set("system-name", "eniac")
+	dir, err := OutputDirectory(v)
+	suite.NoError(err)
+	suite.Equal(filepath.Join(cwd, "eniac"), dir)
+
+	v.Set("output-dir", "build/artifacts")
+	dir, err = OutputDirectory(v)
+	suite.NoError(err)
+	suite.Equal(filepath.Join(cwd, "build", "artifacts"), dir)
+
+	_, err = OutputDirectory(viper.New())
+	suite.Equal(errors.New("either output-dir or system-name must be set"), err)
+}
+
+func TestSystemConfigTestSuite(t *testing.T) {
+	suite.Run(t, new(SystemConfigTestSuite))
+}
@@ -24,7 +24,7 @@
 package csi
 
 import (
-	"log"
+	"fmt"
 	"net"
 	"strings"
 
@@ -115,6 +115,9 @@ const SubrolePlaceHolder = "~fixme~"
 // ValidNetNames is the list of strings that enumerate valid main network names
 var ValidNetNames = []string{"BICAN", "CAN", "CHN", "CMN", "HMN", "HMN_MTN", "HMN_RVR", "MTL", "NMN", "NMN_MTN", "NMN_RVR"}
 
+// DefaultRGWVIPNetworks are the networks the Ceph RGW virtual IP is reserved on when rgw-vip-networks is not set
+var DefaultRGWVIPNetworks = []string{"NMN"}
+
 // ValidCabinetTypes is the list of strings that enumerate valid cabinet types
 var ValidCabinetTypes = []string{"mountain", "river", "hill"}
 
@@ -149,6 +152,12 @@ func IPNetfromCIDRString(mynet string) *net.IPNet {
 // DefaultCabinetMask is the default subnet mask for each cabinet
 var DefaultCabinetMask = net.CIDRMask(22, 32)
 
+// DefaultMinimumCabinetSubnetMask is the smallest cabinet subnet (largest prefix length) generated without a warning
+const DefaultMinimumCabinetSubnetMask = 27
+
+// DefaultSubnetMask6 is the subnet mask for the IPv6 half of every subnet on a dual-stack network
+var DefaultSubnetMask6 = net.CIDRMask(64, 128)
+
 // DefaultNetworkingHardwareMask is the default subnet mask for a subnet that contains all networking hardware
 var DefaultNetworkingHardwareMask = net.CIDRMask(24, 32)
 
@@ -324,19 +333,19 @@ func GenDefaultHSNConfig() NetworkLayoutConfiguration {
 }
 
 // GenDefaultCMNConfig returns the set of defaults for mapping the CMN
-func GenDefaultCMNConfig(ncns int, switches int) NetworkLayoutConfiguration {
+func GenDefaultCMNConfig(ncns int, switches int) (NetworkLayoutConfiguration, error) {
 	_, cmnNet, _ := net.ParseCIDR(DefaultCMN.CIDR)
 
 	// Dynamically calculate the bootstrap_dhcp netmask based on number of NCNs.
 	bootstrapSubnet, err := ipam.SubnetWithin(*cmnNet, ncns)
 	if err != nil {
-		log.Fatalf("Failed to find a suitable subnet mask for %d NCNs within %v\n", ncns, DefaultCMN.Name)
+		return NetworkLayoutConfiguration{}, fmt.Errorf("failed to find a suitable subnet mask for %d NCNs within %v", ncns, DefaultCMN.Name)
 	}
 
 	// Dynamically calculate the network_hardware netmask based on number of NCNs.
 	networkSubnet, err := ipam.SubnetWithin(*cmnNet, switches)
 	if err != nil {
-		log.Fatalf("Failed to find a suitable subnet mask for %d switches within %v\n", switches, DefaultCMN.Name)
+		return NetworkLayoutConfiguration{}, fmt.Errorf("failed to find a suitable subnet mask for %d switches within %v", switches, DefaultCMN.Name)
 	}
 
 	return NetworkLayoutConfiguration{
@@ -348,7 +357,7 @@ func GenDefaultCMNConfig(ncns int, switches int) NetworkLayoutConfiguration {
 		IncludeUAISubnet:                false,
 		NetworkingHardwareNetmask:       networkSubnet.Mask,
 		DesiredBootstrapDHCPMask:        bootstrapSubnet.Mask,
-	}
+	}, nil
 }
 
 // GenDefaultCANConfig returns the set of defaults for mapping the CAN
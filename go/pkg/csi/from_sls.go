@@ -0,0 +1,120 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
+	"github.com/mitchellh/mapstructure"
+)
+
+// SLSConfigInputs are the csi config init inputs that can be recovered from an existing SLS state.
+// SLS does not record MAC addresses so the NCNs have none.
+type SLSConfigInputs struct {
+	NCNs     []NewBootstrapNCNMetadata
+	Switches []ManagementSwitch
+	Cabinets CabinetDetailFile
+}
+
+// ExtractSLSConfigInputs reverses SLS generation, rebuilding ncn_metadata.csv from the Management nodes,
+// switch_metadata.csv from the management switches and the cabinet detail file from the cabinets
+func ExtractSLSConfigInputs(sls *sls_common.SLSState) (SLSConfigInputs, error) {
+	var inputs SLSConfigInputs
+
+	ncns, err := ExtractSLSNCNs(sls)
+	if err != nil {
+		return inputs, err
+	}
+	sort.Slice(ncns, func(i, j int) bool {
+		return ncns[i].Xname < ncns[j].Xname
+	})
+	for _, ncn := range ncns {
+		inputs.NCNs = append(inputs.NCNs, NewBootstrapNCNMetadata{
+			Xname:   ncn.Xname,
+			Role:    ncn.Role,
+			Subrole: ncn.Subrole,
+		})
+	}
+
+	if inputs.Switches, err = ExtractSLSSwitches(sls); err != nil {
+		return inputs, err
+	}
+
+	if inputs.Cabinets, err = ExtractSLSCabinetDetails(sls); err != nil {
+		return inputs, err
+	}
+	return inputs, nil
+}
+
+// ExtractSLSCabinetDetails groups the cabinets in SLS by class into a cabinet detail file, the inverse of
+// the cabinet map used to generate SLS.  The NMN and HMN subnets and vlans of each cabinet are kept.
+func ExtractSLSCabinetDetails(sls *sls_common.SLSState) (CabinetDetailFile, error) {
+	groups := map[string]*CabinetGroupDetail{}
+	for key, hardware := range sls.Hardware {
+		if hardware.Type != sls_common.Cabinet {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(key, "x"))
+		if err != nil {
+			return CabinetDetailFile{}, fmt.Errorf("unable to find the cabinet id of %v: %v", key, err)
+		}
+		var extra sls_common.ComptypeCabinet
+		if err := mapstructure.Decode(hardware.ExtraPropertiesRaw, &extra); err != nil {
+			return CabinetDetailFile{}, err
+		}
+		cabinet := CabinetDetail{ID: id}
+		networks, ok := extra.Networks["cn"]
+		if !ok {
+			networks = extra.Networks["ncn"]
+		}
+		if nmn, ok := networks["NMN"]; ok {
+			cabinet.NMNSubnet = nmn.CIDR
+			cabinet.NMNVlanID = int16(nmn.VLan)
+		}
+		if hmn, ok := networks["HMN"]; ok {
+			cabinet.HMNSubnet = hmn.CIDR
+			cabinet.HMNVlanID = int16(hmn.VLan)
+		}
+
+		kind := strings.ToLower(string(hardware.Class))
+		group, ok := groups[kind]
+		if !ok {
+			group = &CabinetGroupDetail{Kind: kind}
+			groups[kind] = group
+		}
+		group.CabinetDetails = append(group.CabinetDetails, cabinet)
+	}
+
+	var cabinetFile CabinetDetailFile
+	for _, group := range groups {
+		sort.Slice(group.CabinetDetails, func(i, j int) bool {
+			return group.CabinetDetails[i].ID < group.CabinetDetails[j].ID
+		})
+		group.Cabinets = len(group.CabinetDetails)
+		group.StartingCabinet = group.CabinetDetails[0].ID
+		cabinetFile.Cabinets = append(cabinetFile.Cabinets, *group)
+	}
+	sort.Slice(cabinetFile.Cabinets, func(i, j int) bool {
+		return cabinetFile.Cabinets[i].Kind < cabinetFile.Cabinets[j].Kind
+	})
+	return cabinetFile, nil
+}
+
+// WriteSLSConfigInputs writes ncn_metadata.csv, switch_metadata.csv and cabinets.yaml into dir
+func WriteSLSConfigInputs(dir string, inputs SLSConfigInputs) error {
+	if err := csiFiles.WriteCSVConfig(filepath.Join(dir, "ncn_metadata.csv"), inputs.NCNs); err != nil {
+		return err
+	}
+	if err := csiFiles.WriteCSVConfig(filepath.Join(dir, "switch_metadata.csv"), inputs.Switches); err != nil {
+		return err
+	}
+	return csiFiles.WriteYAMLConfig(filepath.Join(dir, "cabinets.yaml"), inputs.Cabinets)
+}
@@ -0,0 +1,119 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
+	"github.com/stretchr/testify/suite"
+)
+
+type FromSLSTestSuite struct {
+	suite.Suite
+}
+
+func testFromSLSState() *sls_common.SLSState {
+	return &sls_common.SLSState{
+		Hardware: map[string]sls_common.GenericHardware{
+			"x3000": {Xname: "x3000", Type: sls_common.Cabinet, Class: sls_common.ClassRiver,
+				ExtraPropertiesRaw: map[string]interface{}{"Networks": map[string]interface{}{"cn": map[string]interface{}{
+					"NMN": map[string]interface{}{"CIDR": "10.106.0.0/22", "VLan": 1770},
+					"HMN": map[string]interface{}{"CIDR": "10.107.0.0/22", "VLan": 1513},
+				}}}},
+			"x1000": {Xname: "x1000", Type: sls_common.Cabinet, Class: sls_common.ClassMountain},
+			"x1001": {Xname: "x1001", Type: sls_common.Cabinet, Class: sls_common.ClassMountain},
+
+			"x3000c0s1b0n0": {Xname: "x3000c0s1b0n0", Type: sls_common.Node, Class: sls_common.ClassRiver,
+				ExtraPropertiesRaw: sls_common.ComptypeNode{Role: "Management", SubRole: "Master", Aliases: []string{"ncn-m001"}}},
+			"x3000c0s7b0n0": {Xname: "x3000c0s7b0n0", Type: sls_common.Node, Class: sls_common.ClassRiver,
+				ExtraPropertiesRaw: sls_common.ComptypeNode{Role: "Management", SubRole: "Worker", Aliases: []string{"ncn-w001"}}},
+			"x3000c0s19b1n0": {Xname: "x3000c0s19b1n0", Type: sls_common.Node, Class: sls_common.ClassRiver,
+				ExtraPropertiesRaw: sls_common.ComptypeNode{Role: "Compute", Aliases: []string{"nid000001"}}},
+
+			"x3000c0h33s1": {Xname: "x3000c0h33s1", Type: sls_common.MgmtHLSwitch, Class: sls_common.ClassRiver,
+				ExtraPropertiesRaw: sls_common.ComptypeMgmtHLSwitch{Brand: "Aruba", Model: "8325", IP4Addr: "10.254.0.2", Aliases: []string{"sw-spine-001"}}},
+			"x3000c0w14": {Xname: "x3000c0w14", Type: sls_common.MgmtSwitch, Class: sls_common.ClassRiver,
+				ExtraPropertiesRaw: sls_common.ComptypeMgmtSwitch{Brand: "Dell", Aliases: []string{"sw-leaf-bmc-001"}}},
+			"d0w1": {Xname: "d0w1", Type: sls_common.CDUMgmtSwitch, Class: sls_common.ClassMountain,
+				ExtraPropertiesRaw: sls_common.ComptypeCDUMgmtSwitch{Brand: "Aruba", Aliases: []string{"sw-cdu-001"}}},
+		},
+	}
+}
+
+func (suite *FromSLSTestSuite) TestExtractSLSConfigInputs() {
+	inputs, err := ExtractSLSConfigInputs(testFromSLSState())
+	suite.NoError(err)
+
+	suite.Equal([]NewBootstrapNCNMetadata{
+		{Xname: "x3000c0s1b0n0", Role: "Management", Subrole: "Master"},
+		{Xname: "x3000c0s7b0n0", Role: "Management", Subrole: "Worker"},
+	}, inputs.NCNs)
+
+	suite.Len(inputs.Switches, 3)
+	suite.Equal("d0w1", inputs.Switches[0].Xname)
+	suite.Equal(ManagementSwitchTypeCDU, inputs.Switches[0].SwitchType)
+	suite.Equal(ManagementSwitchTypeSpine, inputs.Switches[1].SwitchType)
+	suite.Equal(ManagementSwitchBrandAruba, inputs.Switches[1].Brand)
+	suite.Equal("sw-spine-001", inputs.Switches[1].Name)
+	suite.Equal("10.254.0.2", inputs.Switches[1].ManagementInterface.String())
+	suite.Equal(ManagementSwitchTypeLeafBMC, inputs.Switches[2].SwitchType)
+
+	suite.Equal(CabinetDetailFile{Cabinets: []CabinetGroupDetail{
+		{Kind: "mountain", Cabinets: 2, StartingCabinet: 1000, CabinetDetails: []CabinetDetail{{ID: 1000}, {ID: 1001}}},
+		{Kind: "river", Cabinets: 1, StartingCabinet: 3000, CabinetDetails: []CabinetDetail{
+			{ID: 3000, NMNSubnet: "10.106.0.0/22", NMNVlanID: 1770, HMNSubnet: "10.107.0.0/22", HMNVlanID: 1513},
+		}},
+	}}, inputs.Cabinets)
+}
+
+func (suite *FromSLSTestSuite) TestWriteSLSConfigInputs_RoundTrip() {
+	dir, err := ioutil.TempDir("", "from-sls")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+
+	inputs, err := ExtractSLSConfigInputs(testFromSLSState())
+	suite.NoError(err)
+	suite.NoError(WriteSLSConfigInputs(dir, inputs))
+
+	ncns, err := ReadNodeCSV(filepath.Join(dir, "ncn_metadata.csv"))
+	suite.NoError(err)
+	suite.Len(ncns, 2)
+	suite.Equal("x3000c0s7b0n0", ncns[1].Xname)
+	suite.Equal("Worker", ncns[1].Subrole)
+
+	switches, err := ReadSwitchCSV(filepath.Join(dir, "switch_metadata.csv"))
+	suite.NoError(err)
+	suite.Len(switches, 3)
+	for i, mySwitch := range switches {
+		suite.Equal(inputs.Switches[i].Xname, mySwitch.Xname)
+		suite.Equal(inputs.Switches[i].SwitchType, mySwitch.SwitchType)
+		suite.Equal(inputs.Switches[i].Brand, mySwitch.Brand)
+	}
+
+	cabinets, err := LoadCabinetDetailFile(filepath.Join(dir, "cabinets.yaml"))
+	suite.NoError(err)
+	suite.Equal(inputs.Cabinets, cabinets)
+}
+
+func (suite *FromSLSTestSuite) TestExtractSLSSwitches_UnknownType() {
+	state := &sls_common.SLSState{Hardware: map[string]sls_common.GenericHardware{
+		"x3000c0h35s1": {Xname: "x3000c0h35s1", Type: sls_common.MgmtHLSwitch,
+			ExtraPropertiesRaw: sls_common.ComptypeMgmtHLSwitch{Aliases: []string{"sw-core-001"}}},
+	}}
+	_, err := ExtractSLSSwitches(state)
+	suite.Equal(errors.New("unable to determine the type of switch x3000c0h35s1: no sw-spine, sw-leaf or sw-edge alias in [sw-core-001]"), err)
+}
+
+func TestFromSLSTestSuite(t *testing.T) {
+	suite.Run(t, new(FromSLSTestSuite))
+}
@@ -6,9 +6,10 @@ package csi
 
 import (
 	"crypto/rand"
+	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"os"
 
@@ -151,7 +152,30 @@ type NCNInterface struct {
 	Usage         string `json:"usage" csv:"-"`
 }
 
-// ReadNodeCSV parses a CSV file into a list of NCN_bootstrap nodes for use by the installer
+// NCNMetadataError points at the row and column of ncn_metadata.csv that could not be parsed.
+// Row is 1-based and does not count the header, so row 1 is on line 2 of the file.
+type NCNMetadataError struct {
+	Row    int
+	Column string
+	Value  string
+	Err    error
+}
+
+func (e *NCNMetadataError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("ncn_metadata row %d (line %d): %v", e.Row, e.Row+1, e.Err)
+	}
+	return fmt.Sprintf("ncn_metadata row %d (line %d): invalid %s %q: %v", e.Row, e.Row+1, e.Column, e.Value, e.Err)
+}
+
+// Unwrap returns the underlying parse error
+func (e *NCNMetadataError) Unwrap() error {
+	return e.Err
+}
+
+// ReadNodeCSV parses a CSV file into a list of NCN_bootstrap nodes for use by the installer.
+// The header decides whether the file is in the 1.4 format or the older one so that errors are
+// reported against the format the file is actually in.
 func ReadNodeCSV(filename string) ([]*LogicalNCN, error) {
 	nodes := []*LogicalNCN{}
 	newNodes := []*NewBootstrapNCNMetadata{}
@@ -161,38 +185,73 @@ func ReadNodeCSV(filename string) ([]*LogicalNCN, error) {
 		return nodes, err
 	}
 	defer ncnMetadataFile.Close()
-	// In 1.4, we have a new format for this file.  Try that first and then fall back to the older style if necessary
-	newErr := gocsv.UnmarshalFile(ncnMetadataFile, &newNodes)
-	if newErr == nil {
-		for _, node := range newNodes {
-			// log.Println("Appending ", node)
-			nodes = append(nodes, &LogicalNCN{
-				Xname:     node.Xname,
-				Role:      node.Role,
-				Subrole:   node.Subrole,
-				BmcMac:    node.BmcMac,
-				NmnMac:    node.BootstrapMac,
-				Bond0Mac0: node.Bond0Mac0,
-				Bond0Mac1: node.Bond0Mac1,
-			})
-		}
-		return nodes, nil
-	}
 
+	header, err := csv.NewReader(ncnMetadataFile).Read()
+	if err != nil {
+		return nodes, fmt.Errorf("unable to read the ncn_metadata header: %v", err)
+	}
 	// Be Kind Rewind https://www.imdb.com/title/tt0799934/
 	ncnMetadataFile.Seek(0, io.SeekStart)
-	err = gocsv.UnmarshalFile(ncnMetadataFile, &nodes)
-	if err == nil { // Load nodes from file
+
+	// In 1.4, we have a new format for this file.  Fall back to the older style when the header doesn't match.
+	if !stringInSlice("Xname", header) {
+		if err := gocsv.UnmarshalFile(ncnMetadataFile, &nodes); err != nil {
+			return nodes, fmt.Errorf("unable to parse ncn_metadata with old format because %v", ncnMetadataParseError(err))
+		}
+		for i, node := range nodes {
+			if err := validateNCNMetadataMACs(i+1, map[string]string{"BMC MAC": node.BmcMac, "NMN MAC": node.NmnMac}); err != nil {
+				return nodes, err
+			}
+		}
 		return nodes, nil
 	}
 
-	if newErr != nil {
-		if err != nil {
-			log.Println("Unable to parse ncn_metadata with new style because ", newErr)
-			log.Fatal("Unable to parse ncn_metadata with old format because ", err)
+	if err := gocsv.UnmarshalFile(ncnMetadataFile, &newNodes); err != nil {
+		return nodes, fmt.Errorf("unable to parse ncn_metadata with new style because %v", ncnMetadataParseError(err))
+	}
+	for i, node := range newNodes {
+		macs := map[string]string{
+			"BMC MAC":       node.BmcMac,
+			"Bootstrap MAC": node.BootstrapMac,
+			"Bond0 MAC0":    node.Bond0Mac0,
+			"Bond0 MAC1":    node.Bond0Mac1,
 		}
-		log.Fatal("Unable to parse ncn_metadata with new style because ", newErr)
+		if err := validateNCNMetadataMACs(i+1, macs); err != nil {
+			return nodes, err
+		}
+		// log.Println("Appending ", node)
+		nodes = append(nodes, &LogicalNCN{
+			Xname:     node.Xname,
+			Role:      node.Role,
+			Subrole:   node.Subrole,
+			BmcMac:    node.BmcMac,
+			NmnMac:    node.BootstrapMac,
+			Bond0Mac0: node.Bond0Mac0,
+			Bond0Mac1: node.Bond0Mac1,
+		})
+	}
+	return nodes, nil
+}
+
+// ncnMetadataParseError converts the line of a csv error into the row it is on
+func ncnMetadataParseError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return &NCNMetadataError{Row: parseErr.Line - 1, Err: parseErr.Err}
 	}
+	return err
+}
 
-	return nodes, err
+// validateNCNMetadataMACs checks the MAC columns of a row in a fixed order so the first bad column is reported
+func validateNCNMetadataMACs(row int, macs map[string]string) error {
+	for _, column := range []string{"BMC MAC", "Bootstrap MAC", "NMN MAC", "Bond0 MAC0", "Bond0 MAC1"} {
+		mac, ok := macs[column]
+		if !ok || mac == "" {
+			continue
+		}
+		if _, err := net.ParseMAC(mac); err != nil {
+			return &NCNMetadataError{Row: row, Column: column, Value: mac, Err: err}
+		}
+	}
+	return nil
 }
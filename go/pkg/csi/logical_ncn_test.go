@@ -9,6 +9,9 @@ package csi
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
@@ -132,6 +135,74 @@ func (suite *NCNBootStrapTestSuite) TestLogicalNCNNormalize() {
 	}
 }
 
+func writeTestNCNMetadata(suite *NCNBootStrapTestSuite, contents string) (string, func()) {
+	dir, err := ioutil.TempDir("", "ncn_metadata")
+	suite.NoError(err)
+	path := filepath.Join(dir, "ncn_metadata.csv")
+	suite.NoError(ioutil.WriteFile(path, []byte(contents), 0644))
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func (suite *NCNBootStrapTestSuite) TestReadNodeCSV() {
+	path, cleanup := writeTestNCNMetadata(suite, `Xname,Role,Subrole,BMC MAC,Bootstrap MAC,Bond0 MAC0,Bond0 MAC1
+x3000c0s1b0n0,Management,Master,94:40:c9:37:77:26,14:02:ec:d9:76:88,14:02:ec:d9:76:88,94:40:c9:5f:b5:df
+`)
+	defer cleanup()
+
+	nodes, err := ReadNodeCSV(path)
+	suite.NoError(err)
+	suite.Equal([]*LogicalNCN{{
+		Xname:     "x3000c0s1b0n0",
+		Role:      "Management",
+		Subrole:   "Master",
+		BmcMac:    "94:40:c9:37:77:26",
+		NmnMac:    "14:02:ec:d9:76:88",
+		Bond0Mac0: "14:02:ec:d9:76:88",
+		Bond0Mac1: "94:40:c9:5f:b5:df",
+	}}, nodes)
+}
+
+func (suite *NCNBootStrapTestSuite) TestReadNodeCSV_BadMACRow5() {
+	path, cleanup := writeTestNCNMetadata(suite, `Xname,Role,Subrole,BMC MAC,Bootstrap MAC,Bond0 MAC0,Bond0 MAC1
+x3000c0s1b0n0,Management,Master,94:40:c9:37:77:26,14:02:ec:d9:76:88,14:02:ec:d9:76:88,94:40:c9:5f:b5:df
+x3000c0s3b0n0,Management,Master,94:40:c9:37:87:5a,14:02:ec:d9:79:e8,14:02:ec:d9:79:e8,94:40:c9:5f:b6:92
+x3000c0s5b0n0,Management,Master,94:40:c9:37:67:60,14:02:ec:d9:7a:38,14:02:ec:d9:7a:38,94:40:c9:5f:a3:a8
+x3000c0s7b0n0,Management,Worker,94:40:c9:37:04:84,14:02:ec:d9:7b:10,14:02:ec:d9:7b:10,94:40:c9:5f:b5:cc
+x3000c0s9b0n0,Management,Worker,94:40:c9:37:f9:b4,14:02:ec:da:b8:4g,14:02:ec:da:b8:40,94:40:c9:5f:a3:d8
+x3000c0s11b0n0,Management,Worker,94:40:c9:37:77:b8,14:02:ec:da:b9:88,14:02:ec:da:b9:88,94:40:c9:5f:9a:98
+`)
+	defer cleanup()
+
+	_, err := ReadNodeCSV(path)
+	var metadataErr *NCNMetadataError
+	suite.True(errors.As(err, &metadataErr))
+	suite.Equal(5, metadataErr.Row)
+	suite.Equal("Bootstrap MAC", metadataErr.Column)
+	suite.EqualError(err, `ncn_metadata row 5 (line 6): invalid Bootstrap MAC "14:02:ec:da:b8:4g": address 14:02:ec:da:b8:4g: invalid MAC address`)
+}
+
+func (suite *NCNBootStrapTestSuite) TestReadNodeCSV_OldFormat() {
+	path, cleanup := writeTestNCNMetadata(suite, `NCN xname,NCN Role,NCN Subrole,BMC MAC,BMC Switch Port,NMN MAC,NMN Switch Port
+x3000c0s1b0n0,Management,Master,94:40:c9:37:77:26,x3000c0w14j1,14:02:ec:d9:76:88,x3000c0h33s1j1
+x3000c0s3b0n0,Management,Master,BMC-MAC,x3000c0w14j3,14:02:ec:d9:79:e8,x3000c0h33s1j3
+`)
+	defer cleanup()
+
+	_, err := ReadNodeCSV(path)
+	suite.EqualError(err, `ncn_metadata row 2 (line 3): invalid BMC MAC "BMC-MAC": address BMC-MAC: invalid MAC address`)
+}
+
+func (suite *NCNBootStrapTestSuite) TestReadNodeCSV_MalformedRow() {
+	path, cleanup := writeTestNCNMetadata(suite, `Xname,Role,Subrole,BMC MAC,Bootstrap MAC,Bond0 MAC0,Bond0 MAC1
+x3000c0s1b0n0,Management,Master,94:40:c9:37:77:26,14:02:ec:d9:76:88,14:02:ec:d9:76:88,94:40:c9:5f:b5:df
+x3000c0s3b0n0,Management,Master,94:40:c9:37:87:5a
+`)
+	defer cleanup()
+
+	_, err := ReadNodeCSV(path)
+	suite.EqualError(err, "unable to parse ncn_metadata with new style because ncn_metadata row 2 (line 3): wrong number of fields")
+}
+
 func TestNCNBootStrapTestSuite(t *testing.T) {
 	suite.Run(t, new(NCNBootStrapTestSuite))
 }
@@ -0,0 +1,88 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	base "github.com/Cray-HPE/hms-base"
+)
+
+// ncnXnamePattern splits a node xname into its chassis, slot, bmc and node
+var ncnXnamePattern = regexp.MustCompile(`^x[0-9]+c([0-9]+)s([0-9]+)b([0-9]+)n([0-9]+)$`)
+
+// NCNTableRow is an NCN with its xname decomposed into its location
+type NCNTableRow struct {
+	Hostname string `json:"hostname"`
+	Xname    string `json:"xname"`
+	Cabinet  string `json:"cabinet"`
+	Chassis  int    `json:"chassis"`
+	Slot     int    `json:"slot"`
+	Node     int    `json:"node"`
+	Role     string `json:"role"`
+	Subrole  string `json:"subrole"`
+}
+
+// MakeNCNTableRow decomposes the xname of an NCN
+func MakeNCNTableRow(ncn LogicalNCN) (NCNTableRow, error) {
+	xname := base.NormalizeHMSCompID(ncn.Xname)
+	matches := ncnXnamePattern.FindStringSubmatch(xname)
+	if matches == nil {
+		return NCNTableRow{}, fmt.Errorf("%s is not a node xname", ncn.Xname)
+	}
+	cabinet, err := CabinetForXname(xname)
+	if err != nil {
+		return NCNTableRow{}, err
+	}
+	row := NCNTableRow{
+		Hostname: ncn.Hostname,
+		Xname:    xname,
+		Cabinet:  cabinet,
+		Role:     ncn.Role,
+		Subrole:  ncn.Subrole,
+	}
+	// The pattern only matches digits so these can't fail
+	row.Chassis, _ = strconv.Atoi(matches[1])
+	row.Slot, _ = strconv.Atoi(matches[2])
+	row.Node, _ = strconv.Atoi(matches[4])
+	return row, nil
+}
+
+// MakeNCNTable builds a row for each NCN from ncn_metadata, sorted by hostname.  The hostnames come from the
+// matching NCNs in SLS, and NCNs that are not in SLS fall back to their xname.
+func MakeNCNTable(metadataNCNs []LogicalNCN, slsNCNs []LogicalNCN) ([]NCNTableRow, error) {
+	matches, _ := MatchSLSNCNs(metadataNCNs, slsNCNs)
+	var rows []NCNTableRow
+	for _, ncn := range metadataNCNs {
+		if slsNCN, ok := matches[ncn.Xname]; ok && ncn.Hostname == "" {
+			ncn.Hostname = slsNCN.Hostname
+		}
+		ncn.Hostname = ncn.GetHostname()
+		row, err := MakeNCNTableRow(ncn)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Hostname < rows[j].Hostname
+	})
+	return rows, nil
+}
+
+// WriteNCNTable writes the rows to w as a table
+func WriteNCNTable(w io.Writer, rows []NCNTableRow) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOSTNAME\tXNAME\tCABINET\tCHASSIS\tSLOT\tNODE\tROLE\tSUBROLE\t")
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s\t\n", row.Hostname, row.Xname, row.Cabinet, row.Chassis, row.Slot, row.Node, row.Role, row.Subrole)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,66 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type NCNTableTestSuite struct {
+	suite.Suite
+}
+
+func (suite *NCNTableTestSuite) TestMakeNCNTableRow() {
+	row, err := MakeNCNTableRow(LogicalNCN{Xname: "x3000c0s07b0n0", Hostname: "ncn-w001", Role: "Management", Subrole: "Worker"})
+	suite.NoError(err)
+	suite.Equal(NCNTableRow{
+		Hostname: "ncn-w001",
+		Xname:    "x3000c0s7b0n0",
+		Cabinet:  "x3000",
+		Chassis:  0,
+		Slot:     7,
+		Node:     0,
+		Role:     "Management",
+		Subrole:  "Worker",
+	}, row)
+}
+
+func (suite *NCNTableTestSuite) TestMakeNCNTableRow_NotANode() {
+	_, err := MakeNCNTableRow(LogicalNCN{Xname: "x3000c0s7b0"})
+	suite.Equal(errors.New("x3000c0s7b0 is not a node xname"), err)
+}
+
+func (suite *NCNTableTestSuite) TestMakeNCNTable() {
+	metadata := []LogicalNCN{
+		{Xname: "x3000c0s9b0n0", Role: "Management", Subrole: "Worker"},
+		{Xname: "x3000c0s1b0n0", Role: "Management", Subrole: "Master"},
+		{Xname: "x3001c0s3b0n1", Role: "Management", Subrole: "Storage"},
+	}
+	sls := []LogicalNCN{
+		{Xname: "x3000c0s9b0n0", Hostname: "ncn-w001"},
+		{Xname: "x3000c0s1b0n0", Hostname: "ncn-m001"},
+	}
+
+	rows, err := MakeNCNTable(metadata, sls)
+	suite.NoError(err)
+	suite.Equal([]string{"ncn-m001", "ncn-w001", "x3001c0s3b0n1"}, []string{rows[0].Hostname, rows[1].Hostname, rows[2].Hostname})
+	suite.Equal("x3001", rows[2].Cabinet)
+	suite.Equal(1, rows[2].Node)
+
+	var bs bytes.Buffer
+	suite.NoError(WriteNCNTable(&bs, rows))
+	suite.Contains(bs.String(), "ncn-w001       x3000c0s9b0n0  x3000    0        9     0     Management  Worker")
+}
+
+func TestNCNTableTestSuite(t *testing.T) {
+	suite.Run(t, new(NCNTableTestSuite))
+}
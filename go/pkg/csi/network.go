@@ -24,14 +24,18 @@
 package csi
 
 import (
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
-	"log"
 	"net"
+	"sort"
 	"strings"
 
 	"github.com/Cray-HPE/csm-common/go/pkg/ipam"
+	"github.com/Cray-HPE/csm-common/go/pkg/logging"
 	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
 	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
 )
 
 // IPV4Network is a type for managing IPv4 Networks
@@ -47,6 +51,21 @@ type IPV4Network struct {
 	PeerASN            int                    `yaml:"peer-asn"`
 	MyASN              int                    `yaml:"my-asn"`
 	SystemDefaultRoute string                 `yaml:"system_default_route"`
+	// CIDR6 is the optional IPv6 range of a dual-stack network
+	CIDR6 string `yaml:"cidr6,omitempty"`
+	// AllocationDirection controls which end of the network new subnets are carved from
+	AllocationDirection string `yaml:"-"`
+}
+
+// Valid values for IPV4Network.AllocationDirection. An empty value is treated as SubnetAllocationBottom.
+const (
+	SubnetAllocationBottom = "bottom"
+	SubnetAllocationTop    = "top"
+)
+
+// IsSubnetAllocationDirectionValid checks whether the given subnet allocation direction is supported
+func IsSubnetAllocationDirectionValid(direction string) bool {
+	return direction == "" || direction == SubnetAllocationBottom || direction == SubnetAllocationTop
 }
 
 // IPV4Subnet is a type for managing IPv4 Subnets
@@ -66,33 +85,183 @@ type IPV4Subnet struct {
 	ReservationStart net.IP          `yaml:"reservation-start"`
 	ReservationEnd   net.IP          `yaml:"reservation-end"`
 	MetalLBPoolName  string          `yaml:"metallb-pool-name"`
+	// CIDR6 and Gateway6 are only set on the subnets of a dual-stack network
+	CIDR6    net.IPNet `yaml:"cidr6,omitempty"`
+	Gateway6 net.IP    `yaml:"gateway6,omitempty"`
+	// MTU overrides the MTU of the network for this subnet when set
+	MTU int16 `yaml:"mtu,omitempty"`
+	// DHCPEndPadding is the number of addresses at the top of the subnet that are held back from DHCP
+	DHCPEndPadding int `yaml:"dhcp-end-padding,omitempty"`
+	// DHCPExclusions are blocks within the DHCP range that must not be handed out, such as addresses
+	// used by external equipment.  When set, DHCPRanges holds the pieces of the range around them.
+	DHCPExclusions []net.IPNet `yaml:"dhcp-exclusions,omitempty"`
+	DHCPRanges     []DHCPRange `yaml:"dhcp-ranges,omitempty"`
+}
+
+// DHCPRange is an inclusive range of addresses handed out by DHCP
+type DHCPRange struct {
+	Start net.IP `yaml:"start"`
+	End   net.IP `yaml:"end"`
+}
+
+// subnetJSON is the json form of an IPV4Subnet, with the CIDRs as strings instead of the IP and Mask bytes of a
+// net.IPNet.  Its fields shadow those of the embedded subnet so every key keeps its usual name.
+type subnetJSON struct {
+	ipv4Subnet
+	CIDR           string
+	CIDR6          string   `json:",omitempty"`
+	DHCPExclusions []string `json:",omitempty"`
+}
+
+// ipv4Subnet has the fields of IPV4Subnet without its json methods
+type ipv4Subnet IPV4Subnet
+
+// MarshalJSON writes the CIDRs of the subnet as strings such as "10.1.0.0/16"
+func (iSubnet IPV4Subnet) MarshalJSON() ([]byte, error) {
+	out := subnetJSON{ipv4Subnet: ipv4Subnet(iSubnet), CIDR: iSubnet.CIDR.String()}
+	if iSubnet.CIDR6.IP != nil {
+		out.CIDR6 = iSubnet.CIDR6.String()
+	}
+	for _, exclusion := range iSubnet.DHCPExclusions {
+		out.DHCPExclusions = append(out.DHCPExclusions, exclusion.String())
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads a subnet written by MarshalJSON
+func (iSubnet *IPV4Subnet) UnmarshalJSON(data []byte) error {
+	var in subnetJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*iSubnet = IPV4Subnet(in.ipv4Subnet)
+	if in.CIDR != "" {
+		_, cidr, err := net.ParseCIDR(in.CIDR)
+		if err != nil {
+			return fmt.Errorf("invalid cidr for subnet %s: %v", in.Name, err)
+		}
+		iSubnet.CIDR = *cidr
+	}
+	if in.CIDR6 != "" {
+		_, cidr6, err := net.ParseCIDR(in.CIDR6)
+		if err != nil {
+			return fmt.Errorf("invalid cidr6 for subnet %s: %v", in.Name, err)
+		}
+		iSubnet.CIDR6 = *cidr6
+	}
+	for _, exclusion := range in.DHCPExclusions {
+		_, cidr, err := net.ParseCIDR(exclusion)
+		if err != nil {
+			return fmt.Errorf("invalid dhcp exclusion for subnet %s: %v", in.Name, err)
+		}
+		iSubnet.DHCPExclusions = append(iSubnet.DHCPExclusions, *cidr)
+	}
+	return nil
+}
+
+// MarshalSubnet renders a subnet as yaml, the default, or json
+func MarshalSubnet(subnet *IPV4Subnet, format string) ([]byte, error) {
+	switch format {
+	case "yaml", "":
+		return yaml.Marshal(subnet)
+	case "json":
+		return json.MarshalIndent(subnet, "", "  ")
+	}
+	return nil, fmt.Errorf("unknown subnet output format %q, must be yaml or json", format)
 }
 
 // IPReservation is a type for managing IP Reservations
 type IPReservation struct {
-	IPAddress net.IP   `yaml:"ip_address"`
-	Name      string   `yaml:"name"`
-	Comment   string   `yaml:"comment"`
-	Aliases   []string `yaml:"aliases"`
+	IPAddress   net.IP   `yaml:"ip_address"`
+	IPv6Address net.IP   `yaml:"ipv6_address,omitempty"`
+	Name        string   `yaml:"name"`
+	Comment     string   `yaml:"comment"`
+	Aliases     []string `yaml:"aliases"`
+}
+
+// cabinetSubnetPin returns the subnet a cabinet is pinned to on this network, if any
+func (iNet IPV4Network) cabinetSubnetPin(cabinet CabinetDetail) string {
+	if strings.HasPrefix(iNet.Name, "NMN") {
+		return cabinet.NMNSubnet
+	}
+	if strings.HasPrefix(iNet.Name, "HMN") {
+		return cabinet.HMNSubnet
+	}
+	return ""
+}
+
+func overlaps(a, b net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// pinnedCabinetSubnets parses the subnets the cabinets of cabinetType are pinned to and verifies that each one
+// is within the network and clear of the allocated subnets and of every other pin
+func (iNet IPV4Network) pinnedCabinetSubnets(cabinetDetails []CabinetGroupDetail, cabinetType string, allocated []net.IPNet) (map[int]net.IPNet, error) {
+	_, myNet, _ := net.ParseCIDR(iNet.CIDR)
+	pinned := map[int]net.IPNet{}
+	var pinnedIDs []int
+	for _, cabinetDetail := range cabinetDetails {
+		if cabinetType != cabinetDetail.Kind {
+			continue
+		}
+		for _, cabinet := range cabinetDetail.CabinetDetails {
+			pin := iNet.cabinetSubnetPin(cabinet)
+			if pin == "" {
+				continue
+			}
+			_, pinNet, err := net.ParseCIDR(pin)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s subnet %q for cabinet %d: %v", iNet.Name, pin, cabinet.ID, err)
+			}
+			if !ipam.Contains(*myNet, *pinNet) {
+				return nil, fmt.Errorf("subnet %v of cabinet %d is not part of the %s network %v", pinNet.String(), cabinet.ID, iNet.Name, myNet.String())
+			}
+			for _, subnet := range allocated {
+				if overlaps(subnet, *pinNet) {
+					return nil, fmt.Errorf("subnet %v of cabinet %d overlaps the allocated subnet %v of the %s network", pinNet.String(), cabinet.ID, subnet.String(), iNet.Name)
+				}
+			}
+			for _, otherID := range pinnedIDs {
+				if other := pinned[otherID]; overlaps(other, *pinNet) {
+					return nil, fmt.Errorf("subnet %v of cabinet %d overlaps subnet %v of cabinet %d in the %s network", pinNet.String(), cabinet.ID, other.String(), otherID, iNet.Name)
+				}
+			}
+			pinned[cabinet.ID] = *pinNet
+			pinnedIDs = append(pinnedIDs, cabinet.ID)
+		}
+	}
+	return pinned, nil
 }
 
-// GenSubnets subdivides a network into a set of subnets
+// GenSubnets subdivides a network into a set of subnets.  A cabinet with an NMN or HMN subnet in its
+// CabinetDetail keeps that subnet and the rest are allocated around it.
 func (iNet *IPV4Network) GenSubnets(cabinetDetails []CabinetGroupDetail, mask net.IPMask, cabinetType string) error {
-	// log.Printf("Generating Subnets for %s\ncabinetType: %v,\n", iNet.Name, cabinetType)
+	logging.Debugf("Generating Subnets for %s cabinetType: %v", iNet.Name, cabinetType)
 	_, myNet, _ := net.ParseCIDR(iNet.CIDR)
 	mySubnets := iNet.AllocatedSubnets()
 	myIPv4Subnets := iNet.Subnets
 	var minVlan, maxVlan int16 = 4095, 0
 
+	pinned, err := iNet.pinnedCabinetSubnets(cabinetDetails, cabinetType, mySubnets)
+	if err != nil {
+		return err
+	}
+	for _, pinNet := range pinned {
+		mySubnets = append(mySubnets, pinNet)
+	}
+
 	for _, cabinetDetail := range cabinetDetails {
 		if cabinetType == cabinetDetail.Kind {
-			// log.Println("Dealing with CabinetDetail: ", cabinetDetail)
+			logging.Debugf("Dealing with CabinetDetail: %v", cabinetDetail)
 
 			for j, i := range cabinetDetail.CabinetDetails {
-				newSubnet, err := ipam.Free(*myNet, mask, mySubnets)
-				mySubnets = append(mySubnets, newSubnet)
-				if err != nil {
-					log.Fatalf("Gensubnets couldn't add subnet because %v \n", err)
+				newSubnet, ok := pinned[i.ID]
+				if !ok {
+					newSubnet, err = iNet.freeSubnet(*myNet, mask, mySubnets)
+					mySubnets = append(mySubnets, newSubnet)
+					if err != nil {
+						return fmt.Errorf("gensubnets couldn't add subnet because %v", err)
+					}
 				}
 				var tmpVlanID int16
 				if strings.HasPrefix(iNet.Name, "NMN") {
@@ -110,7 +279,12 @@ func (iNet *IPV4Network) GenSubnets(cabinetDetails []CabinetGroupDetail, mask ne
 					Gateway: ipam.Add(newSubnet.IP, 1),
 					VlanID:  tmpVlanID,
 				}
-				tempSubnet.UpdateDHCPRange(false)
+				if err := tempSubnet.UpdateDHCPRange(false); err != nil {
+					return err
+				}
+				if err := iNet.addSubnet6(&tempSubnet, myIPv4Subnets); err != nil {
+					return err
+				}
 				myIPv4Subnets = append(myIPv4Subnets, &tempSubnet)
 				if tmpVlanID < minVlan {
 					minVlan = tmpVlanID
@@ -127,6 +301,14 @@ func (iNet *IPV4Network) GenSubnets(cabinetDetails []CabinetGroupDetail, mask ne
 	return nil
 }
 
+// freeSubnet finds an unallocated subnet of the requested size honoring the network's AllocationDirection
+func (iNet IPV4Network) freeSubnet(network net.IPNet, mask net.IPMask, subnets []net.IPNet) (net.IPNet, error) {
+	if iNet.AllocationDirection == SubnetAllocationTop {
+		return ipam.FreeFromTop(network, mask, subnets)
+	}
+	return ipam.Free(network, mask, subnets)
+}
+
 // AllocatedSubnets returns a list of the allocated subnets
 func (iNet IPV4Network) AllocatedSubnets() []net.IPNet {
 	var myNets []net.IPNet
@@ -136,6 +318,31 @@ func (iNet IPV4Network) AllocatedSubnets() []net.IPNet {
 	return myNets
 }
 
+// addSubnet6 allocates the IPv6 half of a subnet on a dual-stack network.
+// Every IPv6 subnet is a DefaultSubnetMask6 so that it lines up with its IPv4 subnet at the same vlan.
+func (iNet IPV4Network) addSubnet6(subnet *IPV4Subnet, subnets []*IPV4Subnet) error {
+	if iNet.CIDR6 == "" {
+		return nil
+	}
+	_, myNet6, err := net.ParseCIDR(iNet.CIDR6)
+	if err != nil {
+		return fmt.Errorf("invalid cidr6 %q for the %s network: %v", iNet.CIDR6, iNet.Name, err)
+	}
+	var allocated []net.IPNet
+	for _, v := range subnets {
+		if v.CIDR6.IP != nil {
+			allocated = append(allocated, v.CIDR6)
+		}
+	}
+	newSubnet6, err := ipam.Free6(*myNet6, DefaultSubnetMask6, allocated)
+	if err != nil {
+		return fmt.Errorf("couldn't add an IPv6 subnet for %s to the %s network because %v", subnet.Name, iNet.Name, err)
+	}
+	subnet.CIDR6 = newSubnet6
+	subnet.Gateway6 = ipam.Add6(newSubnet6.IP, 1)
+	return nil
+}
+
 // AllocatedVlans returns a list of all allocated vlan ids
 func (iNet IPV4Network) AllocatedVlans() []int16 {
 	var myVlans []int16
@@ -151,12 +358,16 @@ func (iNet IPV4Network) AllocatedVlans() []int16 {
 func (iNet *IPV4Network) AddSubnetbyCIDR(desiredNet net.IPNet, name string, vlanID int16) (*IPV4Subnet, error) {
 	_, myNet, _ := net.ParseCIDR(iNet.CIDR)
 	if ipam.Contains(*myNet, desiredNet) {
-		iNet.Subnets = append(iNet.Subnets, &IPV4Subnet{
+		tempSubnet := IPV4Subnet{
 			CIDR:    desiredNet,
 			Name:    name,
 			Gateway: ipam.Add(desiredNet.IP, 1),
 			VlanID:  vlanID,
-		})
+		}
+		if err := iNet.addSubnet6(&tempSubnet, iNet.Subnets); err != nil {
+			return &IPV4Subnet{}, err
+		}
+		iNet.Subnets = append(iNet.Subnets, &tempSubnet)
 		return iNet.Subnets[len(iNet.Subnets)-1], nil
 	}
 	return &IPV4Subnet{}, fmt.Errorf("subnet %v is not part of %v", desiredNet.String(), myNet.String())
@@ -166,32 +377,56 @@ func (iNet *IPV4Network) AddSubnetbyCIDR(desiredNet net.IPNet, name string, vlan
 func (iNet *IPV4Network) AddSubnet(mask net.IPMask, name string, vlanID int16) (*IPV4Subnet, error) {
 	var tempSubnet IPV4Subnet
 	_, myNet, _ := net.ParseCIDR(iNet.CIDR)
-	newSubnet, err := ipam.Free(*myNet, mask, iNet.AllocatedSubnets())
+	newSubnet, err := iNet.freeSubnet(*myNet, mask, iNet.AllocatedSubnets())
 	if err != nil {
 		return &tempSubnet, err
 	}
-	iNet.Subnets = append(iNet.Subnets, &IPV4Subnet{
+	tempSubnet = IPV4Subnet{
 		CIDR:    newSubnet,
 		Name:    name,
 		NetName: iNet.Name,
 		Gateway: ipam.Add(newSubnet.IP, 1),
 		VlanID:  vlanID,
-	})
+	}
+	if err := iNet.addSubnet6(&tempSubnet, iNet.Subnets); err != nil {
+		return &IPV4Subnet{}, err
+	}
+	iNet.Subnets = append(iNet.Subnets, &tempSubnet)
 	return iNet.Subnets[len(iNet.Subnets)-1], nil
 }
 
-// AddBiggestSubnet allocates the largest subnet possible within the requested network and mask
-func (iNet *IPV4Network) AddBiggestSubnet(mask net.IPMask, name string, vlanID int16) (*IPV4Subnet, error) {
+// DefaultSmallestSubnetMask is the smallest subnet AddBiggestSubnet normally settles for
+const DefaultSmallestSubnetMask = 29
+
+// MaxSubnetMaskSize is the longest mask AddBiggestSubnet can be asked for, a /31 point-to-point link
+const MaxSubnetMaskSize = 31
+
+// AddBiggestSubnet allocates the largest subnet possible within the requested network and mask,
+// trying each smaller mask down to and including smallestMask.  Only a lack of IPv4 space shrinks the mask,
+// an IPv6 allocation failure is returned as is.
+func (iNet *IPV4Network) AddBiggestSubnet(mask net.IPMask, name string, vlanID int16, smallestMask int) (*IPV4Subnet, error) {
 	// Try for the largest available and go smaller if needed
 	maskSize, _ := mask.Size() // the second output of this function is 32 for ipv4 or 64 for ipv6
-	for i := maskSize; i < 29; i++ {
-		// log.Printf("Trying to find room for a /%d mask in %v \n", i, iNet.Name)
-		newSubnet, err := iNet.AddSubnet(net.CIDRMask(i, 32), name, vlanID)
-		if err == nil {
-			return newSubnet, nil
+	if smallestMask < maskSize || smallestMask > MaxSubnetMaskSize {
+		return &IPV4Subnet{}, fmt.Errorf("the smallest mask for the %v subnet must be between /%d and /%d, not /%d", name, maskSize, MaxSubnetMaskSize, smallestMask)
+	}
+	_, myNet, _ := net.ParseCIDR(iNet.CIDR)
+	for i := maskSize; i <= smallestMask; i++ {
+		logging.Debugf("Trying to find room for a /%d mask in %v", i, iNet.Name)
+		if _, err := iNet.freeSubnet(*myNet, net.CIDRMask(i, 32), iNet.AllocatedSubnets()); err != nil {
+			continue
 		}
+		return iNet.AddSubnet(net.CIDRMask(i, 32), name, vlanID)
 	}
-	return &IPV4Subnet{}, fmt.Errorf("no room for %v subnet within %v (tried from /%d to /29)", name, iNet.Name, maskSize)
+	return &IPV4Subnet{}, fmt.Errorf("no room for %v subnet within %v (tried from /%d to /%d)", name, iNet.Name, maskSize, smallestMask)
+}
+
+// SubnetMTU returns the MTU of a subnet of the network, the network MTU unless the subnet overrides it
+func (iNet IPV4Network) SubnetMTU(subnet *IPV4Subnet) int16 {
+	if subnet != nil && subnet.MTU != 0 {
+		return subnet.MTU
+	}
+	return iNet.MTU
 }
 
 // LookUpSubnet returns a subnet by name
@@ -215,6 +450,47 @@ func (iNet *IPV4Network) LookUpSubnet(name string) (*IPV4Subnet, error) {
 	return &IPV4Subnet{}, fmt.Errorf("subnet not found \"%v\"", name)
 }
 
+// ValidateUniqueSubnetNames verifies that no two subnets of the network share a name, which would make
+// LookUpSubnet ambiguous, e.g. two cabinet_3000 subnets from a repeated cabinet id
+func (iNet IPV4Network) ValidateUniqueSubnetNames() error {
+	counts := map[string]int{}
+	var duplicates []string
+	for _, subnet := range iNet.Subnets {
+		counts[subnet.Name]++
+		if counts[subnet.Name] == 2 {
+			duplicates = append(duplicates, subnet.Name)
+		}
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+	var described []string
+	for _, name := range duplicates {
+		described = append(described, fmt.Sprintf("%s (%d times)", name, counts[name]))
+	}
+	return fmt.Errorf("the %s network has more than one subnet named %s", iNet.Name, strings.Join(described, ", "))
+}
+
+// RemoveSubnet releases a subnet by name leaving the other subnets untouched
+func (iNet *IPV4Network) RemoveSubnet(name string) error {
+	index := -1
+	count := 0
+	for i, v := range iNet.Subnets {
+		if v.Name == name {
+			index = i
+			count++
+		}
+	}
+	if count == 0 {
+		return fmt.Errorf("subnet not found \"%v\"", name)
+	}
+	if count > 1 {
+		return fmt.Errorf("found %v subnets instead of just one", count)
+	}
+	iNet.Subnets = append(iNet.Subnets[:index], iNet.Subnets[index+1:]...)
+	return nil
+}
+
 // SubnetbyName Return a copy of the subnet by name or a blank subnet if it doesn't exists
 func (iNet IPV4Network) SubnetbyName(name string) IPV4Subnet {
 	for _, v := range iNet.Subnets {
@@ -226,31 +502,35 @@ func (iNet IPV4Network) SubnetbyName(name string) IPV4Subnet {
 }
 
 // ReserveEdgeSwitchIPs reserves (n) IP addresses for edge switches
-func (iSubnet *IPV4Subnet) ReserveEdgeSwitchIPs(edges []string) {
+func (iSubnet *IPV4Subnet) ReserveEdgeSwitchIPs(edges []string) error {
 	for i := 0; i < len(edges); i++ {
 		name := fmt.Sprintf("chn-switch-%01d", i+1)
-		iSubnet.AddReservation(name, edges[i])
+		if _, err := iSubnet.AddReservation(name, edges[i]); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 // ReserveNetMgmtIPs reserves (n) IP addresses for management networking equipment
-func (iSubnet *IPV4Subnet) ReserveNetMgmtIPs(spines []string, leafs []string, leafbmcs []string, cdus []string) {
-	for i := 0; i < len(spines); i++ {
-		name := fmt.Sprintf("sw-spine-%03d", i+1)
-		iSubnet.AddReservation(name, spines[i])
-	}
-	for i := 0; i < len(leafs); i++ {
-		name := fmt.Sprintf("sw-leaf-%03d", i+1)
-		iSubnet.AddReservation(name, leafs[i])
-	}
-	for i := 0; i < len(leafbmcs); i++ {
-		name := fmt.Sprintf("sw-leaf-bmc-%03d", i+1)
-		iSubnet.AddReservation(name, leafbmcs[i])
-	}
-	for i := 0; i < len(cdus); i++ {
-		name := fmt.Sprintf("sw-cdu-%03d", i+1)
-		iSubnet.AddReservation(name, cdus[i])
+func (iSubnet *IPV4Subnet) ReserveNetMgmtIPs(spines []string, leafs []string, leafbmcs []string, cdus []string) error {
+	for _, group := range []struct {
+		format string
+		xnames []string
+	}{
+		{"sw-spine-%03d", spines},
+		{"sw-leaf-%03d", leafs},
+		{"sw-leaf-bmc-%03d", leafbmcs},
+		{"sw-cdu-%03d", cdus},
+	} {
+		for i := 0; i < len(group.xnames); i++ {
+			name := fmt.Sprintf(group.format, i+1)
+			if _, err := iSubnet.AddReservation(name, group.xnames[i]); err != nil {
+				return err
+			}
+		}
 	}
+	return nil
 }
 
 // ReservedIPs returns a list of IPs already reserved within the subnet
@@ -271,6 +551,22 @@ func (iSubnet *IPV4Subnet) ReservationsByName() map[string]IPReservation {
 	return reservations
 }
 
+// EnsureReservation returns the reservation named name, adding it with AddReservation when the subnet does not
+// have one yet.  The bool reports whether the reservation was created, and the error is set when the subnet is
+// too full to add it.
+func (iSubnet *IPV4Subnet) EnsureReservation(name, comment string) (*IPReservation, bool, error) {
+	for i := range iSubnet.IPReservations {
+		if iSubnet.IPReservations[i].Name == name {
+			return &iSubnet.IPReservations[i], false, nil
+		}
+	}
+	reservation, err := iSubnet.AddReservation(name, comment)
+	if err != nil {
+		return nil, false, err
+	}
+	return reservation, true, nil
+}
+
 // LookupReservation searches the subnet for an IPReservation that matches the name provided
 func (iSubnet *IPV4Subnet) LookupReservation(resName string) IPReservation {
 	for _, v := range iSubnet.IPReservations {
@@ -299,11 +595,11 @@ func (iSubnet *IPV4Subnet) UsableHostAddresses() int {
 }
 
 // UpdateDHCPRange resets the DHCPStart to exclude all IPReservations
-func (iSubnet *IPV4Subnet) UpdateDHCPRange(applySupernetHack bool) {
+func (iSubnet *IPV4Subnet) UpdateDHCPRange(applySupernetHack bool) error {
 
 	myReservedIPs := iSubnet.ReservedIPs()
 	if len(myReservedIPs) > iSubnet.UsableHostAddresses() {
-		log.Fatalf("Could not create %s subnet in %s.  There are %d reservations and only %d usable ip addresses in the subnet %v.", iSubnet.FullName, iSubnet.NetName, len(myReservedIPs), iSubnet.UsableHostAddresses(), iSubnet.CIDR.String())
+		return fmt.Errorf("could not create %s subnet in %s.  There are %d reservations and only %d usable ip addresses in the subnet %v", iSubnet.FullName, iSubnet.NetName, len(myReservedIPs), iSubnet.UsableHostAddresses(), iSubnet.CIDR.String())
 	}
 
 	// Bump the DHCP Start IP past the gateway
@@ -337,10 +633,117 @@ func (iSubnet *IPV4Subnet) UpdateDHCPRange(applySupernetHack bool) {
 			iSubnet.DHCPEnd = ipam.Add(ipam.Broadcast(iSubnet.CIDR), -1)
 		}
 	}
+
+	if iSubnet.Name != "uai_macvlan" && ipam.IPLessThan(iSubnet.DHCPEnd, iSubnet.DHCPStart) {
+		return fmt.Errorf("could not create %s subnet in %s.  The DHCP range would start at %v, after the gateway and %d reservations, but end at %v.  Use a larger subnet than %v", iSubnet.FullName, iSubnet.NetName, iSubnet.DHCPStart, len(iSubnet.IPReservations), iSubnet.DHCPEnd, iSubnet.CIDR.String())
+	}
+
+	// Hold back the requested number of addresses at the top of the range
+	if iSubnet.DHCPEndPadding > 0 {
+		if iSubnet.Name == "uai_macvlan" {
+			iSubnet.ReservationEnd = ipam.Add(iSubnet.ReservationEnd, -iSubnet.DHCPEndPadding)
+			if ipam.IPLessThan(iSubnet.ReservationEnd, iSubnet.ReservationStart) {
+				return fmt.Errorf("could not create %s subnet in %s.  A padding of %d addresses leaves no room for reservations in the subnet %v", iSubnet.FullName, iSubnet.NetName, iSubnet.DHCPEndPadding, iSubnet.CIDR.String())
+			}
+		} else {
+			iSubnet.DHCPEnd = ipam.Add(iSubnet.DHCPEnd, -iSubnet.DHCPEndPadding)
+			if ipam.IPLessThan(iSubnet.DHCPEnd, iSubnet.DHCPStart) {
+				return fmt.Errorf("could not create %s subnet in %s.  A padding of %d addresses leaves an empty DHCP range in the subnet %v", iSubnet.FullName, iSubnet.NetName, iSubnet.DHCPEndPadding, iSubnet.CIDR.String())
+			}
+		}
+	}
+
+	if iSubnet.Name != "uai_macvlan" {
+		return iSubnet.applyDHCPExclusions(iSubnet.inUseDHCPExclusions())
+	}
+	return nil
+}
+
+// inUseDHCPExclusions returns the addresses within DHCPStart to DHCPEnd that are already taken, such as a
+// gateway overridden to the top of the subnet or a reservation pinned above the packed reservations, as single
+// address blocks to carve out of the DHCP range
+func (iSubnet *IPV4Subnet) inUseDHCPExclusions() []net.IPNet {
+	var inUse []net.IPNet
+	for _, ip := range append(iSubnet.ReservedIPs(), iSubnet.Gateway) {
+		if ip == nil || ip.To4() == nil {
+			continue
+		}
+		if !ipam.IPLessThan(ip.To4(), iSubnet.DHCPStart.To4()) && !ipam.IPLessThan(iSubnet.DHCPEnd.To4(), ip.To4()) {
+			inUse = append(inUse, net.IPNet{IP: ip.To4(), Mask: net.CIDRMask(32, 32)})
+		}
+	}
+	return inUse
+}
+
+// applyDHCPExclusions splits the DHCP range around the DHCPExclusions and the inUse blocks into DHCPRanges and
+// shrinks DHCPStart and DHCPEnd to the first and last address still handed out.  Without exclusions there is a
+// single range, DHCPStart to DHCPEnd, and DHCPRanges is left empty.
+func (iSubnet *IPV4Subnet) applyDHCPExclusions(inUse []net.IPNet) error {
+	iSubnet.DHCPRanges = nil
+	exclusions := append(append([]net.IPNet{}, iSubnet.DHCPExclusions...), inUse...)
+	if len(exclusions) == 0 {
+		return nil
+	}
+
+	type block struct{ start, end uint32 }
+	var excluded []block
+	for _, exclusion := range exclusions {
+		excluded = append(excluded, block{
+			start: binary.BigEndian.Uint32(exclusion.IP.To4()),
+			end:   binary.BigEndian.Uint32(ipam.Broadcast(exclusion).To4()),
+		})
+	}
+	sort.Slice(excluded, func(i, j int) bool {
+		return excluded[i].start < excluded[j].start
+	})
+
+	start := binary.BigEndian.Uint32(iSubnet.DHCPStart.To4())
+	end := binary.BigEndian.Uint32(iSubnet.DHCPEnd.To4())
+	for _, ex := range excluded {
+		if ex.end < start {
+			continue
+		}
+		if ex.start > end {
+			break
+		}
+		if ex.start > start {
+			iSubnet.DHCPRanges = append(iSubnet.DHCPRanges, DHCPRange{Start: uint32ToIP(start), End: uint32ToIP(ex.start - 1)})
+		}
+		if ex.end >= end {
+			start = end + 1
+			break
+		}
+		start = ex.end + 1
+	}
+	if start <= end {
+		iSubnet.DHCPRanges = append(iSubnet.DHCPRanges, DHCPRange{Start: uint32ToIP(start), End: uint32ToIP(end)})
+	}
+
+	if len(iSubnet.DHCPRanges) == 0 {
+		return fmt.Errorf("could not create %s subnet in %s.  The DHCP exclusions leave an empty DHCP range in the subnet %v", iSubnet.FullName, iSubnet.NetName, iSubnet.CIDR.String())
+	}
+	iSubnet.DHCPStart = iSubnet.DHCPRanges[0].Start
+	iSubnet.DHCPEnd = iSubnet.DHCPRanges[len(iSubnet.DHCPRanges)-1].End
+	return nil
+}
+
+func uint32ToIP(addr uint32) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, addr)
+	return ip
+}
+
+// DHCPRangeList returns the ranges handed out by DHCP, DHCPRanges when there are exclusions and otherwise
+// the single range from DHCPStart to DHCPEnd
+func (iSubnet IPV4Subnet) DHCPRangeList() []DHCPRange {
+	if len(iSubnet.DHCPRanges) > 0 {
+		return iSubnet.DHCPRanges
+	}
+	return []DHCPRange{{Start: iSubnet.DHCPStart, End: iSubnet.DHCPEnd}}
 }
 
 // AddReservationWithPin adds a new IPv4 reservation to the subnet with the last octet pinned
-func (iSubnet *IPV4Subnet) AddReservationWithPin(name, comment string, pin uint8) *IPReservation {
+func (iSubnet *IPV4Subnet) AddReservationWithPin(name, comment string, pin uint8) (*IPReservation, error) {
 	// Grab the "floor" of the subnet and alter the last byte to match the pinned byte
 	// modulo 4/16 bit ip addresses
 	// Worth noting that I could not seem to do this by copying the IP from the struct into a new
@@ -360,20 +763,30 @@ func (iSubnet *IPV4Subnet) AddReservationWithPin(name, comment string, pin uint8
 		newIP[2] = iSubnet.CIDR.IP[14]
 		newIP[3] = pin
 	}
+	if !iSubnet.isUsableHostAddress(newIP) {
+		return nil, fmt.Errorf("cannot pin %s to %v, it is not a usable address in the %s subnet %v", name, newIP, iSubnet.Name, iSubnet.CIDR.String())
+	}
 	if comment != "" {
 		iSubnet.IPReservations = append(iSubnet.IPReservations, IPReservation{
-			IPAddress: newIP,
-			Name:      name,
-			Comment:   comment,
-			Aliases:   strings.Split(comment, ","),
+			IPAddress:   newIP,
+			IPv6Address: iSubnet.ipv6For(newIP),
+			Name:        name,
+			Comment:     comment,
+			Aliases:     strings.Split(comment, ","),
 		})
 	} else {
 		iSubnet.IPReservations = append(iSubnet.IPReservations, IPReservation{
-			IPAddress: newIP,
-			Name:      name,
+			IPAddress:   newIP,
+			IPv6Address: iSubnet.ipv6For(newIP),
+			Name:        name,
 		})
 	}
-	return &iSubnet.IPReservations[len(iSubnet.IPReservations)-1]
+	return &iSubnet.IPReservations[len(iSubnet.IPReservations)-1], nil
+}
+
+// isUsableHostAddress checks that the address lies between the network and broadcast addresses of the subnet
+func (iSubnet *IPV4Subnet) isUsableHostAddress(ip net.IP) bool {
+	return ipam.IPLessThan(iSubnet.CIDR.IP.To4(), ip.To4()) && ipam.IPLessThan(ip.To4(), ipam.Broadcast(iSubnet.CIDR).To4())
 }
 
 // AddReservationAlias adds an alias to a reservation if it doesn't already exist
@@ -383,9 +796,32 @@ func (iReserv *IPReservation) AddReservationAlias(alias string) {
 	}
 }
 
+// UpdateNCNReservations names the reservations whose comment is an NCN xname after the NCN and adds the
+// <hostname>-<network> alias. Aliases are only added once so the update can safely be repeated.
+func (iSubnet *IPV4Subnet) UpdateNCNReservations(ncns []LogicalNCN) {
+	// Without templates the aliases can't fail to render
+	_ = iSubnet.UpdateNCNReservationsWithTemplates(ncns, nil)
+}
+
+// defaultNCNAliases are the aliases given to an NCN reservation when no alias template applies
+func defaultNCNAliases(hostname, netName string) []string {
+	var aliases []string
+	if netName != "" {
+		aliases = append(aliases, fmt.Sprintf("%s-%s", hostname, netName))
+	}
+	if netName == "nmn" {
+		aliases = append(aliases, fmt.Sprintf("%s.local", hostname))
+	}
+	return aliases
+}
+
 // AddReservation adds a new IP reservation to the subnet
-func (iSubnet *IPV4Subnet) AddReservation(name, comment string) *IPReservation {
+func (iSubnet *IPV4Subnet) AddReservation(name, comment string) (*IPReservation, error) {
 	myReservedIPs := iSubnet.ReservedIPs()
+	// The gateway may have been moved into the subnet, e.g. to a VRRP VIP at .254
+	if iSubnet.Gateway != nil {
+		myReservedIPs = append(myReservedIPs, iSubnet.Gateway)
+	}
 	// Commenting out this section because the supernet configuration we're using will trigger this all the time and it shouldn't be an error
 	// floor := iSubnet.CIDR.IP.Mask(iSubnet.CIDR.Mask)
 	// if !floor.Equal(iSubnet.CIDR.IP) {
@@ -394,27 +830,43 @@ func (iSubnet *IPV4Subnet) AddReservation(name, comment string) *IPReservation {
 	// Start counting from the bottom knowing the gateway is on the bottom
 	tempIP := ipam.Add(iSubnet.CIDR.IP, 2)
 	for {
-		for _, v := range myReservedIPs {
-			if tempIP.Equal(v) {
+		// Rescan after every bump, pinned reservations are not in address order
+		for i := 0; i < len(myReservedIPs); i++ {
+			if tempIP.Equal(myReservedIPs[i]) {
 				tempIP = ipam.Add(tempIP, 1)
+				i = -1
 			}
 		}
+		if !iSubnet.isUsableHostAddress(tempIP) {
+			return nil, fmt.Errorf("subnet %s (%v) is full, unable to reserve an address for %s", iSubnet.Name, iSubnet.CIDR.String(), name)
+		}
 		iSubnet.IPReservations = append(iSubnet.IPReservations, IPReservation{
-			IPAddress: tempIP,
-			Name:      name,
-			Comment:   comment,
+			IPAddress:   tempIP,
+			IPv6Address: iSubnet.ipv6For(tempIP),
+			Name:        name,
+			Comment:     comment,
 		})
-		return &iSubnet.IPReservations[len(iSubnet.IPReservations)-1]
+		return &iSubnet.IPReservations[len(iSubnet.IPReservations)-1], nil
+	}
+}
+
+// ipv6For returns the address at the same host offset in the IPv6 subnet, or nil if the subnet is IPv4 only
+func (iSubnet *IPV4Subnet) ipv6For(ip net.IP) net.IP {
+	if iSubnet.CIDR6.IP == nil {
+		return nil
 	}
+	offset := int64(binary.BigEndian.Uint32(ip.To4())) - int64(binary.BigEndian.Uint32(iSubnet.CIDR.IP.To4()))
+	return ipam.Add6(iSubnet.CIDR6.IP, offset)
 }
 
 // AddReservationWithIP adds a reservation with a specific ip address
 func (iSubnet *IPV4Subnet) AddReservationWithIP(name, addr, comment string) (*IPReservation, error) {
 	if iSubnet.CIDR.Contains(net.ParseIP(addr)) {
 		iSubnet.IPReservations = append(iSubnet.IPReservations, IPReservation{
-			IPAddress: net.ParseIP(addr),
-			Name:      name,
-			Comment:   comment,
+			IPAddress:   net.ParseIP(addr),
+			IPv6Address: iSubnet.ipv6For(net.ParseIP(addr)),
+			Name:        name,
+			Comment:     comment,
 		})
 		return &iSubnet.IPReservations[len(iSubnet.IPReservations)-1], nil
 	}
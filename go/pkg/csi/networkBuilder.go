@@ -25,14 +25,18 @@ package csi
 
 import (
 	"fmt"
-	"log"
 	"net"
+	"strconv"
 	"strings"
 
 	"github.com/Cray-HPE/csm-common/go/pkg/ipam"
+	"github.com/Cray-HPE/csm-common/go/pkg/logging"
 	"github.com/spf13/viper"
 )
 
+// bootstrapVIPNetworks are the networks whose bootstrap_dhcp subnet carries the switch and virtual IP reservations
+var bootstrapVIPNetworks = []string{"NMN", "HMN", "CMN", "CAN", "CHN"}
+
 // NetworkLayoutConfiguration is the internal configuration structure for shasta networks
 type NetworkLayoutConfiguration struct {
 	Template                        IPV4Network
@@ -50,6 +54,126 @@ type NetworkLayoutConfiguration struct {
 	CabinetDetails                  []CabinetGroupDetail
 	CabinetCIDR                     net.IPMask
 	ManagementSwitches              []*ManagementSwitch
+	ManagementReservations          []ManagementReservation
+	NCNs                            []LogicalNCN
+}
+
+// bootstrapSubnetSizeNetworks are the networks whose bootstrap_dhcp subnet size can be set with <net>-bootstrap-subnet-size
+var bootstrapSubnetSizeNetworks = []string{"NMN", "HMN"}
+
+// BootstrapSubnetMask returns the mask of the bootstrap_dhcp subnet of a network and the smallest mask AddBiggestSubnet
+// may settle for.  An explicit nmn-bootstrap-subnet-size or hmn-bootstrap-subnet-size prefix length is used exactly and
+// must fit within the network, otherwise the layout default may shrink down to DefaultSmallestSubnetMask.
+func BootstrapSubnetMask(v *viper.Viper, netName, networkCIDR string, defaultMask net.IPMask) (net.IPMask, int, error) {
+	key := fmt.Sprintf("%s-bootstrap-subnet-size", strings.ToLower(netName))
+	if !stringInSlice(netName, bootstrapSubnetSizeNetworks) || !v.IsSet(key) {
+		return defaultMask, DefaultSmallestSubnetMask, nil
+	}
+	size := v.GetInt(key)
+	_, network, err := net.ParseCIDR(networkCIDR)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to apply %s to the %s network: %v", key, netName, err)
+	}
+	networkSize, _ := network.Mask.Size()
+	if size < networkSize || size > DefaultSmallestSubnetMask {
+		return nil, 0, fmt.Errorf("%s /%d must be between /%d, the size of the %s network, and /%d", key, size, networkSize, netName, DefaultSmallestSubnetMask)
+	}
+	return net.CIDRMask(size, 32), size, nil
+}
+
+// ValidateBootstrapSubnetCapacity verifies that the bootstrap_dhcp subnets of the NMN and HMN have room for the NCNs
+// that are not reserved in them yet, along with everything that already is.  It measures the subnets as they are,
+// so it has to run before the supernet hack widens their mask.
+func ValidateBootstrapSubnetCapacity(networks map[string]*IPV4Network, ncns []LogicalNCN) []error {
+	var errs []error
+	for _, netName := range bootstrapSubnetSizeNetworks {
+		network, ok := networks[netName]
+		if !ok {
+			continue
+		}
+		subnet, err := network.LookUpSubnet("bootstrap_dhcp")
+		if err != nil {
+			continue
+		}
+		reserved := map[string]bool{}
+		for _, reservation := range subnet.IPReservations {
+			reserved[reservation.Comment] = true
+		}
+		required := len(subnet.IPReservations)
+		for _, ncn := range ncns {
+			if !reserved[ncn.Xname] {
+				required++
+			}
+		}
+		// The gateway takes one of the usable addresses
+		if available := subnet.UsableHostAddresses() - 1; required > available {
+			errs = append(errs, fmt.Errorf("the %s bootstrap_dhcp subnet %v has room for %d reservations but %d are required, increase %s-bootstrap-subnet-size",
+				netName, subnet.CIDR.String(), available, required, strings.ToLower(netName)))
+		}
+	}
+	return errs
+}
+
+// addVIPReservation reserves a virtual IP in a bootstrap_dhcp subnet.  When pinKey is set the VIP gets that last
+// octet, otherwise it gets the next free address as before.  A pin cannot be the gateway or an address that is
+// already reserved.
+func addVIPReservation(v *viper.Viper, subnet *IPV4Subnet, name, comment, pinKey string) error {
+	if !v.IsSet(pinKey) {
+		_, err := subnet.AddReservation(name, comment)
+		return err
+	}
+	pin := v.GetInt(pinKey)
+	if pin < 0 || pin > 255 {
+		return fmt.Errorf("%s must be between 0 and 255, not %d", pinKey, pin)
+	}
+	ip := subnet.CIDR.IP.To4()
+	ip = net.IPv4(ip[0], ip[1], ip[2], byte(pin)).To4()
+	if subnet.Gateway != nil && ip.Equal(subnet.Gateway) {
+		return fmt.Errorf("%s %d puts %s on %v, the gateway of the %s subnet", pinKey, pin, name, ip, subnet.Name)
+	}
+	for _, reservation := range subnet.IPReservations {
+		if ip.Equal(reservation.IPAddress) {
+			return fmt.Errorf("%s %d puts %s on %v, which is already reserved for %s", pinKey, pin, name, ip, reservation.Name)
+		}
+	}
+	reservation, err := subnet.AddReservationWithPin(name, "", uint8(pin))
+	if err != nil {
+		return err
+	}
+	reservation.Comment = comment
+	return nil
+}
+
+// ManagementReservation is a named address in the network_hardware subnet of a network, e.g. a switch loopback or VRRP VIP.
+// They are listed under management-reservations in system_config.yaml.
+type ManagementReservation struct {
+	Name    string `mapstructure:"name" yaml:"name"`
+	Network string `mapstructure:"network" yaml:"network"`
+	Comment string `mapstructure:"comment" yaml:"comment"`
+}
+
+// ManagementReservations reads the management-reservations and checks that each names a network with a network_hardware subnet
+func ManagementReservations(v *viper.Viper, internalNetConfigs map[string]NetworkLayoutConfiguration) ([]ManagementReservation, error) {
+	var reservations []ManagementReservation
+	if err := v.UnmarshalKey("management-reservations", &reservations); err != nil {
+		return nil, fmt.Errorf("invalid management-reservations: %v", err)
+	}
+	seen := make(map[string]bool)
+	for i, reservation := range reservations {
+		if reservation.Name == "" {
+			return nil, fmt.Errorf("management-reservations entry %d has no name", i+1)
+		}
+		layout, ok := internalNetConfigs[strings.ToUpper(reservation.Network)]
+		if !ok || !layout.IncludeNetworkingHardwareSubnet {
+			return nil, fmt.Errorf("management reservation %s is on %q which is not a network with a network_hardware subnet", reservation.Name, reservation.Network)
+		}
+		key := strings.ToUpper(reservation.Network) + "/" + reservation.Name
+		if seen[key] {
+			return nil, fmt.Errorf("management reservation %s is defined more than once on the %s network", reservation.Name, reservation.Network)
+		}
+		seen[key] = true
+	}
+	return reservations, nil
 }
 
 // IsValid provides feedback about any problems with the configuration
@@ -67,29 +191,57 @@ func (nlc *NetworkLayoutConfiguration) IsValid() (bool, error) {
 	return true, nil
 }
 
-// BuildCSMNetworks creates an array of IPv4 Networks based on the supplied system configuration
-func BuildCSMNetworks(internalNetConfigs map[string]NetworkLayoutConfiguration, internalCabinetDetails []CabinetGroupDetail, switches []*ManagementSwitch) (map[string]*IPV4Network, error) {
+// BuildCSMNetworks creates an array of IPv4 Networks based on the supplied system configuration.
+// The bootstrap_dhcp subnets of the NMN and HMN are checked for room for the ncns.
+func BuildCSMNetworks(internalNetConfigs map[string]NetworkLayoutConfiguration, internalCabinetDetails []CabinetGroupDetail, switches []*ManagementSwitch, ncns []LogicalNCN) (map[string]*IPV4Network, error) {
 	v := viper.GetViper()
 	var networkMap = make(map[string]*IPV4Network)
 
+	if err := ValidateCabinetIDs(internalCabinetDetails); err != nil {
+		return networkMap, err
+	}
+	if err := ValidateCabinetClasses(internalCabinetDetails); err != nil {
+		return networkMap, err
+	}
+
+	for _, rgwNetwork := range RGWVIPNetworks(v) {
+		rgwLayout, ok := internalNetConfigs[rgwNetwork]
+		if !ok || !rgwLayout.IncludeBootstrapDHCP || !stringInSlice(rgwNetwork, bootstrapVIPNetworks) {
+			return networkMap, fmt.Errorf("rgw-vip-networks contains %v which is not one of the configured %v networks", rgwNetwork, bootstrapVIPNetworks)
+		}
+	}
+
+	managementReservations, err := ManagementReservations(v, internalNetConfigs)
+	if err != nil {
+		return networkMap, err
+	}
+
 	for name, layout := range internalNetConfigs {
-		// log.Println("Building Network for ", name)
+		logging.Debugf("Building Network for %s", name)
 		myLayout := layout
 
 		if name == "CHN" {
 			if v.GetString("chn-cidr") == "" {
-				log.Println("No CHN Network definition provided")
+				logging.Infof("No CHN Network definition provided")
 				continue
 			}
 		}
 
+		superNetHack, err := SupernetEnabled(v, name, myLayout.SuperNetHack)
+		if err != nil {
+			return networkMap, err
+		}
+		myLayout.SuperNetHack = superNetHack
+
 		// Update with computed fields
 		myLayout.CabinetDetails = internalCabinetDetails
 		myLayout.ManagementSwitches = switches
+		myLayout.ManagementReservations = managementReservations
+		myLayout.NCNs = ncns
 
 		netPtr, err := createNetFromLayoutConfig(myLayout)
 		if err != nil {
-			log.Fatalf("Couldn't add %v Network because %v", name, err)
+			return networkMap, fmt.Errorf("couldn't add %v Network because %v", name, err)
 		}
 		networkMap[name] = netPtr
 	}
@@ -103,7 +255,9 @@ func BuildCSMNetworks(internalNetConfigs map[string]NetworkLayoutConfiguration,
 	pool.FullName = "NMN MetalLB"
 	pool.MetalLBPoolName = "node-management"
 	for nme, rsrv := range PinnedMetalLBReservations {
-		pool.AddReservationWithPin(nme, strings.Join(rsrv.Aliases, ","), rsrv.IPByte)
+		if _, err := pool.AddReservationWithPin(nme, strings.Join(rsrv.Aliases, ","), rsrv.IPByte); err != nil {
+			return networkMap, err
+		}
 	}
 	networkMap["NMNLB"] = &tempNMNLoadBalancer
 
@@ -118,20 +272,45 @@ func BuildCSMNetworks(internalNetConfigs map[string]NetworkLayoutConfiguration,
 		// // Because of the hack to pin ip addresses, we've got an overloaded datastructure in defaults.
 		// // We need to prune it here before we write it out.  It's pretty ugly, but we plan to throw all of this code away when ip pinning is no longer necessary
 		if nme != "istio-ingressgateway-local" {
+			comment := strings.Join(rsrv.Aliases, ",")
 			if nme == "istio-ingressgateway" {
-				pool.AddReservationWithPin(nme, "", rsrv.IPByte)
-			} else {
-				pool.AddReservationWithPin(nme, strings.Join(rsrv.Aliases, ","), rsrv.IPByte)
+				comment = ""
+			}
+			if _, err := pool.AddReservationWithPin(nme, comment, rsrv.IPByte); err != nil {
+				return networkMap, err
 			}
 		}
 	}
 	networkMap["HMNLB"] = &tempHMNLoadBalancer
 
+	if errs := ValidateVlanRanges(networkMap); len(errs) > 0 {
+		var overlaps []string
+		for _, err := range errs {
+			overlaps = append(overlaps, err.Error())
+		}
+		return networkMap, fmt.Errorf("invalid vlan ranges: %s", strings.Join(overlaps, "; "))
+	}
+	if errs := ValidateNetworkOverlaps(networkMap); len(errs) > 0 {
+		var overlaps []string
+		for _, err := range errs {
+			overlaps = append(overlaps, err.Error())
+		}
+		return networkMap, fmt.Errorf("overlapping network CIDRs: %s", strings.Join(overlaps, "; "))
+	}
+
+	minimumCabinetSubnetMask := DefaultMinimumCabinetSubnetMask
+	if v.IsSet("minimum-cabinet-subnet-mask") {
+		minimumCabinetSubnetMask = v.GetInt("minimum-cabinet-subnet-mask")
+	}
+	for _, warning := range SmallCabinetSubnetWarnings(networkMap, minimumCabinetSubnetMask) {
+		logging.Warnf("%s", warning)
+	}
+
 	return networkMap, nil
 }
 
 func createNetFromLayoutConfig(conf NetworkLayoutConfiguration) (*IPV4Network, error) {
-	// log.Printf("Creating a network for %v with NetworkLayoutConfig %+v", conf.Template.Name, conf)
+	logging.Debugf("Creating a network for %v with NetworkLayoutConfig %+v", conf.Template.Name, conf)
 	var canCIDR *net.IPNet
 	var cmnCIDR *net.IPNet
 	var chnCIDR *net.IPNet
@@ -141,6 +320,11 @@ func createNetFromLayoutConfig(conf NetworkLayoutConfiguration) (*IPV4Network, e
 	tempNet := conf.Template
 	netNameLower := strings.ToLower(tempNet.Name)
 
+	tempNet.AllocationDirection = v.GetString("subnet-allocation-direction")
+	if !IsSubnetAllocationDirectionValid(tempNet.AllocationDirection) {
+		return &tempNet, fmt.Errorf("invalid subnet-allocation-direction %q, must be %s or %s", tempNet.AllocationDirection, SubnetAllocationTop, SubnetAllocationBottom)
+	}
+
 	// figure out what switches we have
 	leafbmcSwitches := switchXnamesByType(conf.ManagementSwitches, "LeafBMC")
 	spineSwitches := switchXnamesByType(conf.ManagementSwitches, "Spine")
@@ -154,13 +338,13 @@ func createNetFromLayoutConfig(conf NetworkLayoutConfiguration) (*IPV4Network, e
 		conf.DesiredBootstrapDHCPMask = cmnCIDR.Mask
 		_, cmnStaticPool, err := net.ParseCIDR(v.GetString("cmn-static-pool"))
 		if err != nil {
-			log.Printf("IP Addressing Failure\nInvalid cmn-static-pool.  Cowardly refusing to create it.")
+			logging.Warnf("IP Addressing Failure: Invalid cmn-static-pool.  Cowardly refusing to create it.")
 		} else {
 			static, err := tempNet.AddSubnetbyCIDR(*cmnStaticPool, "cmn_metallb_static_pool", int16(v.GetInt("cmn-bootstrap-vlan")))
 			if err != nil {
-				log.Fatalf("IP Addressing Failure\n"+
-					"Couldn't add MetalLB Static pool of %v to net %v: %v\n"+
-					"Possible missing or mismatched cmn-static-pool input value.",
+				return &tempNet, fmt.Errorf("IP Addressing Failure: "+
+					"couldn't add MetalLB Static pool of %v to net %v: %v.  "+
+					"Possible missing or mismatched cmn-static-pool input value",
 					v.GetString("cmn-static-pool"), tempNet.CIDR, err)
 			}
 			static.FullName = "CMN Static Pool MetalLB"
@@ -168,18 +352,18 @@ func createNetFromLayoutConfig(conf NetworkLayoutConfiguration) (*IPV4Network, e
 
 			_, err = static.AddReservationWithIP("external-dns", v.GetString("cmn-external-dns"), "site to system lookups")
 			if err != nil {
-				log.Fatal(err)
+				return &tempNet, err
 			}
 		}
 		_, cmnDynamicPool, err := net.ParseCIDR(v.GetString("cmn-dynamic-pool"))
 		if err != nil {
-			log.Printf("IP Addressing Failure\nInvalid cmn-dynamic-pool.  Cowardly refusing to create it.")
+			logging.Warnf("IP Addressing Failure: Invalid cmn-dynamic-pool.  Cowardly refusing to create it.")
 		} else {
 			pool, err := tempNet.AddSubnetbyCIDR(*cmnDynamicPool, "cmn_metallb_address_pool", int16(v.GetInt("cmn-bootstrap-vlan")))
 			if err != nil {
-				log.Fatalf("IP Addressing Failure\n"+
-					"Couldn't add MetalLB Dynamic pool of %v to net %v: %v\n"+
-					"Possible missing or mismatched cmn-dynamic-pool input value.",
+				return &tempNet, fmt.Errorf("IP Addressing Failure: "+
+					"couldn't add MetalLB Dynamic pool of %v to net %v: %v.  "+
+					"Possible missing or mismatched cmn-dynamic-pool input value",
 					v.GetString("cmn-dynamic-pool"), tempNet.CIDR, err)
 			}
 			pool.FullName = "CMN Dynamic MetalLB"
@@ -197,13 +381,13 @@ func createNetFromLayoutConfig(conf NetworkLayoutConfiguration) (*IPV4Network, e
 			if v.GetString("can-static-pool") != "" {
 				_, canStaticPool, err := net.ParseCIDR(v.GetString("can-static-pool"))
 				if err != nil {
-					log.Printf("IP Addressing Failure\nInvalid can-static-pool.  Cowardly refusing to create it.")
+					logging.Warnf("IP Addressing Failure: Invalid can-static-pool.  Cowardly refusing to create it.")
 				} else {
 					static, err := tempNet.AddSubnetbyCIDR(*canStaticPool, "can_metallb_static_pool", int16(v.GetInt("can-bootstrap-vlan")))
 					if err != nil {
-						log.Fatalf("IP Addressing Failure\n"+
-							"Couldn't add MetalLB Static pool of %v to net %v: %v\n"+
-							"Possible missing or mismatched can-static-pool input value.",
+						return &tempNet, fmt.Errorf("IP Addressing Failure: "+
+							"couldn't add MetalLB Static pool of %v to net %v: %v.  "+
+							"Possible missing or mismatched can-static-pool input value",
 							v.GetString("can-static-pool"), tempNet.CIDR, err)
 					}
 					static.FullName = "CAN Static Pool MetalLB"
@@ -213,15 +397,14 @@ func createNetFromLayoutConfig(conf NetworkLayoutConfiguration) (*IPV4Network, e
 			if v.GetString("can-dynamic-pool") != "" {
 				_, canDynamicPool, err := net.ParseCIDR(v.GetString("can-dynamic-pool"))
 				if err != nil {
-					log.Printf("IP Addressing Failure\nInvalid can-dynamic-pool.  Cowardly refusing to create it.")
+					logging.Warnf("IP Addressing Failure: Invalid can-dynamic-pool.  Cowardly refusing to create it.")
 				} else {
 					pool, err := tempNet.AddSubnetbyCIDR(*canDynamicPool, "can_metallb_address_pool", int16(v.GetInt("can-bootstrap-vlan")))
 					if err != nil {
-						log.Fatalf("IP Addressing Failure\n"+
-							"Couldn't add MetalLB Dynamic pool of %v to net %v: %v\n"+
-							"Possible missing or mismatched can-dynamic-pool value.",
+						return &tempNet, fmt.Errorf("IP Addressing Failure: "+
+							"couldn't add MetalLB Dynamic pool of %v to net %v: %v.  "+
+							"Possible missing or mismatched can-dynamic-pool value",
 							v.GetString("can-dynamic-pool"), tempNet.CIDR, err)
-						log.Fatalf("Possible missing or mismatched can-dynamic-pool value.")
 					}
 					pool.FullName = "CAN Dynamic MetalLB"
 					pool.MetalLBPoolName = "customer-access"
@@ -239,13 +422,13 @@ func createNetFromLayoutConfig(conf NetworkLayoutConfiguration) (*IPV4Network, e
 			if v.GetString("chn-static-pool") != "" {
 				_, chnStaticPool, err := net.ParseCIDR(v.GetString("chn-static-pool"))
 				if err != nil {
-					log.Printf("IP Addressing Failure\nInvalid chn-static-pool.  Cowardly refusing to create it.")
+					logging.Warnf("IP Addressing Failure: Invalid chn-static-pool.  Cowardly refusing to create it.")
 				} else {
 					static, err := tempNet.AddSubnetbyCIDR(*chnStaticPool, "chn_metallb_static_pool", int16(v.GetInt("chn-bootstrap-vlan")))
 					if err != nil {
-						log.Fatalf("IP Addressing Failure\n"+
-							"Couldn't add MetalLB Static pool of %v to net %v: %v\n"+
-							"Possible missing or mismatched chn-static-pool input value.",
+						return &tempNet, fmt.Errorf("IP Addressing Failure: "+
+							"couldn't add MetalLB Static pool of %v to net %v: %v.  "+
+							"Possible missing or mismatched chn-static-pool input value",
 							v.GetString("chn-static-pool"), tempNet.CIDR, err)
 					}
 					static.FullName = "CHN Static Pool MetalLB"
@@ -255,15 +438,14 @@ func createNetFromLayoutConfig(conf NetworkLayoutConfiguration) (*IPV4Network, e
 			if v.GetString("chn-dynamic-pool") != "" {
 				_, chnDynamicPool, err := net.ParseCIDR(v.GetString("chn-dynamic-pool"))
 				if err != nil {
-					log.Printf("IP Addressing Failure\nInvalid chn-dynamic-pool.  Cowardly refusing to create it.")
+					logging.Warnf("IP Addressing Failure: Invalid chn-dynamic-pool.  Cowardly refusing to create it.")
 				} else {
 					pool, err := tempNet.AddSubnetbyCIDR(*chnDynamicPool, "chn_metallb_address_pool", int16(v.GetInt("chn-bootstrap-vlan")))
 					if err != nil {
-						log.Fatalf("IP Addressing Failure\n"+
-							"Couldn't add MetalLB Dynamic pool of %v to net %v: %v\n"+
-							"Possible missing or mismatched chn-dynamic-pool value.",
+						return &tempNet, fmt.Errorf("IP Addressing Failure: "+
+							"couldn't add MetalLB Dynamic pool of %v to net %v: %v.  "+
+							"Possible missing or mismatched chn-dynamic-pool value",
 							v.GetString("chn-dynamic-pool"), tempNet.CIDR, err)
-						log.Fatalf("Possible missing or mismatched chn-dynamic-pool value.")
 					}
 					pool.FullName = "CHN Dynamic MetalLB"
 					pool.MetalLBPoolName = "customer-high-speed"
@@ -277,11 +459,11 @@ func createNetFromLayoutConfig(conf NetworkLayoutConfiguration) (*IPV4Network, e
 	if tempNet.Name == "HSN" {
 		_, hsnDefaultSubnet, err := net.ParseCIDR(v.GetString("hsn-cidr"))
 		if err != nil {
-			log.Printf("IP Addressing Failure\nInvalid hsn-cidr.  Cowardly refusing to create it.")
+			logging.Warnf("IP Addressing Failure: Invalid hsn-cidr.  Cowardly refusing to create it.")
 		} else {
 			subnet, err := tempNet.AddSubnetbyCIDR(*hsnDefaultSubnet, "hsn_base_subnet", int16(DefaultHSN.VlanRange[0]))
 			if err != nil {
-				log.Fatalf("IP Addressing Failure\nCouldn't add hsn_base_subnet of %v to net %v: %v", v.GetString("hsn-cidr"), tempNet.CIDR, err)
+				return &tempNet, fmt.Errorf("IP Addressing Failure: couldn't add hsn_base_subnet of %v to net %v: %v", v.GetString("hsn-cidr"), tempNet.CIDR, err)
 			}
 			subnet.FullName = "HSN Base Subnet"
 		}
@@ -296,7 +478,16 @@ func createNetFromLayoutConfig(conf NetworkLayoutConfiguration) (*IPV4Network, e
 		}
 		// populate it with base information
 		hardwareSubnet.FullName = fmt.Sprintf("%v Management Network Infrastructure", tempNet.Name)
-		hardwareSubnet.ReserveNetMgmtIPs(spineSwitches, leafSwitches, leafbmcSwitches, cduSwitches)
+		if err := hardwareSubnet.ReserveNetMgmtIPs(spineSwitches, leafSwitches, leafbmcSwitches, cduSwitches); err != nil {
+			return &tempNet, err
+		}
+		for _, reservation := range conf.ManagementReservations {
+			if strings.EqualFold(reservation.Network, tempNet.Name) {
+				if _, err := hardwareSubnet.AddReservation(reservation.Name, reservation.Comment); err != nil {
+					return &tempNet, err
+				}
+			}
+		}
 	}
 
 	// Set up the Boostrap DHCP subnet(s)
@@ -304,27 +495,38 @@ func createNetFromLayoutConfig(conf NetworkLayoutConfiguration) (*IPV4Network, e
 		myNet := fmt.Sprintf("%s-cidr", netNameLower)
 		if v.GetString(myNet) != "" {
 			var subnet *IPV4Subnet
-			subnet, err := tempNet.AddBiggestSubnet(conf.DesiredBootstrapDHCPMask, "bootstrap_dhcp", conf.BaseVlan)
+			bootstrapMask, smallestMask, err := BootstrapSubnetMask(v, tempNet.Name, tempNet.CIDR, conf.DesiredBootstrapDHCPMask)
+			if err != nil {
+				return &tempNet, err
+			}
+			subnet, err = tempNet.AddBiggestSubnet(bootstrapMask, "bootstrap_dhcp", conf.BaseVlan, smallestMask)
 			if err != nil {
 				return &tempNet, fmt.Errorf("unable to add bootstrap_dhcp subnet to %v because %v", conf.Template.Name, err)
 			}
 			subnet.FullName = fmt.Sprintf("%v Bootstrap DHCP Subnet", tempNet.Name)
-			if tempNet.Name == "NMN" || tempNet.Name == "HMN" || tempNet.Name == "CMN" || tempNet.Name == "CAN" || tempNet.Name == "CHN" {
+			if stringInSlice(tempNet.Name, bootstrapVIPNetworks) {
 				if tempNet.Name == "CAN" {
 					subnet.CIDR = *canCIDR
 					subnet.Gateway = net.ParseIP(v.GetString("can-gateway"))
-					subnet.AddReservation("can-switch-1", "")
-					subnet.AddReservation("can-switch-2", "")
+					for _, name := range []string{"can-switch-1", "can-switch-2"} {
+						if _, err := subnet.AddReservation(name, ""); err != nil {
+							return &tempNet, err
+						}
+					}
 				} else if tempNet.Name == "CHN" {
 					subnet.CIDR = *chnCIDR
 					subnet.Gateway = net.ParseIP(v.GetString("chn-gateway"))
-					subnet.ReserveEdgeSwitchIPs(edgeSwitches)
-				} else {
-					subnet.ReserveNetMgmtIPs([]string{}, []string{}, []string{}, []string{})
+					if err := subnet.ReserveEdgeSwitchIPs(edgeSwitches); err != nil {
+						return &tempNet, err
+					}
 				}
-				subnet.AddReservation("kubeapi-vip", "k8s-virtual-ip")
-				if tempNet.Name == "NMN" {
-					subnet.AddReservation("rgw-vip", "rgw-virtual-ip")
+				if err := addVIPReservation(v, subnet, "kubeapi-vip", "k8s-virtual-ip", "kubeapi-vip-pin"); err != nil {
+					return &tempNet, err
+				}
+				if stringInSlice(tempNet.Name, RGWVIPNetworks(v)) {
+					if err := addVIPReservation(v, subnet, "rgw-vip", "rgw-virtual-ip", "rgw-vip-pin"); err != nil {
+						return &tempNet, err
+					}
 				}
 			}
 		}
@@ -341,54 +543,78 @@ func createNetFromLayoutConfig(conf NetworkLayoutConfiguration) (*IPV4Network, e
 	if conf.IncludeUAISubnet {
 		// Use the NMN vlan for uai_macvlan
 		uaisubnet, err := tempNet.AddSubnet(net.CIDRMask(23, 32), "uai_macvlan", int16(v.GetInt("nmn-bootstrap-vlan")))
-		_, supernetNet, _ := net.ParseCIDR(tempNet.CIDR)
-		uaisubnet.Gateway = ipam.Add(supernetNet.IP, 1)
 		if err != nil {
-			log.Fatalf("Couln't add the uai subnet to the %v Network: %v", tempNet.Name, err)
+			return &tempNet, fmt.Errorf("couldn't add the uai subnet to the %v Network: %v", tempNet.Name, err)
 		}
+		_, supernetNet, _ := net.ParseCIDR(tempNet.CIDR)
+		uaisubnet.Gateway = ipam.Add(supernetNet.IP, 1)
 		uaisubnet.FullName = "NMN UAIs"
 		for reservationName, reservationComment := range DefaultUAISubnetReservations {
-			reservation := uaisubnet.AddReservation(reservationName, strings.Join(reservationComment, ","))
+			reservation, err := uaisubnet.AddReservation(reservationName, strings.Join(reservationComment, ","))
+			if err != nil {
+				return &tempNet, err
+			}
 			for _, alias := range reservationComment {
 				reservation.AddReservationAlias(alias)
 			}
 		}
-		// log.Println("Added the MacVlan Subnet at ", uaisubnet.CIDR.String())
+		logging.Debugf("Added the MacVlan Subnet at %s", uaisubnet.CIDR.String())
 	}
 	// Build out the per-cabinet subnets
 	// If the networks are intended to be grouped, only do the listed cabinet type
 
+	var cabinetTypes []string
 	if conf.GroupNetworksByCabinetType && conf.SubdivideByCabinet {
 		if strings.HasSuffix(conf.Template.Name, "RVR") {
-			tempNet.GenSubnets(conf.CabinetDetails, conf.CabinetCIDR, "river")
+			cabinetTypes = []string{"river"}
 		}
 		if strings.HasSuffix(conf.Template.Name, "MTN") {
-			tempNet.GenSubnets(conf.CabinetDetails, conf.CabinetCIDR, "mountain")
-			tempNet.GenSubnets(conf.CabinetDetails, conf.CabinetCIDR, "hill")
+			cabinetTypes = []string{"mountain", "hill"}
 		}
 		// Otherwise do both
 	}
 	if conf.SubdivideByCabinet && !conf.GroupNetworksByCabinetType {
-		tempNet.GenSubnets(conf.CabinetDetails, conf.CabinetCIDR, "river")
-		tempNet.GenSubnets(conf.CabinetDetails, conf.CabinetCIDR, "mountain")
-		tempNet.GenSubnets(conf.CabinetDetails, conf.CabinetCIDR, "hill")
+		cabinetTypes = []string{"river", "mountain", "hill"}
+	}
+	for _, cabinetType := range cabinetTypes {
+		if err := tempNet.GenSubnets(conf.CabinetDetails, conf.CabinetCIDR, cabinetType); err != nil {
+			return &tempNet, err
+		}
+	}
+	if err := tempNet.ValidateUniqueSubnetNames(); err != nil {
+		return &tempNet, err
+	}
+
+	// Check the bootstrap subnet has room for the NCNs while it still has its own mask
+	if errs := ValidateBootstrapSubnetCapacity(map[string]*IPV4Network{tempNet.Name: &tempNet}, conf.NCNs); len(errs) > 0 {
+		return &tempNet, errs[0]
 	}
 
 	// Apply the Supernet Hack
 	if conf.SuperNetHack {
-		tempNet.applySupernetHack()
+		if err := tempNet.applySupernetHack(); err != nil {
+			return &tempNet, err
+		}
+	}
+
+	// Replace the computed gateways with the one given for the network
+	if !stringInSlice(tempNet.Name, gatewayOverrideExcludedNetworks) {
+		if err := tempNet.applyGatewayOverride(v); err != nil {
+			return &tempNet, err
+		}
 	}
 	return &tempNet, nil
 }
 
 // ApplySupernetHack applys a dirty hack.
-func (tempNet *IPV4Network) applySupernetHack() {
+func (tempNet *IPV4Network) applySupernetHack() error {
 	// Replace the gateway and netmask on the to better support the 1.3 network switch configuration
 	// *** This is a HACK ***
 	_, superNet, err := net.ParseCIDR(tempNet.CIDR)
 	if err != nil {
-		log.Fatal("Couldn't parse the CIDR for ", tempNet.Name)
+		return fmt.Errorf("couldn't parse the CIDR for %s: %v", tempNet.Name, err)
 	}
+	superNetGateway := ipam.Add(superNet.IP, 1)
 	for _, subnetName := range []string{"bootstrap_dhcp", "network_hardware",
 		"can_metallb_static_pool", "can_metallb_address_pool"} {
 		tempSubnet, err := tempNet.LookUpSubnet(subnetName)
@@ -398,10 +624,133 @@ func (tempNet *IPV4Network) applySupernetHack() {
 			// ** HACK ** We're doing this here to bypass all sanity checks
 			// This **WILL** cause an overlap of broadcast domains, but is required
 			// for reducing switch configuration changes from 1.3 to 1.4
-			tempSubnet.Gateway = ipam.Add(superNet.IP, 1)
+			if err := ValidateSupernetGateway(*superNet, superNetGateway, tempSubnet); err != nil {
+				return fmt.Errorf("invalid supernet for the %s network: %v", tempNet.Name, err)
+			}
+			tempSubnet.Gateway = superNetGateway
 			tempSubnet.CIDR.Mask = superNet.Mask
 		}
 	}
+	return nil
+}
+
+// ValidateSupernetGateway verifies that the supernet gateway is within the supernet and
+// is not one of the subnet's own network, broadcast, reserved or DHCP addresses
+func ValidateSupernetGateway(superNet net.IPNet, gateway net.IP, subnet *IPV4Subnet) error {
+	if !superNet.Contains(gateway) {
+		return fmt.Errorf("supernet gateway %v for the %s subnet is not within the supernet %v", gateway, subnet.Name, superNet.String())
+	}
+	return validateGatewayAddress("supernet gateway", gateway, subnet)
+}
+
+// validateGatewayAddress verifies that a gateway is not one of the subnet's own network, broadcast, reserved or
+// DHCP addresses.  The errors start with the label.
+func validateGatewayAddress(label string, gateway net.IP, subnet *IPV4Subnet) error {
+	if gateway.Equal(subnet.CIDR.IP) || gateway.Equal(ipam.Broadcast(subnet.CIDR)) {
+		return fmt.Errorf("%s %v is the network or broadcast address of the %s subnet %v", label, gateway, subnet.Name, subnet.CIDR.String())
+	}
+	for _, reservation := range subnet.IPReservations {
+		if gateway.Equal(reservation.IPAddress) {
+			return fmt.Errorf("%s %v is reserved for %s in the %s subnet", label, gateway, reservation.Name, subnet.Name)
+		}
+	}
+	if subnet.DHCPStart != nil && subnet.DHCPEnd != nil &&
+		!ipam.IPLessThan(gateway.To4(), subnet.DHCPStart.To4()) && !ipam.IPLessThan(subnet.DHCPEnd.To4(), gateway.To4()) {
+		return fmt.Errorf("%s %v is within the DHCP range %v-%v of the %s subnet", label, gateway, subnet.DHCPStart, subnet.DHCPEnd, subnet.Name)
+	}
+	return nil
+}
+
+// gatewayOverrideExcludedNetworks take their bootstrap gateway from can-gateway and chn-gateway already
+var gatewayOverrideExcludedNetworks = []string{"CAN", "CHN"}
+
+// applyGatewayOverride replaces the computed gateway of every subnet that contains the <net>-gateway address,
+// e.g. for a VRRP VIP at .254.  The subnets that do not contain it keep their computed gateway.
+func (tempNet *IPV4Network) applyGatewayOverride(v *viper.Viper) error {
+	key := fmt.Sprintf("%s-gateway", strings.ToLower(tempNet.Name))
+	if v.GetString(key) == "" {
+		return nil
+	}
+	gateway := net.ParseIP(v.GetString(key)).To4()
+	if gateway == nil {
+		return fmt.Errorf("%s %q is not a valid IPv4 address", key, v.GetString(key))
+	}
+	_, myNet, err := net.ParseCIDR(tempNet.CIDR)
+	if err != nil {
+		return fmt.Errorf("couldn't parse the CIDR for %s: %v", tempNet.Name, err)
+	}
+	if !myNet.Contains(gateway) {
+		return fmt.Errorf("%s %v is not within the %s network %v", key, gateway, tempNet.Name, myNet.String())
+	}
+
+	applied := false
+	for _, subnet := range tempNet.Subnets {
+		if !subnet.CIDR.Contains(gateway) {
+			continue
+		}
+		if err := validateGatewayAddress(key, gateway, subnet); err != nil {
+			return err
+		}
+		subnet.Gateway = gateway
+		applied = true
+	}
+	if !applied {
+		return fmt.Errorf("%s %v is not within any subnet of the %s network", key, gateway, tempNet.Name)
+	}
+	return nil
+}
+
+// SupernetEnabled decides whether the supernet hack applies to a network.  The supernet-networks list names the
+// networks in the supernet explicitly.  Otherwise supernet is either a boolean, the --supernet flag, that turns
+// the hack on or off for every network that uses it by default, or a map of network names to true/false read from
+// the system config, in which networks without an entry keep their layout default.  The result is also the
+// applySupernetHack argument of UpdateDHCPRange for the subnets of the network.
+func SupernetEnabled(v *viper.Viper, networkName string, layoutDefault bool) (bool, error) {
+	global := layoutDefault
+	perNetwork, isMap := v.Get("supernet").(map[string]interface{})
+	if v.IsSet("supernet") && !isMap {
+		global = layoutDefault && v.GetBool("supernet")
+	}
+	if v.IsSet("supernet-networks") {
+		if v.IsSet("supernet") && !isMap && !v.GetBool("supernet") {
+			return false, nil
+		}
+		for _, name := range v.GetStringSlice("supernet-networks") {
+			if strings.EqualFold(strings.TrimSpace(name), networkName) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	for name, enabled := range perNetwork {
+		if !strings.EqualFold(name, networkName) {
+			continue
+		}
+		switch enabled := enabled.(type) {
+		case bool:
+			return enabled, nil
+		case string:
+			if parsed, err := strconv.ParseBool(enabled); err == nil {
+				return parsed, nil
+			}
+		}
+		return false, fmt.Errorf("supernet entry for %s must be true or false, not %v", networkName, enabled)
+	}
+	return global, nil
+}
+
+// RGWVIPNetworks returns the networks the Ceph RGW virtual IP is reserved on. The first network is the primary one.
+func RGWVIPNetworks(v *viper.Viper) []string {
+	var networks []string
+	for _, network := range v.GetStringSlice("rgw-vip-networks") {
+		if network = strings.ToUpper(strings.TrimSpace(network)); network != "" {
+			networks = append(networks, network)
+		}
+	}
+	if len(networks) == 0 {
+		return DefaultRGWVIPNetworks
+	}
+	return networks
 }
 
 func switchXnamesByType(switches []*ManagementSwitch, switchType ManagementSwitchType) []string {
@@ -0,0 +1,419 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/csm-common/go/pkg/ipam"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+)
+
+type NetworkBuilderTestSuite struct {
+	suite.Suite
+}
+
+func (suite *NetworkBuilderTestSuite) TearDownTest() {
+	viper.Reset()
+}
+
+func (suite *NetworkBuilderTestSuite) TestRGWVIPNetworks_Default() {
+	suite.Equal([]string{"NMN"}, RGWVIPNetworks(viper.New()))
+}
+
+func (suite *NetworkBuilderTestSuite) TestRGWVIPOnCAN() {
+	viper.Set("can-cidr", DefaultCANString)
+	viper.Set("can-gateway", "10.102.11.1")
+	viper.Set("rgw-vip-networks", []string{"can"})
+
+	network, err := createNetFromLayoutConfig(GenDefaultCANConfig())
+	suite.NoError(err)
+
+	subnet, err := network.LookUpSubnet("bootstrap_dhcp")
+	suite.NoError(err)
+	rgwVIP := subnet.LookupReservation("rgw-vip")
+	suite.Equal("rgw-virtual-ip", rgwVIP.Comment)
+	suite.NotNil(rgwVIP.IPAddress)
+}
+
+func (suite *NetworkBuilderTestSuite) TestRGWVIPNetworks_Invalid() {
+	viper.Set("rgw-vip-networks", []string{"MTL"})
+
+	_, err := BuildCSMNetworks(map[string]NetworkLayoutConfiguration{"MTL": GenDefaultMTLConfig()}, nil, nil, nil)
+	suite.Equal(errors.New("rgw-vip-networks contains MTL which is not one of the configured [NMN HMN CMN CAN CHN] networks"), err)
+}
+
+func testSupernetNetwork(cidr string) *IPV4Network {
+	_, bootstrap, _ := net.ParseCIDR("10.252.1.0/24")
+	return &IPV4Network{
+		Name:    "NMN",
+		CIDR:    cidr,
+		Subnets: []*IPV4Subnet{{Name: "bootstrap_dhcp", CIDR: *bootstrap, Gateway: net.ParseIP("10.252.1.1")}},
+	}
+}
+
+func (suite *NetworkBuilderTestSuite) TestApplySupernetHack() {
+	network := testSupernetNetwork("10.252.0.0/17")
+	suite.NoError(network.applySupernetHack())
+	suite.Equal("10.252.0.1", network.Subnets[0].Gateway.String())
+	suite.Equal("10.252.1.0/17", network.Subnets[0].CIDR.String())
+}
+
+func (suite *NetworkBuilderTestSuite) TestApplySupernetHack_GatewayOutsideSupernet() {
+	network := testSupernetNetwork("10.252.1.0/32")
+	err := network.applySupernetHack()
+	suite.Equal(errors.New("invalid supernet for the NMN network: supernet gateway 10.252.1.1 for the bootstrap_dhcp subnet is not within the supernet 10.252.1.0/32"), err)
+}
+
+func (suite *NetworkBuilderTestSuite) TestApplySupernetHack_GatewayReserved() {
+	network := testSupernetNetwork("10.252.1.0/24")
+	network.Subnets[0].AddReservationWithIP("ncn-m001", "10.252.1.1", "x3000c0s1b0n0")
+	err := network.applySupernetHack()
+	suite.Equal(errors.New("invalid supernet for the NMN network: supernet gateway 10.252.1.1 is reserved for ncn-m001 in the bootstrap_dhcp subnet"), err)
+}
+
+func (suite *NetworkBuilderTestSuite) TestValidateSupernetGateway_DHCPRange() {
+	_, superNet, _ := net.ParseCIDR("10.252.0.0/17")
+	_, bootstrap, _ := net.ParseCIDR("10.252.0.0/24")
+	subnet := &IPV4Subnet{Name: "bootstrap_dhcp", CIDR: *bootstrap, DHCPStart: net.ParseIP("10.252.0.1"), DHCPEnd: net.ParseIP("10.252.0.200")}
+	err := ValidateSupernetGateway(*superNet, net.ParseIP("10.252.0.1"), subnet)
+	suite.Equal(errors.New("supernet gateway 10.252.0.1 is within the DHCP range 10.252.0.1-10.252.0.200 of the bootstrap_dhcp subnet"), err)
+}
+
+func (suite *NetworkBuilderTestSuite) TestSupernetEnabled_Config() {
+	v := viper.New()
+	v.SetDefault("supernet", true)
+	v.SetConfigType("yaml")
+	suite.NoError(v.ReadConfig(strings.NewReader("supernet:\n  NMN: true\n  HMN: false\n")))
+
+	for _, test := range []struct {
+		network       string
+		layoutDefault bool
+		expected      bool
+	}{
+		{"NMN", true, true},
+		{"HMN", true, false},
+		// networks without an entry keep their layout default
+		{"CMN", true, true},
+		{"CAN", false, false},
+	} {
+		enabled, err := SupernetEnabled(v, test.network, test.layoutDefault)
+		suite.NoError(err)
+		suite.Equal(test.expected, enabled, test.network)
+	}
+}
+
+func (suite *NetworkBuilderTestSuite) TestSupernetEnabled_Global() {
+	v := viper.New()
+	enabled, _ := SupernetEnabled(v, "CMN", true)
+	suite.True(enabled)
+
+	v.Set("supernet", false)
+	enabled, _ = SupernetEnabled(v, "CMN", true)
+	suite.False(enabled)
+
+	v.Set("supernet", true)
+	enabled, _ = SupernetEnabled(v, "CMN", true)
+	suite.True(enabled)
+	enabled, _ = SupernetEnabled(v, "CAN", false)
+	suite.False(enabled)
+}
+
+func (suite *NetworkBuilderTestSuite) TestSupernetEnabled_Invalid() {
+	v := viper.New()
+	v.Set("supernet", map[string]interface{}{"nmn": "sometimes"})
+	_, err := SupernetEnabled(v, "NMN", true)
+	suite.Equal(errors.New("supernet entry for NMN must be true or false, not sometimes"), err)
+}
+
+func (suite *NetworkBuilderTestSuite) TestSupernetEnabled_Networks() {
+	v := viper.New()
+	v.Set("supernet", true)
+	v.Set("supernet-networks", []string{"nmn", "HMN"})
+
+	for _, test := range []struct {
+		network       string
+		layoutDefault bool
+		expected      bool
+	}{
+		{"NMN", true, true},
+		{"HMN", true, true},
+		{"MTL", true, false},
+		{"CMN", true, false},
+		{"CAN", false, false},
+	} {
+		enabled, err := SupernetEnabled(v, test.network, test.layoutDefault)
+		suite.NoError(err)
+		suite.Equal(test.expected, enabled, test.network)
+	}
+
+	v.Set("supernet-networks", []string{"CAN"})
+	enabled, _ := SupernetEnabled(v, "CAN", false)
+	suite.True(enabled)
+
+	v.Set("supernet", false)
+	enabled, _ = SupernetEnabled(v, "CAN", false)
+	suite.False(enabled)
+}
+
+func testManagementReservationConfig() *viper.Viper {
+	viper.SetConfigType("yaml")
+	viper.ReadConfig(strings.NewReader(`
+management-reservations:
+  - name: sw-spine-vrrp
+    network: nmn
+    comment: spine VRRP VIP
+  - name: sw-spine-001-loopback
+    network: NMN
+`))
+	return viper.GetViper()
+}
+
+func (suite *NetworkBuilderTestSuite) TestManagementReservations() {
+	layout := GenDefaultNMNConfig()
+	var err error
+	layout.ManagementReservations, err = ManagementReservations(testManagementReservationConfig(), map[string]NetworkLayoutConfiguration{"NMN": layout})
+	suite.NoError(err)
+	layout.ManagementSwitches = []*ManagementSwitch{{Xname: "x3000c0h33s1", SwitchType: ManagementSwitchTypeSpine}}
+	layout.SuperNetHack = false
+
+	network, err := createNetFromLayoutConfig(layout)
+	suite.NoError(err)
+	hardware, err := network.LookUpSubnet("network_hardware")
+	suite.NoError(err)
+	suite.Equal([]string{"sw-spine-001", "sw-spine-vrrp", "sw-spine-001-loopback"}, reservationNames(hardware))
+	suite.Equal("spine VRRP VIP", hardware.LookupReservation("sw-spine-vrrp").Comment)
+	suite.True(hardware.CIDR.Contains(hardware.LookupReservation("sw-spine-001-loopback").IPAddress))
+}
+
+func (suite *NetworkBuilderTestSuite) TestManagementReservations_Invalid() {
+	v := testManagementReservationConfig()
+	_, err := ManagementReservations(v, map[string]NetworkLayoutConfiguration{"CAN": GenDefaultCANConfig()})
+	suite.Equal(errors.New(`management reservation sw-spine-vrrp is on "nmn" which is not a network with a network_hardware subnet`), err)
+
+	v.Set("management-reservations", []map[string]string{{"name": "vip", "network": "NMN"}, {"name": "vip", "network": "nmn"}})
+	_, err = ManagementReservations(v, map[string]NetworkLayoutConfiguration{"NMN": GenDefaultNMNConfig()})
+	suite.Equal(errors.New("management reservation vip is defined more than once on the nmn network"), err)
+}
+
+func (suite *NetworkBuilderTestSuite) TestBootstrapSubnetSize() {
+	viper.Set("nmn-cidr", DefaultNMNString)
+	viper.Set("nmn-bootstrap-subnet-size", 23)
+	layout := GenDefaultNMNConfig()
+	layout.SuperNetHack = false
+
+	network, err := createNetFromLayoutConfig(layout)
+	suite.NoError(err)
+	bootstrap, err := network.LookUpSubnet("bootstrap_dhcp")
+	suite.NoError(err)
+	ones, _ := bootstrap.CIDR.Mask.Size()
+	suite.Equal(23, ones)
+
+	var ncns []LogicalNCN
+	for i := 0; i < 600; i++ {
+		ncns = append(ncns, LogicalNCN{Xname: fmt.Sprintf("x3000c0s%db0n0", i)})
+	}
+	suite.Empty(ValidateBootstrapSubnetCapacity(map[string]*IPV4Network{"NMN": network}, ncns[:400]))
+	suite.Equal([]error{
+		fmt.Errorf("the NMN bootstrap_dhcp subnet %v has room for 509 reservations but 602 are required, increase nmn-bootstrap-subnet-size", bootstrap.CIDR.String()),
+	}, ValidateBootstrapSubnetCapacity(map[string]*IPV4Network{"NMN": network}, ncns))
+}
+
+func (suite *NetworkBuilderTestSuite) TestBuildCSMNetworks_BootstrapSubnetCapacity() {
+	viper.Set("nmn-cidr", DefaultNMNString)
+	viper.Set("nmn-bootstrap-subnet-size", 28)
+	layout := GenDefaultNMNConfig()
+	layout.SuperNetHack = true
+
+	var ncns []LogicalNCN
+	for i := 0; i < 12; i++ {
+		ncns = append(ncns, LogicalNCN{Xname: fmt.Sprintf("x3000c0s%db0n0", i)})
+	}
+	configs := map[string]NetworkLayoutConfiguration{"NMN": layout}
+	networks, err := BuildCSMNetworks(configs, nil, nil, ncns[:11])
+	suite.NoError(err)
+	bootstrap, err := networks["NMN"].LookUpSubnet("bootstrap_dhcp")
+	suite.NoError(err)
+	// The supernet hack widened the mask, the capacity was checked against the /28
+	ones, _ := bootstrap.CIDR.Mask.Size()
+	suite.Equal(17, ones)
+
+	_, err = BuildCSMNetworks(configs, nil, nil, ncns)
+	suite.Equal(errors.New("couldn't add NMN Network because the NMN bootstrap_dhcp subnet 10.252.1.0/28 has room for 13 reservations but 14 are required, increase nmn-bootstrap-subnet-size"), err)
+}
+
+func (suite *NetworkBuilderTestSuite) TestBuildCSMNetworks_DefaultCIDRs() {
+	viper.Set("hmn-cidr", DefaultHMNString)
+	viper.Set("nmn-cidr", DefaultNMNString)
+	viper.Set("chn-cidr", DefaultCHNString)
+	viper.Set("chn-gateway", "10.104.7.1")
+	viper.Set("hmn_mtn-cidr", DefaultHMNMTNString)
+	hmnMTN := NetworkLayoutConfiguration{
+		Template:                   IPV4Network{Name: "HMN_MTN", CIDR: DefaultHMNMTNString, VlanRange: []int16{3000, 3999}},
+		SubdivideByCabinet:         true,
+		GroupNetworksByCabinetType: true,
+		CabinetCIDR:                DefaultCabinetMask,
+	}
+	configs := map[string]NetworkLayoutConfiguration{
+		"HMN":     GenDefaultHMNConfig(),
+		"NMN":     GenDefaultNMNConfig(),
+		"CHN":     GenDefaultCHNConfig(),
+		"HMN_MTN": hmnMTN,
+	}
+	cabinets := []CabinetGroupDetail{{Kind: "mountain", CabinetDetails: []CabinetDetail{{ID: 1000}}}}
+
+	// The default CHN sits inside the HMN_MTN range, which is fine until a cabinet subnet reaches it
+	networks, err := BuildCSMNetworks(configs, cabinets, nil, nil)
+	suite.NoError(err)
+	cabinet, err := networks["HMN_MTN"].LookUpSubnet("cabinet_1000")
+	suite.NoError(err)
+	suite.Equal("10.104.0.0/22", cabinet.CIDR.String())
+
+	cabinets[0].CabinetDetails = append(cabinets[0].CabinetDetails, CabinetDetail{ID: 1001})
+	_, err = BuildCSMNetworks(configs, cabinets, nil, nil)
+	suite.Equal(errors.New("overlapping network CIDRs: the CHN bootstrap_dhcp subnet 10.104.7.0/24 overlaps the HMN_MTN cabinet_1001 subnet 10.104.4.0/22 in 10.104.7.0/24"), err)
+}
+
+func (suite *NetworkBuilderTestSuite) TestGatewayOverride() {
+	viper.Set("hmn-cidr", DefaultHMNString)
+	viper.Set("hmn-gateway", "10.254.1.254")
+	layout := GenDefaultHMNConfig()
+	layout.SuperNetHack = false
+
+	network, err := createNetFromLayoutConfig(layout)
+	suite.NoError(err)
+	bootstrap, err := network.LookUpSubnet("bootstrap_dhcp")
+	suite.NoError(err)
+	suite.Equal("10.254.1.0/24", bootstrap.CIDR.String())
+	suite.Equal("10.254.1.254", bootstrap.Gateway.String())
+	hardware, err := network.LookUpSubnet("network_hardware")
+	suite.NoError(err)
+	suite.Equal("10.254.0.1", hardware.Gateway.String())
+
+	// The overridden gateway is kept out of the DHCP pool
+	suite.NoError(bootstrap.UpdateDHCPRange(false))
+	suite.Equal("10.254.1.253", bootstrap.DHCPEnd.String())
+	for _, dhcpRange := range bootstrap.DHCPRangeList() {
+		suite.True(ipam.IPLessThan(dhcpRange.End, bootstrap.Gateway), dhcpRange.End.String())
+	}
+}
+
+func (suite *NetworkBuilderTestSuite) TestGatewayOverride_AddReservation() {
+	_, cidr, _ := net.ParseCIDR("10.254.1.0/24")
+	subnet := &IPV4Subnet{Name: "bootstrap_dhcp", CIDR: *cidr, Gateway: net.ParseIP("10.254.1.2")}
+	reservation, err := subnet.AddReservation("ncn-m001-mgmt", "x3000c0s1b0")
+	suite.NoError(err)
+	suite.Equal("10.254.1.3", reservation.IPAddress.String())
+}
+
+func (suite *NetworkBuilderTestSuite) TestGatewayOverride_Invalid() {
+	viper.Set("hmn-cidr", DefaultHMNString)
+	layout := GenDefaultHMNConfig()
+	layout.SuperNetHack = false
+
+	viper.Set("hmn-gateway", "10.254.1.0")
+	_, err := createNetFromLayoutConfig(layout)
+	suite.Equal(errors.New("hmn-gateway 10.254.1.0 is the network or broadcast address of the bootstrap_dhcp subnet 10.254.1.0/24"), err)
+
+	viper.Set("hmn-gateway", "10.252.1.254")
+	_, err = createNetFromLayoutConfig(layout)
+	suite.Equal(errors.New("hmn-gateway 10.252.1.254 is not within the HMN network 10.254.0.0/17"), err)
+}
+
+func (suite *NetworkBuilderTestSuite) TestVIPPins() {
+	viper.Set("nmn-cidr", DefaultNMNString)
+	viper.Set("kubeapi-vip-pin", 5)
+	viper.Set("rgw-vip-pin", 3)
+	layout := GenDefaultNMNConfig()
+	layout.SuperNetHack = false
+
+	network, err := createNetFromLayoutConfig(layout)
+	suite.NoError(err)
+	bootstrap, err := network.LookUpSubnet("bootstrap_dhcp")
+	suite.NoError(err)
+	reservations := bootstrap.ReservationsByName()
+	suite.Equal("10.252.1.5", reservations["kubeapi-vip"].IPAddress.String())
+	suite.Equal("k8s-virtual-ip", reservations["kubeapi-vip"].Comment)
+	suite.Equal("10.252.1.3", reservations["rgw-vip"].IPAddress.String())
+
+	// The next reservations skip over the pinned addresses
+	for _, name := range []string{"ncn-m001", "ncn-m002", "ncn-m003", "ncn-w001"} {
+		_, err := bootstrap.AddReservation(name, "")
+		suite.NoError(err)
+	}
+	reservations = bootstrap.ReservationsByName()
+	suite.Equal("10.252.1.2", reservations["ncn-m001"].IPAddress.String())
+	suite.Equal("10.252.1.4", reservations["ncn-m002"].IPAddress.String())
+	suite.Equal("10.252.1.6", reservations["ncn-m003"].IPAddress.String())
+	suite.Equal("10.252.1.7", reservations["ncn-w001"].IPAddress.String())
+}
+
+func (suite *NetworkBuilderTestSuite) TestVIPPins_HighPin() {
+	viper.Set("nmn-cidr", DefaultNMNString)
+	viper.Set("kubeapi-vip-pin", 200)
+	viper.Set("rgw-vip-pin", 3)
+	layout := GenDefaultNMNConfig()
+	layout.SuperNetHack = false
+
+	network, err := createNetFromLayoutConfig(layout)
+	suite.NoError(err)
+	bootstrap, err := network.LookUpSubnet("bootstrap_dhcp")
+	suite.NoError(err)
+	suite.Equal("10.252.1.200", bootstrap.ReservationsByName()["kubeapi-vip"].IPAddress.String())
+
+	// The pinned address is carved out of the DHCP pool that UpdateDHCPRange lays out above the reservations
+	suite.NoError(bootstrap.UpdateDHCPRange(false))
+	suite.Equal([]DHCPRange{
+		{Start: net.ParseIP("10.252.1.10").To4(), End: net.ParseIP("10.252.1.199").To4()},
+		{Start: net.ParseIP("10.252.1.201").To4(), End: net.ParseIP("10.252.1.254").To4()},
+	}, bootstrap.DHCPRangeList())
+}
+
+func (suite *NetworkBuilderTestSuite) TestVIPPins_Collision() {
+	viper.Set("nmn-cidr", DefaultNMNString)
+	layout := GenDefaultNMNConfig()
+	layout.SuperNetHack = false
+
+	viper.Set("kubeapi-vip-pin", 1)
+	_, err := createNetFromLayoutConfig(layout)
+	suite.Equal(errors.New("kubeapi-vip-pin 1 puts kubeapi-vip on 10.252.1.1, the gateway of the bootstrap_dhcp subnet"), err)
+
+	viper.Set("kubeapi-vip-pin", 5)
+	viper.Set("rgw-vip-pin", 5)
+	_, err = createNetFromLayoutConfig(layout)
+	suite.Equal(errors.New("rgw-vip-pin 5 puts rgw-vip on 10.252.1.5, which is already reserved for kubeapi-vip"), err)
+}
+
+func (suite *NetworkBuilderTestSuite) TestBootstrapSubnetMask_Invalid() {
+	v := viper.New()
+	v.Set("hmn-bootstrap-subnet-size", 16)
+	_, _, err := BootstrapSubnetMask(v, "HMN", DefaultHMNString, net.CIDRMask(24, 32))
+	suite.Equal(errors.New("hmn-bootstrap-subnet-size /16 must be between /17, the size of the HMN network, and /29"), err)
+
+	mask, smallest, err := BootstrapSubnetMask(v, "CAN", DefaultCANString, net.CIDRMask(24, 32))
+	suite.NoError(err)
+	suite.Equal(net.CIDRMask(24, 32), mask)
+	suite.Equal(DefaultSmallestSubnetMask, smallest)
+}
+
+func reservationNames(subnet *IPV4Subnet) []string {
+	var names []string
+	for _, reservation := range subnet.IPReservations {
+		names = append(names, reservation.Name)
+	}
+	return names
+}
+
+func TestNetworkBuilderTestSuite(t *testing.T) {
+	suite.Run(t, new(NetworkBuilderTestSuite))
+}
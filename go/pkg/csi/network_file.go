@@ -0,0 +1,70 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"fmt"
+	"math/bits"
+	"net"
+	"path/filepath"
+
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+)
+
+// SubnetMaskForSize returns the mask of a subnet with size addresses, which must be a power of two between
+// the 2 addresses of a /31 and the 2^31 of a /1
+func SubnetMaskForSize(size int) (net.IPMask, error) {
+	if size < 2 || int64(size) > 1<<31 || size&(size-1) != 0 {
+		return nil, fmt.Errorf("subnet size %d must be a power of two between 2 and %d addresses", size, int64(1)<<31)
+	}
+	return net.CIDRMask(32-bits.TrailingZeros(uint(size)), 32), nil
+}
+
+// AddSubnetToNetworkFile allocates a subnet of size addresses named name in the network file at path, such as
+// networks/NMN.yaml, and writes the network back.  Unless exact is set, a smaller subnet down to a
+// /DefaultSmallestSubnetMask is allocated when there is no room for the requested size, as AddBiggestSubnet does.
+// Names are unique within a network so an existing subnet with the name is an error.
+func AddSubnetToNetworkFile(path, name string, size int, vlanID int16, exact bool) (*IPV4Subnet, error) {
+	mask, err := SubnetMaskForSize(size)
+	if err != nil {
+		return nil, err
+	}
+
+	var network IPV4Network
+	if err := csiFiles.ReadYAMLConfig(path, &network); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %v", path, err)
+	}
+	netName := network.Name
+	if netName == "" {
+		netName = filepath.Base(path)
+	}
+	if _, _, err := net.ParseCIDR(network.CIDR); err != nil {
+		return nil, fmt.Errorf("unable to add %s to the %s network: %v", name, netName, err)
+	}
+	for _, subnet := range network.Subnets {
+		if subnet.Name == name {
+			return nil, fmt.Errorf("the %s network already has a subnet named %s (%v)", netName, name, subnet.CIDR.String())
+		}
+	}
+
+	var subnet *IPV4Subnet
+	if exact {
+		subnet, err = network.AddSubnet(mask, name, vlanID)
+	} else {
+		smallestMask, _ := mask.Size()
+		if smallestMask < DefaultSmallestSubnetMask {
+			smallestMask = DefaultSmallestSubnetMask
+		}
+		subnet, err = network.AddBiggestSubnet(mask, name, vlanID, smallestMask)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to add %s to the %s network: %v", name, netName, err)
+	}
+
+	if err := csiFiles.WriteYAMLConfig(path, network); err != nil {
+		return nil, err
+	}
+	return subnet, nil
+}
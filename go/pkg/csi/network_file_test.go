@@ -0,0 +1,89 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+	"github.com/stretchr/testify/suite"
+)
+
+type NetworkFileTestSuite struct {
+	suite.Suite
+	dir  string
+	path string
+}
+
+func (suite *NetworkFileTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "networks")
+	suite.NoError(err)
+	suite.dir = dir
+	suite.path = filepath.Join(dir, "NMN.yaml")
+
+	_, bootstrap, _ := net.ParseCIDR("10.252.0.0/24")
+	network := IPV4Network{
+		Name:    "NMN",
+		CIDR:    "10.252.0.0/22",
+		Subnets: []*IPV4Subnet{{Name: "bootstrap_dhcp", CIDR: *bootstrap, VlanID: 2}},
+	}
+	suite.NoError(csiFiles.WriteYAMLConfig(suite.path, network))
+}
+
+func (suite *NetworkFileTestSuite) TearDownTest() {
+	os.RemoveAll(suite.dir)
+}
+
+func (suite *NetworkFileTestSuite) TestAddSubnetToNetworkFile() {
+	subnet, err := AddSubnetToNetworkFile(suite.path, "my_subnet", 64, 2, true)
+	suite.NoError(err)
+	suite.Equal("10.252.1.0/26", subnet.CIDR.String())
+
+	networks, err := ReadNetworksDirectory(suite.dir)
+	suite.NoError(err)
+	saved, err := networks["NMN"].LookUpSubnet("my_subnet")
+	suite.NoError(err)
+	suite.Equal("10.252.1.0/26", saved.CIDR.String())
+	suite.Equal("10.252.1.1", saved.Gateway.String())
+	suite.Len(networks["NMN"].Subnets, 2)
+}
+
+func (suite *NetworkFileTestSuite) TestAddSubnetToNetworkFile_Biggest() {
+	_, err := AddSubnetToNetworkFile(suite.path, "filler", 512, 2, true)
+	suite.NoError(err)
+
+	_, err = AddSubnetToNetworkFile(suite.path, "too_big", 512, 2, true)
+	suite.Error(err)
+
+	subnet, err := AddSubnetToNetworkFile(suite.path, "smaller", 512, 2, false)
+	suite.NoError(err)
+	suite.Equal("10.252.1.0/24", subnet.CIDR.String())
+}
+
+func (suite *NetworkFileTestSuite) TestAddSubnetToNetworkFile_Duplicate() {
+	_, err := AddSubnetToNetworkFile(suite.path, "bootstrap_dhcp", 64, 2, true)
+	suite.Equal(errors.New("the NMN network already has a subnet named bootstrap_dhcp (10.252.0.0/24)"), err)
+}
+
+func (suite *NetworkFileTestSuite) TestSubnetMaskForSize() {
+	mask, err := SubnetMaskForSize(64)
+	suite.NoError(err)
+	suite.Equal(net.CIDRMask(26, 32), mask)
+
+	_, err = SubnetMaskForSize(100)
+	suite.Equal(errors.New("subnet size 100 must be a power of two between 2 and 2147483648 addresses"), err)
+}
+
+func TestNetworkFileTestSuite(t *testing.T) {
+	suite.Run(t, new(NetworkFileTestSuite))
+}
@@ -0,0 +1,462 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type IPV4NetworkTestSuite struct {
+	suite.Suite
+}
+
+func testCabinetNetwork(direction string) IPV4Network {
+	return IPV4Network{
+		Name:                "NMN_RVR",
+		CIDR:                "10.100.0.0/16",
+		VlanRange:           []int16{1770, 1999},
+		AllocationDirection: direction,
+	}
+}
+
+func testRiverCabinets() []CabinetGroupDetail {
+	return []CabinetGroupDetail{{
+		Kind: "river",
+		CabinetDetails: []CabinetDetail{
+			{ID: 3000},
+			{ID: 3001},
+		},
+	}}
+}
+
+func (suite *IPV4NetworkTestSuite) TestAddSubnet_DefaultBottom() {
+	network := testCabinetNetwork("")
+	subnet, err := network.AddSubnet(net.CIDRMask(22, 32), "test", 10)
+	suite.NoError(err)
+	suite.Equal("10.100.0.0/22", subnet.CIDR.String())
+}
+
+func (suite *IPV4NetworkTestSuite) TestAddSubnet_Top() {
+	network := testCabinetNetwork(SubnetAllocationTop)
+	subnet, err := network.AddSubnet(net.CIDRMask(22, 32), "test", 10)
+	suite.NoError(err)
+	suite.Equal("10.100.252.0/22", subnet.CIDR.String())
+	suite.Equal("10.100.252.1", subnet.Gateway.String())
+}
+
+func (suite *IPV4NetworkTestSuite) TestGenSubnets_Top() {
+	network := testCabinetNetwork(SubnetAllocationTop)
+	err := network.GenSubnets(testRiverCabinets(), net.CIDRMask(22, 32), "river")
+	suite.NoError(err)
+	suite.Len(network.Subnets, 2)
+	suite.Equal("cabinet_3000", network.Subnets[0].Name)
+	suite.Equal("10.100.252.0/22", network.Subnets[0].CIDR.String())
+	suite.Equal("cabinet_3001", network.Subnets[1].Name)
+	suite.Equal("10.100.248.0/22", network.Subnets[1].CIDR.String())
+}
+
+func (suite *IPV4NetworkTestSuite) TestGenSubnets_Bottom() {
+	network := testCabinetNetwork(SubnetAllocationBottom)
+	err := network.GenSubnets(testRiverCabinets(), net.CIDRMask(22, 32), "river")
+	suite.NoError(err)
+	suite.Equal("10.100.0.0/22", network.Subnets[0].CIDR.String())
+	suite.Equal("10.100.4.0/22", network.Subnets[1].CIDR.String())
+}
+
+func (suite *IPV4NetworkTestSuite) TestGenSubnets_Pinned() {
+	network := testCabinetNetwork(SubnetAllocationBottom)
+	cabinets := []CabinetGroupDetail{{
+		Kind: "river",
+		CabinetDetails: []CabinetDetail{
+			{ID: 3000},
+			{ID: 3001, NMNSubnet: "10.100.0.0/22"},
+			{ID: 3002, HMNSubnet: "10.107.0.0/22"},
+		},
+	}}
+	suite.NoError(network.GenSubnets(cabinets, net.CIDRMask(22, 32), "river"))
+
+	suite.Len(network.Subnets, 3)
+	suite.Equal("10.100.4.0/22", network.Subnets[0].CIDR.String())
+	suite.Equal("10.100.0.0/22", network.Subnets[1].CIDR.String())
+	suite.Equal("10.100.0.1", network.Subnets[1].Gateway.String())
+	suite.Equal("10.100.8.0/22", network.Subnets[2].CIDR.String())
+}
+
+func (suite *IPV4NetworkTestSuite) TestGenSubnets_PinnedCollision() {
+	network := testCabinetNetwork(SubnetAllocationBottom)
+	_, uai, _ := net.ParseCIDR("10.100.0.0/23")
+	network.Subnets = []*IPV4Subnet{{Name: "uai_macvlan", CIDR: *uai}}
+	cabinets := []CabinetGroupDetail{{
+		Kind:           "river",
+		CabinetDetails: []CabinetDetail{{ID: 3000, NMNSubnet: "10.100.0.0/22"}},
+	}}
+	err := network.GenSubnets(cabinets, net.CIDRMask(22, 32), "river")
+	suite.Equal(errors.New("subnet 10.100.0.0/22 of cabinet 3000 overlaps the allocated subnet 10.100.0.0/23 of the NMN_RVR network"), err)
+
+	network.Subnets = nil
+	cabinets[0].CabinetDetails = append(cabinets[0].CabinetDetails, CabinetDetail{ID: 3001, NMNSubnet: "10.100.2.0/24"})
+	err = network.GenSubnets(cabinets, net.CIDRMask(22, 32), "river")
+	suite.Equal(errors.New("subnet 10.100.2.0/24 of cabinet 3001 overlaps subnet 10.100.0.0/22 of cabinet 3000 in the NMN_RVR network"), err)
+
+	cabinets[0].CabinetDetails = []CabinetDetail{{ID: 3000, NMNSubnet: "10.101.0.0/22"}}
+	err = network.GenSubnets(cabinets, net.CIDRMask(22, 32), "river")
+	suite.Equal(errors.New("subnet 10.101.0.0/22 of cabinet 3000 is not part of the NMN_RVR network 10.100.0.0/16"), err)
+}
+
+func (suite *IPV4NetworkTestSuite) TestValidateUniqueSubnetNames() {
+	network := testCabinetNetwork(SubnetAllocationBottom)
+	suite.NoError(network.GenSubnets(testRiverCabinets(), net.CIDRMask(22, 32), "river"))
+	suite.NoError(network.ValidateUniqueSubnetNames())
+
+	cabinets := []CabinetGroupDetail{{
+		Kind:           "river",
+		CabinetDetails: []CabinetDetail{{ID: 3000}, {ID: 3001}, {ID: 3000}, {ID: 3001}, {ID: 3001}},
+	}}
+	network = testCabinetNetwork(SubnetAllocationBottom)
+	suite.NoError(network.GenSubnets(cabinets, net.CIDRMask(22, 32), "river"))
+	suite.Equal(errors.New("the NMN_RVR network has more than one subnet named cabinet_3000 (2 times), cabinet_3001 (3 times)"), network.ValidateUniqueSubnetNames())
+}
+
+func (suite *IPV4NetworkTestSuite) TestEnsureReservation() {
+	_, subnetNet, _ := net.ParseCIDR("10.252.1.0/30")
+	subnet := IPV4Subnet{Name: "bootstrap_dhcp", CIDR: *subnetNet}
+
+	reservation, created, err := subnet.EnsureReservation("kubeapi-vip", "k8s-virtual-ip")
+	suite.NoError(err)
+	suite.True(created)
+	suite.Equal("10.252.1.2", reservation.IPAddress.String())
+
+	// The second call finds the first reservation rather than adding another
+	reservation.AddReservationAlias("kubeapi-vip.nmn")
+	reservation, created, err = subnet.EnsureReservation("kubeapi-vip", "ignored")
+	suite.NoError(err)
+	suite.False(created)
+	suite.Equal("k8s-virtual-ip", reservation.Comment)
+	suite.Equal([]string{"kubeapi-vip.nmn"}, subnet.IPReservations[0].Aliases)
+	suite.Len(subnet.IPReservations, 1)
+
+	_, created, err = subnet.EnsureReservation("rgw-vip", "rgw-virtual-ip")
+	suite.Equal(errors.New("subnet bootstrap_dhcp (10.252.1.0/30) is full, unable to reserve an address for rgw-vip"), err)
+	suite.False(created)
+}
+
+func (suite *IPV4NetworkTestSuite) TestBuildCabinetNetworkMap() {
+	nmn := testCabinetNetwork("")
+	suite.NoError(nmn.GenSubnets(testRiverCabinets(), net.CIDRMask(22, 32), "river"))
+
+	hmn := IPV4Network{Name: "HMN_RVR", CIDR: "10.107.0.0/17", VlanRange: []int16{1513, 1769}}
+	suite.NoError(hmn.GenSubnets(testRiverCabinets(), net.CIDRMask(22, 32), "river"))
+
+	cabinetMap := BuildCabinetNetworkMap(map[string]*IPV4Network{"NMN_RVR": &nmn, "HMN_RVR": &hmn})
+	suite.Len(cabinetMap, 2)
+	suite.Equal(CabinetNetworks{
+		NMN: &CabinetSubnet{Network: "NMN_RVR", VlanID: 1771, CIDR: "10.100.4.0/22", Gateway: "10.100.4.1"},
+		HMN: &CabinetSubnet{Network: "HMN_RVR", VlanID: 1514, CIDR: "10.107.4.0/22", Gateway: "10.107.4.1"},
+	}, cabinetMap["x3001"])
+}
+
+func (suite *IPV4NetworkTestSuite) TestUpdateDHCPRange_EndPadding() {
+	_, subnetNet, _ := net.ParseCIDR("10.252.1.0/24")
+	subnet := IPV4Subnet{Name: "bootstrap_dhcp", CIDR: *subnetNet}
+
+	suite.NoError(subnet.UpdateDHCPRange(false))
+	suite.Equal("10.252.1.10", subnet.DHCPStart.String())
+	suite.Equal("10.252.1.254", subnet.DHCPEnd.String())
+
+	subnet.DHCPEndPadding = 10
+	suite.NoError(subnet.UpdateDHCPRange(false))
+	suite.Equal("10.252.1.10", subnet.DHCPStart.String())
+	suite.Equal("10.252.1.244", subnet.DHCPEnd.String())
+}
+
+func (suite *IPV4NetworkTestSuite) TestUpdateDHCPRange_PaddingTooLarge() {
+	_, subnetNet, _ := net.ParseCIDR("10.252.1.0/24")
+	subnet := IPV4Subnet{Name: "bootstrap_dhcp", FullName: "NMN Bootstrap DHCP Subnet", NetName: "NMN", CIDR: *subnetNet, DHCPEndPadding: 250}
+
+	err := subnet.UpdateDHCPRange(false)
+	suite.Equal(errors.New("could not create NMN Bootstrap DHCP Subnet subnet in NMN.  A padding of 250 addresses leaves an empty DHCP range in the subnet 10.252.1.0/24"), err)
+}
+
+func (suite *IPV4NetworkTestSuite) TestUpdateDHCPRange_Inverted() {
+	_, subnetNet, _ := net.ParseCIDR("10.252.3.0/29")
+	subnet := IPV4Subnet{Name: "network_hardware", FullName: "NMN Management Network Infrastructure", NetName: "NMN", CIDR: *subnetNet}
+	for _, name := range []string{"sw-spine-001", "sw-spine-002", "sw-leaf-bmc-001", "sw-leaf-bmc-002", "sw-leaf-bmc-003"} {
+		_, err := subnet.AddReservation(name, "")
+		suite.NoError(err)
+	}
+
+	err := subnet.UpdateDHCPRange(false)
+	suite.Equal(errors.New("could not create NMN Management Network Infrastructure subnet in NMN.  The DHCP range would start at 10.252.3.10, after the gateway and 5 reservations, but end at 10.252.3.6.  Use a larger subnet than 10.252.3.0/29"), err)
+}
+
+func (suite *IPV4NetworkTestSuite) TestUpdateDHCPRange_Exclusions() {
+	_, subnetNet, _ := net.ParseCIDR("10.252.1.0/24")
+	_, external, _ := net.ParseCIDR("10.252.1.64/26")
+	_, top, _ := net.ParseCIDR("10.252.1.248/29")
+	subnet := IPV4Subnet{Name: "bootstrap_dhcp", CIDR: *subnetNet, DHCPExclusions: []net.IPNet{*top, *external}}
+
+	suite.NoError(subnet.UpdateDHCPRange(false))
+	suite.Equal([]DHCPRange{
+		{Start: net.ParseIP("10.252.1.10").To4(), End: net.ParseIP("10.252.1.63").To4()},
+		{Start: net.ParseIP("10.252.1.128").To4(), End: net.ParseIP("10.252.1.247").To4()},
+	}, subnet.DHCPRangeList())
+	suite.Equal("10.252.1.10", subnet.DHCPStart.String())
+	suite.Equal("10.252.1.247", subnet.DHCPEnd.String())
+
+	subnet.DHCPExclusions = nil
+	suite.NoError(subnet.UpdateDHCPRange(false))
+	suite.Empty(subnet.DHCPRanges)
+	suite.Equal([]DHCPRange{{Start: subnet.DHCPStart, End: subnet.DHCPEnd}}, subnet.DHCPRangeList())
+	suite.Equal("10.252.1.254", subnet.DHCPEnd.String())
+}
+
+func (suite *IPV4NetworkTestSuite) TestUpdateDHCPRange_ExclusionsCoverRange() {
+	_, subnetNet, _ := net.ParseCIDR("10.252.1.0/24")
+	subnet := IPV4Subnet{Name: "bootstrap_dhcp", FullName: "NMN Bootstrap DHCP Subnet", NetName: "NMN", CIDR: *subnetNet, DHCPExclusions: []net.IPNet{*subnetNet}}
+
+	err := subnet.UpdateDHCPRange(false)
+	suite.Equal(errors.New("could not create NMN Bootstrap DHCP Subnet subnet in NMN.  The DHCP exclusions leave an empty DHCP range in the subnet 10.252.1.0/24"), err)
+}
+
+func (suite *IPV4NetworkTestSuite) TestGenSubnets_DualStack() {
+	network := testCabinetNetwork("")
+	network.CIDR6 = "fd00:100::/48"
+	suite.NoError(network.GenSubnets(testRiverCabinets(), net.CIDRMask(22, 32), "river"))
+
+	suite.Len(network.Subnets, 2)
+	suite.Equal("10.100.0.0/22", network.Subnets[0].CIDR.String())
+	suite.Equal("fd00:100::/64", network.Subnets[0].CIDR6.String())
+	suite.Equal("fd00:100::1", network.Subnets[0].Gateway6.String())
+	suite.Equal("10.100.4.0/22", network.Subnets[1].CIDR.String())
+	suite.Equal("fd00:100:0:1::/64", network.Subnets[1].CIDR6.String())
+	suite.Equal(network.Subnets[0].VlanID+1, network.Subnets[1].VlanID)
+}
+
+func (suite *IPV4NetworkTestSuite) TestAddSubnet_DualStack() {
+	network := testCabinetNetwork("")
+	network.CIDR6 = "fd00:100::/48"
+	subnet, err := network.AddSubnet(net.CIDRMask(24, 32), "bootstrap_dhcp", 2)
+	suite.NoError(err)
+	suite.Equal("10.100.0.0/24", subnet.CIDR.String())
+	suite.Equal("fd00:100::/64", subnet.CIDR6.String())
+
+	reservation, err := subnet.AddReservation("ncn-w001", "x3000c0s7b0n0")
+	suite.NoError(err)
+	suite.Equal("10.100.0.2", reservation.IPAddress.String())
+	suite.Equal("fd00:100::2", reservation.IPv6Address.String())
+
+	reservation, err = subnet.AddReservationWithIP("ncn-w002", "10.100.0.20", "x3000c0s9b0n0")
+	suite.NoError(err)
+	suite.Equal("fd00:100::14", reservation.IPv6Address.String())
+}
+
+func (suite *IPV4NetworkTestSuite) TestAddSubnetbyCIDR_DualStack() {
+	network := testCabinetNetwork("")
+	network.CIDR6 = "fd00:100::/48"
+	_, pool, _ := net.ParseCIDR("10.100.8.0/24")
+	subnet, err := network.AddSubnetbyCIDR(*pool, "metallb_static_pool", 2)
+	suite.NoError(err)
+	suite.Equal("fd00:100::/64", subnet.CIDR6.String())
+	suite.Equal("fd00:100::1", subnet.Gateway6.String())
+}
+
+func (suite *IPV4NetworkTestSuite) TestAddReservationWithPin_DualStack() {
+	network := testCabinetNetwork("")
+	network.CIDR6 = "fd00:100::/48"
+	subnet, err := network.AddSubnet(net.CIDRMask(24, 32), "bootstrap_dhcp", 2)
+	suite.NoError(err)
+
+	reservation, err := subnet.AddReservationWithPin("kubeapi-vip", "k8s-virtual-ip", 200)
+	suite.NoError(err)
+	suite.Equal("10.100.0.200", reservation.IPAddress.String())
+	suite.Equal("fd00:100::c8", reservation.IPv6Address.String())
+}
+
+func (suite *IPV4NetworkTestSuite) TestAddBiggestSubnet_IPv6Full() {
+	network := testCabinetNetwork("")
+	network.CIDR6 = "fd00:100::/64"
+	_, err := network.AddSubnet(net.CIDRMask(24, 32), "network_hardware", 2)
+	suite.NoError(err)
+
+	// The IPv4 space is plentiful, so the IPv6 failure is reported instead of shrinking the mask
+	_, err = network.AddBiggestSubnet(net.CIDRMask(24, 32), "bootstrap_dhcp", 2, DefaultSmallestSubnetMask)
+	suite.Error(err)
+	suite.Contains(err.Error(), "couldn't add an IPv6 subnet for bootstrap_dhcp to the NMN_RVR network")
+	suite.Len(network.Subnets, 1)
+}
+
+func (suite *IPV4NetworkTestSuite) TestAddSubnet_IPv4Only() {
+	network := testCabinetNetwork("")
+	subnet, err := network.AddSubnet(net.CIDRMask(24, 32), "bootstrap_dhcp", 2)
+	suite.NoError(err)
+	suite.Nil(subnet.CIDR6.IP)
+	reservation, err := subnet.AddReservation("ncn-w001", "x3000c0s7b0n0")
+	suite.NoError(err)
+	suite.Nil(reservation.IPv6Address)
+}
+
+func (suite *IPV4NetworkTestSuite) TestConvertIPV4NetworkToSLS_DualStack() {
+	network := testCabinetNetwork("")
+	network.CIDR6 = "fd00:100::/48"
+	suite.Equal([]string{"10.100.0.0/16", "fd00:100::/48"}, ConvertIPV4NetworkToSLS(&network).IPRanges)
+}
+
+func (suite *IPV4NetworkTestSuite) TestRemoveSubnet() {
+	network := testCabinetNetwork("")
+	for _, name := range []string{"first", "second", "third"} {
+		_, err := network.AddSubnet(net.CIDRMask(24, 32), name, 10)
+		suite.NoError(err)
+	}
+	_, removed, _ := net.ParseCIDR("10.100.1.0/24")
+
+	suite.NoError(network.RemoveSubnet("second"))
+	suite.NotContains(network.AllocatedSubnets(), *removed)
+	first, _ := network.LookUpSubnet("first")
+	suite.Equal("10.100.0.0/24", first.CIDR.String())
+	third, _ := network.LookUpSubnet("third")
+	suite.Equal("10.100.2.0/24", third.CIDR.String())
+
+	subnet, err := network.AddSubnet(net.CIDRMask(24, 32), "fourth", 10)
+	suite.NoError(err)
+	suite.Equal(removed.String(), subnet.CIDR.String())
+}
+
+func (suite *IPV4NetworkTestSuite) TestRemoveSubnet_Invalid() {
+	network := testCabinetNetwork("")
+	suite.Equal(errors.New(`subnet not found "missing"`), network.RemoveSubnet("missing"))
+
+	network.AddSubnet(net.CIDRMask(24, 32), "twice", 10)
+	network.AddSubnet(net.CIDRMask(24, 32), "twice", 10)
+	suite.Equal(errors.New("found 2 subnets instead of just one"), network.RemoveSubnet("twice"))
+	suite.Len(network.Subnets, 2)
+}
+
+func (suite *IPV4NetworkTestSuite) TestAddReservation_SubnetFull() {
+	_, cidr, _ := net.ParseCIDR("10.254.0.0/30")
+	subnet := IPV4Subnet{Name: "network_hardware", CIDR: *cidr}
+
+	reservation, err := subnet.AddReservation("sw-spine-001", "x3000c0h33s1")
+	suite.NoError(err)
+	suite.Equal("10.254.0.2", reservation.IPAddress.String())
+
+	_, err = subnet.AddReservation("sw-spine-002", "x3000c0h34s1")
+	suite.Equal(errors.New("subnet network_hardware (10.254.0.0/30) is full, unable to reserve an address for sw-spine-002"), err)
+	suite.Len(subnet.IPReservations, 1)
+}
+
+func (suite *IPV4NetworkTestSuite) TestAddReservationWithPin_OutsideSubnet() {
+	_, cidr, _ := net.ParseCIDR("10.92.100.0/28")
+	subnet := IPV4Subnet{Name: "nmn_metallb_address_pool", CIDR: *cidr}
+
+	reservation, err := subnet.AddReservationWithPin("unbound", "", 2)
+	suite.NoError(err)
+	suite.Equal("10.92.100.2", reservation.IPAddress.String())
+
+	_, err = subnet.AddReservationWithPin("istio-ingressgateway", "", 71)
+	suite.Equal(errors.New("cannot pin istio-ingressgateway to 10.92.100.71, it is not a usable address in the nmn_metallb_address_pool subnet 10.92.100.0/28"), err)
+}
+
+func (suite *IPV4NetworkTestSuite) TestAddReservationWithPin_NetworkAndBroadcast() {
+	_, cidr, _ := net.ParseCIDR("10.92.100.0/28")
+	subnet := IPV4Subnet{Name: "nmn_metallb_address_pool", CIDR: *cidr}
+
+	_, err := subnet.AddReservationWithPin("unbound", "", 0)
+	suite.Equal(errors.New("cannot pin unbound to 10.92.100.0, it is not a usable address in the nmn_metallb_address_pool subnet 10.92.100.0/28"), err)
+
+	_, err = subnet.AddReservationWithPin("unbound", "", 15)
+	suite.Equal(errors.New("cannot pin unbound to 10.92.100.15, it is not a usable address in the nmn_metallb_address_pool subnet 10.92.100.0/28"), err)
+	suite.Empty(subnet.IPReservations)
+
+	reservation, err := subnet.AddReservationWithPin("unbound", "", 14)
+	suite.NoError(err)
+	suite.Equal("10.92.100.14", reservation.IPAddress.String())
+}
+
+func (suite *IPV4NetworkTestSuite) TestUpdateNCNReservations_Idempotent() {
+	_, cidr, _ := net.ParseCIDR("10.252.1.0/24")
+	subnet := IPV4Subnet{Name: "bootstrap_dhcp", NetName: "NMN", CIDR: *cidr}
+	subnet.AddReservation("x3000c0s7b0n0", "x3000c0s7b0n0")
+	subnet.AddReservation("x3000c0s9b0n0", "x3000c0s9b0n0")
+
+	ncns := []LogicalNCN{
+		{Xname: "x3000c0s7b0n0", Hostname: "ncn-w001"},
+		{Xname: "x3000c0s9b0n0", Hostname: "ncn-w002"},
+		{Xname: "x3000c0s7b0n0", Hostname: "ncn-w001"},
+	}
+	subnet.UpdateNCNReservations(ncns)
+	subnet.UpdateNCNReservations(ncns)
+
+	suite.Equal("ncn-w001", subnet.IPReservations[0].Name)
+	suite.Equal([]string{"ncn-w001-nmn", "ncn-w001.local"}, subnet.IPReservations[0].Aliases)
+	suite.Equal("ncn-w002", subnet.IPReservations[1].Name)
+	suite.Equal([]string{"ncn-w002-nmn", "ncn-w002.local"}, subnet.IPReservations[1].Aliases)
+}
+
+func (suite *IPV4NetworkTestSuite) TestMarshalSubnet_JSON() {
+	network := testCabinetNetwork("")
+	network.CIDR6 = "fd00:100::/48"
+	subnet, err := network.AddSubnet(net.CIDRMask(24, 32), "bootstrap_dhcp", 2)
+	suite.NoError(err)
+	subnet.AddReservation("ncn-w001", "x3000c0s7b0n0")
+
+	out, err := MarshalSubnet(subnet, "json")
+	suite.NoError(err)
+	suite.Contains(string(out), `"CIDR": "10.100.0.0/24"`)
+	suite.Contains(string(out), `"CIDR6": "fd00:100::/64"`)
+	suite.Contains(string(out), `"IPAddress": "10.100.0.2"`)
+	suite.Contains(string(out), `"Name": "bootstrap_dhcp"`)
+	suite.Contains(string(out), `"VlanID": 2`)
+
+	var decoded IPV4Subnet
+	suite.NoError(json.Unmarshal(out, &decoded))
+	suite.Equal(subnet.CIDR.String(), decoded.CIDR.String())
+	suite.Equal(subnet.CIDR6.String(), decoded.CIDR6.String())
+	suite.Equal("ncn-w001", decoded.IPReservations[0].Name)
+}
+
+func (suite *IPV4NetworkTestSuite) TestMarshalSubnet_Format() {
+	_, cidr, _ := net.ParseCIDR("10.1.0.0/16")
+	subnet := &IPV4Subnet{Name: "test", CIDR: *cidr}
+
+	out, err := MarshalSubnet(subnet, "")
+	suite.NoError(err)
+	suite.Contains(string(out), "name: test")
+
+	_, err = MarshalSubnet(subnet, "xml")
+	suite.Equal(errors.New(`unknown subnet output format "xml", must be yaml or json`), err)
+}
+
+func (suite *IPV4NetworkTestSuite) TestAddBiggestSubnet_SmallestMask() {
+	network := IPV4Network{Name: "HMN", CIDR: "10.254.0.0/28"}
+	_, err := network.AddSubnet(net.CIDRMask(29, 32), "network_hardware", 0)
+	suite.NoError(err)
+	_, err = network.AddSubnet(net.CIDRMask(30, 32), "link_1", 0)
+	suite.NoError(err)
+
+	_, err = network.AddBiggestSubnet(net.CIDRMask(28, 32), "link_2", 0, DefaultSmallestSubnetMask)
+	suite.Equal(errors.New("no room for link_2 subnet within HMN (tried from /28 to /29)"), err)
+
+	subnet, err := network.AddBiggestSubnet(net.CIDRMask(28, 32), "link_2", 0, 30)
+	suite.NoError(err)
+	suite.Equal("10.254.0.12/30", subnet.CIDR.String())
+}
+
+func (suite *IPV4NetworkTestSuite) TestAddBiggestSubnet_InvalidSmallestMask() {
+	network := testCabinetNetwork("")
+	_, err := network.AddBiggestSubnet(net.CIDRMask(24, 32), "p2p", 0, 32)
+	suite.Equal(errors.New("the smallest mask for the p2p subnet must be between /24 and /31, not /32"), err)
+}
+
+func TestIPV4NetworkTestSuite(t *testing.T) {
+	suite.Run(t, new(IPV4NetworkTestSuite))
+}
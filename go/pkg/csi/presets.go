@@ -0,0 +1,120 @@
+//
+//  MIT License
+//
+//  (C) Copyright 2022 Hewlett Packard Enterprise Development LP
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a
+//  copy of this software and associated documentation files (the "Software"),
+//  to deal in the Software without restriction, including without limitation
+//  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//  and/or sell copies of the Software, and to permit persons to whom the
+//  Software is furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included
+//  in all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+//  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+//  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+//  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+//  OTHER DEALINGS IN THE SOFTWARE.
+
+package csi
+
+import (
+	"fmt"
+	"sort"
+
+	base "github.com/Cray-HPE/hms-base"
+	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
+	"github.com/spf13/viper"
+)
+
+// ConfigPreset is a curated set of configuration defaults for a class of system
+type ConfigPreset struct {
+	Description string
+	// Defaults are applied for any key that has not been set explicitly
+	Defaults map[string]interface{}
+	// RequiredKeys must be provided by the user since no sensible default exists
+	RequiredKeys []string
+}
+
+// ConfigPresets are the presets available to `config init --preset`
+var ConfigPresets = map[string]ConfigPreset{
+	"tds-min": {
+		Description: "Single River cabinet test system with three NCNs",
+		Defaults: map[string]interface{}{
+			"system-name":                 "tds",
+			"site-domain":                 "local",
+			"install-ncn":                 "ncn-m001",
+			"install-ncn-bond-members":    "p1p1,p1p2",
+			"bootstrap-ncn-bmc-user":      "root",
+			"river-cabinets":              1,
+			"starting-river-cabinet":      3000,
+			"hill-cabinets":               0,
+			"mountain-cabinets":           0,
+			"starting-mountain-NID":       1000,
+			"nmn-cidr":                    DefaultNMNString,
+			"hmn-cidr":                    DefaultHMNString,
+			"mtl-cidr":                    DefaultMTLString,
+			"hsn-cidr":                    DefaultHSNString,
+			"nmn-bootstrap-vlan":          DefaultNMNVlan,
+			"hmn-bootstrap-vlan":          DefaultHMNVlan,
+			"cmn-bootstrap-vlan":          DefaultCMNVlan,
+			"can-bootstrap-vlan":          DefaultCANVlan,
+			"ntp-pools":                   []string{"time.nist.gov"},
+			"ntp-timezone":                "UTC",
+			"supernet":                    true,
+			"subnet-allocation-direction": SubnetAllocationBottom,
+		},
+		RequiredKeys: []string{"site-ip", "site-gw", "site-dns", "site-nic"},
+	},
+}
+
+// ApplyConfigPreset sets the defaults of the named preset on v and verifies that the keys the preset
+// cannot supply have been provided
+func ApplyConfigPreset(v *viper.Viper, name string) error {
+	preset, ok := ConfigPresets[name]
+	if !ok {
+		var names []string
+		for presetName := range ConfigPresets {
+			names = append(names, presetName)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown preset %q, must be one of %v", name, names)
+	}
+
+	for key, value := range preset.Defaults {
+		v.SetDefault(key, value)
+	}
+
+	var missing []string
+	for _, key := range preset.RequiredKeys {
+		if !v.IsSet(key) {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("preset %q requires %v to be provided", name, missing)
+	}
+	return nil
+}
+
+// BuildCabinetHardware creates the SLS cabinet entries for count cabinets of the given class starting at startingID
+func BuildCabinetHardware(class sls_common.CabinetType, startingID, count int) map[string]sls_common.GenericHardware {
+	cabinets := map[string]sls_common.GenericHardware{}
+	for id := startingID; id < startingID+count; id++ {
+		xname := fmt.Sprintf("x%d", id)
+		cabinets[xname] = sls_common.GenericHardware{
+			Parent:             "s0",
+			Xname:              xname,
+			Type:               sls_common.Cabinet,
+			Class:              class,
+			TypeString:         base.Cabinet,
+			ExtraPropertiesRaw: sls_common.ComptypeCabinet{},
+		}
+	}
+	return cabinets
+}
@@ -0,0 +1,79 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"errors"
+	"testing"
+
+	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+
+	shcd_parser "github.com/Cray-HPE/hms-shcd-parser/pkg/shcd-parser"
+)
+
+type ConfigPresetTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ConfigPresetTestSuite) TestApplyConfigPreset_UnknownPreset() {
+	err := ApplyConfigPreset(viper.New(), "huge")
+	suite.Equal(errors.New(`unknown preset "huge", must be one of [tds-min]`), err)
+}
+
+func (suite *ConfigPresetTestSuite) TestApplyConfigPreset_MissingSiteFlags() {
+	v := viper.New()
+	v.Set("site-ip", "172.30.52.72/20")
+	err := ApplyConfigPreset(v, "tds-min")
+	suite.Equal(errors.New(`preset "tds-min" requires [site-gw site-dns site-nic] to be provided`), err)
+}
+
+func (suite *ConfigPresetTestSuite) TestApplyConfigPreset_TDSMinSLS() {
+	v := viper.New()
+	v.Set("site-ip", "172.30.52.72/20")
+	v.Set("site-gw", "172.30.48.1")
+	v.Set("site-dns", "172.30.84.40")
+	v.Set("site-nic", "em1")
+	v.Set("system-name", "drax")
+	suite.NoError(ApplyConfigPreset(v, "tds-min"))
+
+	// Explicit values win over the preset
+	suite.Equal("drax", v.GetString("system-name"))
+
+	inputState := SLSGeneratorInputState{
+		ManagementSwitches: map[string]sls_common.GenericHardware{
+			"x3000c0w22": buildMgmtSwitch("x3000c0", "x3000c0w22", "sw-leaf-bmc-001", "10.254.0.2", ManagementSwitchBrandAruba),
+		},
+		RiverCabinets:       BuildCabinetHardware(sls_common.ClassRiver, v.GetInt("starting-river-cabinet"), v.GetInt("river-cabinets")),
+		HillCabinets:        BuildCabinetHardware(sls_common.ClassHill, 0, v.GetInt("hill-cabinets")),
+		MountainCabinets:    BuildCabinetHardware(sls_common.ClassMountain, 0, v.GetInt("mountain-cabinets")),
+		MountainStartingNid: v.GetInt("starting-mountain-NID"),
+	}
+	hmnRows := []shcd_parser.HMNRow{
+		{Source: "mn01", SourceRack: "x3000", SourceLocation: "u01", DestinationRack: "x3000", DestinationLocation: "u22", DestinationPort: "p25"},
+		{Source: "wn01", SourceRack: "x3000", SourceLocation: "u07", DestinationRack: "x3000", DestinationLocation: "u22", DestinationPort: "p27"},
+		{Source: "sn01", SourceRack: "x3000", SourceLocation: "u13", DestinationRack: "x3000", DestinationLocation: "u22", DestinationPort: "p30"},
+	}
+
+	slsState := GenerateSLSState(inputState, hmnRows)
+
+	suite.Len(GetSLSCabinets(slsState, sls_common.ClassRiver), 1)
+	suite.Len(GetSLSCabinets(slsState, sls_common.ClassHill), 0)
+	suite.Len(GetSLSCabinets(slsState, sls_common.ClassMountain), 0)
+
+	summaries, err := GetSLSCabinetSummaries(slsState)
+	suite.NoError(err)
+	suite.Equal([]CabinetSummary{
+		{Xname: "x3000", Class: sls_common.ClassRiver, NodeCount: 3, ManagementNodes: 3},
+	}, summaries)
+}
+
+func TestConfigPresetTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfigPresetTestSuite))
+}
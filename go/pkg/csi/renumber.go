@@ -0,0 +1,145 @@
+//
+//  MIT License
+//
+//  (C) Copyright 2022 Hewlett Packard Enterprise Development LP
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a
+//  copy of this software and associated documentation files (the "Software"),
+//  to deal in the Software without restriction, including without limitation
+//  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//  and/or sell copies of the Software, and to permit persons to whom the
+//  Software is furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included
+//  in all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+//  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+//  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+//  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+//  OTHER DEALINGS IN THE SOFTWARE.
+
+package csi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
+	"github.com/mitchellh/mapstructure"
+)
+
+// ParseNCNRenumbering parses a list of old=new hostname pairs into a map of old to new hostnames
+func ParseNCNRenumbering(values []string) (map[string]string, error) {
+	renames := make(map[string]string)
+	for _, value := range values {
+		pair := strings.Split(value, "=")
+		if len(pair) != 2 || strings.TrimSpace(pair[0]) == "" || strings.TrimSpace(pair[1]) == "" {
+			return nil, fmt.Errorf("invalid renumber-ncn %q, must be old=new", value)
+		}
+		oldName, newName := strings.TrimSpace(pair[0]), strings.TrimSpace(pair[1])
+		if _, ok := renames[oldName]; ok {
+			return nil, fmt.Errorf("renumber-ncn %s is given more than once", oldName)
+		}
+		renames[oldName] = newName
+	}
+	return renames, nil
+}
+
+// ValidateNCNRenumbering verifies that every renamed NCN exists and that the hostnames are unique once renamed
+func ValidateNCNRenumbering(renames map[string]string, ncns []LogicalNCN) error {
+	hostnames := make(map[string]bool)
+	for _, ncn := range ncns {
+		hostnames[ncn.Hostname] = true
+	}
+
+	var oldNames []string
+	for oldName := range renames {
+		oldNames = append(oldNames, oldName)
+	}
+	sort.Strings(oldNames)
+	for _, oldName := range oldNames {
+		if !hostnames[oldName] {
+			return fmt.Errorf("renumber-ncn %s does not match any NCN", oldName)
+		}
+	}
+
+	renamed := make(map[string]string)
+	for _, ncn := range ncns {
+		hostname := renameNCN(ncn.Hostname, renames)
+		if previous, ok := renamed[hostname]; ok {
+			return fmt.Errorf("renumber-ncn would give %s and %s the same hostname %s", previous, ncn.Hostname, hostname)
+		}
+		renamed[hostname] = ncn.Hostname
+	}
+	return nil
+}
+
+// renameNCN renames a hostname, or a name derived from it such as ncn-w001-mgmt or ncn-w001.nmn
+func renameNCN(name string, renames map[string]string) string {
+	for oldName, newName := range renames {
+		if name == oldName {
+			return newName
+		}
+		if strings.HasPrefix(name, oldName+"-") || strings.HasPrefix(name, oldName+".") {
+			return newName + strings.TrimPrefix(name, oldName)
+		}
+	}
+	return name
+}
+
+// renameNCNs renames every name in the list
+func renameNCNs(names []string, renames map[string]string) []string {
+	var out []string
+	for _, name := range names {
+		out = append(out, renameNCN(name, renames))
+	}
+	return out
+}
+
+// RenumberNCNs renames NCNs in the NCN list, the network reservations and the SLS node aliases
+func RenumberNCNs(renames map[string]string, ncns []LogicalNCN, networks map[string]*IPV4Network, slsState *sls_common.SLSState) error {
+	if len(renames) == 0 {
+		return nil
+	}
+	if err := ValidateNCNRenumbering(renames, ncns); err != nil {
+		return err
+	}
+
+	for i := range ncns {
+		ncns[i].Hostname = renameNCN(ncns[i].Hostname, renames)
+		ncns[i].Aliases = renameNCNs(ncns[i].Aliases, renames)
+	}
+
+	for _, network := range networks {
+		for _, subnet := range network.Subnets {
+			for i := range subnet.IPReservations {
+				subnet.IPReservations[i].Name = renameNCN(subnet.IPReservations[i].Name, renames)
+				subnet.IPReservations[i].Aliases = renameNCNs(subnet.IPReservations[i].Aliases, renames)
+			}
+		}
+	}
+
+	if slsState == nil {
+		return nil
+	}
+	for xname, hardware := range slsState.Hardware {
+		if hardware.Type != sls_common.Node {
+			continue
+		}
+		var extra sls_common.ComptypeNode
+		if err := mapstructure.Decode(hardware.ExtraPropertiesRaw, &extra); err != nil {
+			return fmt.Errorf("unable to decode the SLS extra properties of %s: %v", xname, err)
+		}
+		if extra.Role != "Management" {
+			continue
+		}
+		extra.Aliases = renameNCNs(extra.Aliases, renames)
+		hardware.ExtraPropertiesRaw = extra
+		slsState.Hardware[xname] = hardware
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
+	"github.com/stretchr/testify/suite"
+)
+
+type RenumberNCNTestSuite struct {
+	suite.Suite
+}
+
+func testRenumberNCNs() []LogicalNCN {
+	return []LogicalNCN{
+		{Xname: "x3000c0s7b0n0", Hostname: "ncn-w002", Aliases: []string{"ncn-w002", "x3000c0s7b0n0"}},
+		{Xname: "x3000c0s11b0n0", Hostname: "ncn-w004", Aliases: []string{"ncn-w004", "x3000c0s11b0n0"}},
+	}
+}
+
+func (suite *RenumberNCNTestSuite) TestParseNCNRenumbering() {
+	renames, err := ParseNCNRenumbering([]string{"ncn-w004=ncn-w003", " ncn-s005 = ncn-s004 "})
+	suite.NoError(err)
+	suite.Equal(map[string]string{"ncn-w004": "ncn-w003", "ncn-s005": "ncn-s004"}, renames)
+}
+
+func (suite *RenumberNCNTestSuite) TestParseNCNRenumbering_Invalid() {
+	_, err := ParseNCNRenumbering([]string{"ncn-w004"})
+	suite.Equal(errors.New(`invalid renumber-ncn "ncn-w004", must be old=new`), err)
+
+	_, err = ParseNCNRenumbering([]string{"ncn-w004=ncn-w003", "ncn-w004=ncn-w005"})
+	suite.Equal(errors.New("renumber-ncn ncn-w004 is given more than once"), err)
+}
+
+func (suite *RenumberNCNTestSuite) TestValidateNCNRenumbering_Invalid() {
+	err := ValidateNCNRenumbering(map[string]string{"ncn-w005": "ncn-w003"}, testRenumberNCNs())
+	suite.Equal(errors.New("renumber-ncn ncn-w005 does not match any NCN"), err)
+
+	err = ValidateNCNRenumbering(map[string]string{"ncn-w004": "ncn-w002"}, testRenumberNCNs())
+	suite.Equal(errors.New("renumber-ncn would give ncn-w002 and ncn-w004 the same hostname ncn-w002"), err)
+}
+
+func (suite *RenumberNCNTestSuite) TestRenumberNCNs() {
+	ncns := testRenumberNCNs()
+
+	_, nmnCIDR, _ := net.ParseCIDR("10.252.1.0/24")
+	nmnBootstrap := &IPV4Subnet{Name: "bootstrap_dhcp", CIDR: *nmnCIDR}
+	nmnBootstrap.AddReservationWithIP("ncn-w002", "10.252.1.8", "x3000c0s7b0n0")
+	nmnBootstrap.AddReservationWithIP("ncn-w004", "10.252.1.10", "x3000c0s11b0n0")
+	nmnBootstrap.IPReservations[1].Aliases = []string{"ncn-w004-nmn", "ncn-w004.local"}
+	_, hmnCIDR, _ := net.ParseCIDR("10.254.1.0/24")
+	hmnBootstrap := &IPV4Subnet{Name: "bootstrap_dhcp", CIDR: *hmnCIDR}
+	hmnBootstrap.AddReservationWithIP("ncn-w004-mgmt", "10.254.1.10", "x3000c0s11b0")
+	networks := map[string]*IPV4Network{
+		"NMN": {Name: "NMN", Subnets: []*IPV4Subnet{nmnBootstrap}},
+		"HMN": {Name: "HMN", Subnets: []*IPV4Subnet{hmnBootstrap}},
+	}
+
+	slsState := sls_common.SLSState{
+		Hardware: map[string]sls_common.GenericHardware{
+			"x3000c0s11b0n0": {
+				Xname: "x3000c0s11b0n0",
+				Type:  sls_common.Node,
+				ExtraPropertiesRaw: map[string]interface{}{
+					"Role":    "Management",
+					"SubRole": "Worker",
+					"NID":     100004,
+					"Aliases": []interface{}{"ncn-w004"},
+				},
+			},
+		},
+	}
+
+	suite.NoError(RenumberNCNs(map[string]string{"ncn-w004": "ncn-w003"}, ncns, networks, &slsState))
+
+	suite.Equal("ncn-w002", ncns[0].Hostname)
+	suite.Equal("ncn-w003", ncns[1].Hostname)
+	suite.Equal([]string{"ncn-w003", "x3000c0s11b0n0"}, ncns[1].Aliases)
+
+	reservation := nmnBootstrap.LookupReservation("ncn-w003")
+	suite.Equal("10.252.1.10", reservation.IPAddress.String())
+	suite.Equal([]string{"ncn-w003-nmn", "ncn-w003.local"}, reservation.Aliases)
+	suite.Equal("10.254.1.10", hmnBootstrap.LookupReservation("ncn-w003-mgmt").IPAddress.String())
+	suite.Equal("10.252.1.8", nmnBootstrap.LookupReservation("ncn-w002").IPAddress.String())
+
+	suite.Equal(sls_common.ComptypeNode{
+		NID:     100004,
+		Role:    "Management",
+		SubRole: "Worker",
+		Aliases: []string{"ncn-w003"},
+	}, slsState.Hardware["x3000c0s11b0n0"].ExtraPropertiesRaw)
+}
+
+func TestRenumberNCNTestSuite(t *testing.T) {
+	suite.Run(t, new(RenumberNCNTestSuite))
+}
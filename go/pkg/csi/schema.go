@@ -0,0 +1,187 @@
+//
+//  MIT License
+//
+//  (C) Copyright 2022 Hewlett Packard Enterprise Development LP
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a
+//  copy of this software and associated documentation files (the "Software"),
+//  to deal in the Software without restriction, including without limitation
+//  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//  and/or sell copies of the Software, and to permit persons to whom the
+//  Software is furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included
+//  in all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+//  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+//  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+//  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+//  OTHER DEALINGS IN THE SOFTWARE.
+
+package csi
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+	"github.com/spf13/viper"
+)
+
+// JSONSchemaDraft is the JSON Schema dialect of the generated system_config.yaml schema
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchema is the subset of JSON Schema needed to describe system_config.yaml
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	PatternProperties    map[string]*JSONSchema `json:"patternProperties,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+}
+
+// systemConfigPatternKeys are the keys that are derived from network names rather than defined as flags
+var systemConfigPatternKeys = map[string]*JSONSchema{
+	"^[a-z0-9_]+-dhcp-lease$": {Type: "string"},
+}
+
+// systemConfigKeyOverrides describe the keys whose flag default does not capture every accepted type
+var systemConfigKeyOverrides = map[string]*JSONSchema{
+	// supernet is either true/false or a map of network names to true/false
+	"supernet": {},
+}
+
+// SystemConfigSchema derives a JSON Schema for system_config.yaml from the keys known to v.
+// The command flags must already be bound to v so that every key and its default are known.
+func SystemConfigSchema(v *viper.Viper) *JSONSchema {
+	additionalProperties := false
+	schema := &JSONSchema{
+		Schema:               JSONSchemaDraft,
+		Title:                "system_config.yaml",
+		Type:                 "object",
+		Properties:           map[string]*JSONSchema{},
+		PatternProperties:    systemConfigPatternKeys,
+		AdditionalProperties: &additionalProperties,
+	}
+	for _, key := range v.AllKeys() {
+		if override, ok := systemConfigKeyOverrides[key]; ok {
+			schema.Properties[key] = override
+			continue
+		}
+		schema.Properties[key] = jsonSchemaFor(v.Get(key))
+	}
+	return schema
+}
+
+// jsonSchemaFor describes the type of a flag default, unknown types accept any value
+func jsonSchemaFor(value interface{}) *JSONSchema {
+	switch value.(type) {
+	case string:
+		return &JSONSchema{Type: "string"}
+	case bool:
+		return &JSONSchema{Type: "boolean"}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return &JSONSchema{Type: "integer"}
+	case float32, float64:
+		return &JSONSchema{Type: "number"}
+	case []string:
+		return &JSONSchema{Type: "array", Items: &JSONSchema{Type: "string"}}
+	case []int:
+		return &JSONSchema{Type: "array", Items: &JSONSchema{Type: "integer"}}
+	case []interface{}:
+		return &JSONSchema{Type: "array"}
+	case map[string]interface{}, map[interface{}]interface{}:
+		return &JSONSchema{Type: "object"}
+	}
+	return &JSONSchema{}
+}
+
+// ValidateSystemConfig checks the keys and value types of a decoded system_config.yaml against the schema
+func ValidateSystemConfig(schema *JSONSchema, config map[string]interface{}) []error {
+	var keys []string
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		property, ok := schema.lookup(key)
+		if !ok {
+			if suggestion, found := schema.suggest(key); found {
+				errs = append(errs, fmt.Errorf("unknown key %q, did you mean %q?", key, suggestion))
+			} else {
+				errs = append(errs, fmt.Errorf("unknown key %q", key))
+			}
+			continue
+		}
+		if err := property.validate(config[key]); err != nil {
+			errs = append(errs, fmt.Errorf("key %q %v", key, err))
+		}
+	}
+	return errs
+}
+
+// ValidateSystemConfigFile reads a system_config.yaml and validates it against the schema
+func ValidateSystemConfigFile(schema *JSONSchema, path string) ([]error, error) {
+	config := make(map[string]interface{})
+	if err := csiFiles.ReadYAMLConfig(path, &config); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %v", path, err)
+	}
+	return ValidateSystemConfig(schema, config), nil
+}
+
+// lookup finds the schema of a key, viper keys are case insensitive
+func (schema *JSONSchema) lookup(key string) (*JSONSchema, bool) {
+	key = strings.ToLower(key)
+	if property, ok := schema.Properties[key]; ok {
+		return property, true
+	}
+	for pattern, property := range schema.PatternProperties {
+		if matched, _ := regexp.MatchString(pattern, key); matched {
+			return property, true
+		}
+	}
+	if schema.AdditionalProperties == nil || *schema.AdditionalProperties {
+		return &JSONSchema{}, true
+	}
+	return nil, false
+}
+
+// suggest finds the known key that an unknown key was most likely meant to be
+func (schema *JSONSchema) suggest(key string) (string, bool) {
+	normalized := strings.NewReplacer("_", "-", " ", "-").Replace(strings.ToLower(key))
+	if _, ok := schema.Properties[normalized]; ok {
+		return normalized, true
+	}
+	return "", false
+}
+
+func (schema *JSONSchema) validate(value interface{}) error {
+	if schema.Type == "" || value == nil {
+		return nil
+	}
+	actual := jsonSchemaFor(value).Type
+	if actual == "" {
+		actual = fmt.Sprintf("%T", value)
+	}
+	// YAML has no way to tell an integer from a whole number
+	if actual != schema.Type && !(schema.Type == "number" && actual == "integer") {
+		return fmt.Errorf("must be of type %s, not %s", schema.Type, actual)
+	}
+	if items, ok := value.([]interface{}); ok && schema.Items != nil {
+		for i, item := range items {
+			if err := schema.Items.validate(item); err != nil {
+				return fmt.Errorf("item %d %v", i, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+)
+
+type SystemConfigSchemaTestSuite struct {
+	suite.Suite
+}
+
+func testSystemConfigViper() *viper.Viper {
+	v := viper.New()
+	v.SetDefault("system-name", "sn-2024")
+	v.SetDefault("nmn-cidr", DefaultNMNString)
+	v.SetDefault("river-cabinets", 1)
+	v.SetDefault("ntp-pools", []string{"time.nist.gov"})
+	v.SetDefault("supernet", true)
+	v.SetDefault("starting-mountain-NID", 1000)
+	return v
+}
+
+func (suite *SystemConfigSchemaTestSuite) TestSystemConfigSchema() {
+	bs, err := json.Marshal(SystemConfigSchema(testSystemConfigViper()))
+	suite.NoError(err)
+	suite.JSONEq(`{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"title": "system_config.yaml",
+		"type": "object",
+		"properties": {
+			"system-name": {"type": "string"},
+			"nmn-cidr": {"type": "string"},
+			"river-cabinets": {"type": "integer"},
+			"ntp-pools": {"type": "array", "items": {"type": "string"}},
+			"supernet": {},
+			"starting-mountain-nid": {"type": "integer"}
+		},
+		"patternProperties": {"^[a-z0-9_]+-dhcp-lease$": {"type": "string"}},
+		"additionalProperties": false
+	}`, string(bs))
+}
+
+func (suite *SystemConfigSchemaTestSuite) TestValidateSystemConfigFile() {
+	dir, err := ioutil.TempDir("", "schema")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "system_config.yaml")
+	suite.NoError(ioutil.WriteFile(path, []byte(`
+system-name: eniac
+nmn-cidr: 10.252.0.0/17
+river-cabinets: 2
+ntp-pools:
+  - time.nist.gov
+supernet:
+  NMN: true
+  HMN: false
+starting-mountain-NID: 1000
+nmn-dhcp-lease: 1h
+`), 0644))
+
+	errs, err := ValidateSystemConfigFile(SystemConfigSchema(testSystemConfigViper()), path)
+	suite.NoError(err)
+	suite.Empty(errs)
+}
+
+func (suite *SystemConfigSchemaTestSuite) TestValidateSystemConfig_Invalid() {
+	config := map[string]interface{}{
+		"nmn_cidr":       "10.252.0.0/17",
+		"hmn-cidrs":      "10.254.0.0/17",
+		"river-cabinets": "two",
+		"ntp-pools":      []interface{}{"time.nist.gov", 4},
+	}
+	suite.Equal([]error{
+		errors.New(`unknown key "hmn-cidrs"`),
+		errors.New(`unknown key "nmn_cidr", did you mean "nmn-cidr"?`),
+		errors.New(`key "ntp-pools" item 1 must be of type string, not integer`),
+		errors.New(`key "river-cabinets" must be of type integer, not string`),
+	}, ValidateSystemConfig(SystemConfigSchema(testSystemConfigViper()), config))
+}
+
+func TestSystemConfigSchemaTestSuite(t *testing.T) {
+	suite.Run(t, new(SystemConfigSchemaTestSuite))
+}
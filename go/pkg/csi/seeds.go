@@ -0,0 +1,165 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+	base "github.com/Cray-HPE/hms-base"
+	shcd_parser "github.com/Cray-HPE/hms-shcd-parser/pkg/shcd-parser"
+)
+
+// ReadHMNConnections parses an hmn_connections.json file
+func ReadHMNConnections(path string) ([]shcd_parser.HMNRow, error) {
+	var hmnRows []shcd_parser.HMNRow
+	if err := csiFiles.ReadJSONConfig(path, &hmnRows); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %v", path, err)
+	}
+	return hmnRows, nil
+}
+
+// hmnRowLocation returns the cabinet xname and U number of the source of an hmn_connections row
+func hmnRowLocation(row shcd_parser.HMNRow) (string, int, bool) {
+	uSubmatches := uRegex.FindStringSubmatch(row.SourceLocation)
+	if len(uSubmatches) < 2 {
+		return "", 0, false
+	}
+	u, err := strconv.Atoi(uSubmatches[1])
+	if err != nil {
+		return "", 0, false
+	}
+	return base.NormalizeHMSCompID(row.SourceRack), u, true
+}
+
+// ValidateSeeds cross-checks the ncn_metadata, switch_metadata and hmn_connections seed files.  Every NCN and
+// switch xname must be a legal xname of the right type, every NCN must be the source of an hmn_connections row
+// in its cabinet and slot, and every switch an hmn_connections row is cabled to must be in switch_metadata.
+// There is one error per problem.
+func ValidateSeeds(ncns []*LogicalNCN, switches []*ManagementSwitch, hmnRows []shcd_parser.HMNRow) []error {
+	var errs []error
+
+	switchXnames := map[string]bool{}
+	for _, mySwitch := range switches {
+		xname := base.NormalizeHMSCompID(mySwitch.Xname)
+		switch base.GetHMSType(xname) {
+		case base.MgmtSwitch, base.MgmtHLSwitch, base.CDUMgmtSwitch:
+			switchXnames[xname] = true
+		default:
+			errs = append(errs, fmt.Errorf("switch_metadata: %q is not a valid switch xname", mySwitch.Xname))
+		}
+	}
+
+	sources := map[string]bool{}
+	for i, row := range hmnRows {
+		cabinet, u, ok := hmnRowLocation(row)
+		if !ok {
+			errs = append(errs, fmt.Errorf("hmn_connections row %d (%s): unable to find the U number in the source location %q", i+1, row.Source, row.SourceLocation))
+			continue
+		}
+		if base.GetHMSType(cabinet) != base.Cabinet {
+			errs = append(errs, fmt.Errorf("hmn_connections row %d (%s): source rack %q is not a valid cabinet xname", i+1, row.Source, row.SourceRack))
+			continue
+		}
+		sources[fmt.Sprintf("%sc0s%d", cabinet, u)] = true
+
+		if row.DestinationRack == "" {
+			continue
+		}
+		switchXname := base.NormalizeHMSCompID(fmt.Sprintf("%sc0w%s", row.DestinationRack, strings.TrimPrefix(row.DestinationLocation, "u")))
+		if !switchXnames[switchXname] {
+			errs = append(errs, fmt.Errorf("hmn_connections row %d (%s): destination switch %s (%s %s) is not in switch_metadata",
+				i+1, row.Source, switchXname, row.DestinationRack, row.DestinationLocation))
+		}
+	}
+
+	for _, ncn := range ncns {
+		xname := base.NormalizeHMSCompID(ncn.Xname)
+		if base.GetHMSType(xname) != base.Node {
+			errs = append(errs, fmt.Errorf("ncn_metadata: %q is not a valid node xname", ncn.Xname))
+			continue
+		}
+		slot := xname[:strings.Index(xname, "b")]
+		if !sources[slot] {
+			errs = append(errs, fmt.Errorf("ncn_metadata: %s has no source in hmn_connections at %s", ncn.Xname, slot))
+		}
+	}
+	return errs
+}
+
+// ValidateSeedFiles loads the three seed files and validates them with ValidateSeeds.  The error is set when
+// a file cannot be read at all.
+func ValidateSeedFiles(ncnMetadata, switchMetadata, hmnConnections string) ([]error, error) {
+	ncns, err := ReadNodeCSV(ncnMetadata)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %v", ncnMetadata, err)
+	}
+	switches, err := ReadSwitchCSV(switchMetadata)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %v", switchMetadata, err)
+	}
+	hmnRows, err := ReadHMNConnections(hmnConnections)
+	if err != nil {
+		return nil, err
+	}
+	return ValidateSeeds(ncns, switches, hmnRows), nil
+}
+
+// hmnSwitchSourcePrefixes infer the type of a management switch from its name in the Source column of
+// hmn_connections, longest prefix first.  sw-smn, sw-25g and sw-40g are the 1.3 names.
+var hmnSwitchSourcePrefixes = []struct {
+	prefix     string
+	switchType ManagementSwitchType
+}{
+	{"sw-leaf-bmc", ManagementSwitchTypeLeafBMC},
+	{"sw-leafbmc", ManagementSwitchTypeLeafBMC},
+	{"sw-smn", ManagementSwitchTypeLeafBMC},
+	{"sw-spine", ManagementSwitchTypeSpine},
+	{"sw-40g", ManagementSwitchTypeSpine},
+	{"sw-leaf", ManagementSwitchTypeLeaf},
+	{"sw-25g", ManagementSwitchTypeLeaf},
+	{"sw-cdu", ManagementSwitchTypeCDU},
+	{"sw-edge", ManagementSwitchTypeEdge},
+}
+
+// SwitchTypeCablingWarnings compares the type of each switch in switch_metadata with the role its hmn_connections
+// cabling implies.  A switch listed as a source, e.g. sw-spine-001, must have the type its name implies, and a
+// switch that BMCs are cabled to must not be a Spine or Edge switch.
+func SwitchTypeCablingWarnings(switches []*ManagementSwitch, hmnRows []shcd_parser.HMNRow) []string {
+	switchTypes := map[string]ManagementSwitchType{}
+	for _, mySwitch := range switches {
+		switchTypes[base.NormalizeHMSCompID(mySwitch.Xname)] = mySwitch.SwitchType
+	}
+
+	var warnings []string
+	for _, row := range hmnRows {
+		if cabinet, u, ok := hmnRowLocation(row); ok {
+			source := strings.ToLower(strings.TrimSpace(row.Source))
+			for _, inferred := range hmnSwitchSourcePrefixes {
+				if !strings.HasPrefix(source, inferred.prefix) {
+					continue
+				}
+				xname := fmt.Sprintf("%sc0w%d", cabinet, u)
+				if declared, ok := switchTypes[xname]; ok && declared != inferred.switchType {
+					warnings = append(warnings, fmt.Sprintf("switch %s is a %s in switch_metadata but hmn_connections names it %s, a %s",
+						xname, declared, row.Source, inferred.switchType))
+				}
+				break
+			}
+		}
+
+		if row.DestinationRack == "" {
+			continue
+		}
+		xname := base.NormalizeHMSCompID(fmt.Sprintf("%sc0w%s", row.DestinationRack, strings.TrimPrefix(row.DestinationLocation, "u")))
+		if declared := switchTypes[xname]; declared == ManagementSwitchTypeSpine || declared == ManagementSwitchTypeEdge {
+			warnings = append(warnings, fmt.Sprintf("switch %s is a %s in switch_metadata but %s is cabled to it in hmn_connections, which only LeafBMC, Leaf and CDU switches take",
+				xname, declared, row.Source))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,103 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	shcd_parser "github.com/Cray-HPE/hms-shcd-parser/pkg/shcd-parser"
+	"github.com/stretchr/testify/suite"
+)
+
+type SeedsTestSuite struct {
+	suite.Suite
+}
+
+func testSeedSwitches() []*ManagementSwitch {
+	return []*ManagementSwitch{
+		{Xname: "x3000c0w22", SwitchType: ManagementSwitchTypeLeafBMC},
+		{Xname: "x3000c0h33s1", SwitchType: ManagementSwitchTypeSpine},
+	}
+}
+
+func testSeedHMNRows() []shcd_parser.HMNRow {
+	return []shcd_parser.HMNRow{
+		{Source: "mn01", SourceRack: "x3000", SourceLocation: "u01", DestinationRack: "x3000", DestinationLocation: "u22", DestinationPort: "j37"},
+		{Source: "wn01", SourceRack: "x3000", SourceLocation: "u04", DestinationRack: "x3000", DestinationLocation: "u22", DestinationPort: "j38"},
+	}
+}
+
+func (suite *SeedsTestSuite) TestValidateSeeds() {
+	ncns := []*LogicalNCN{{Xname: "x3000c0s1b0n0"}, {Xname: "x3000c0s4b0n0"}}
+	suite.Empty(ValidateSeeds(ncns, testSeedSwitches(), testSeedHMNRows()))
+}
+
+func (suite *SeedsTestSuite) TestValidateSeeds_Mismatches() {
+	ncns := []*LogicalNCN{{Xname: "x3000c0s1b0n0"}, {Xname: "x3000c0s7b0n0"}, {Xname: "x3000c0s9b0"}}
+	switches := append(testSeedSwitches(), &ManagementSwitch{Xname: "x3000c0s9"})
+	hmnRows := append(testSeedHMNRows(),
+		shcd_parser.HMNRow{Source: "sn01", SourceRack: "x3000", SourceLocation: "u10", DestinationRack: "x3000", DestinationLocation: "u14", DestinationPort: "j1"},
+		shcd_parser.HMNRow{Source: "cn01", SourceRack: "rack1", SourceLocation: "u12"},
+	)
+
+	suite.Equal([]error{
+		errors.New(`switch_metadata: "x3000c0s9" is not a valid switch xname`),
+		errors.New("hmn_connections row 3 (sn01): destination switch x3000c0w14 (x3000 u14) is not in switch_metadata"),
+		errors.New(`hmn_connections row 4 (cn01): source rack "rack1" is not a valid cabinet xname`),
+		errors.New("ncn_metadata: x3000c0s7b0n0 has no source in hmn_connections at x3000c0s7"),
+		errors.New(`ncn_metadata: "x3000c0s9b0" is not a valid node xname`),
+	}, ValidateSeeds(ncns, switches, hmnRows))
+}
+
+func (suite *SeedsTestSuite) TestValidateSeedFiles() {
+	dir, err := ioutil.TempDir("", "seeds")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+
+	ncnMetadata := filepath.Join(dir, "ncn_metadata.csv")
+	suite.NoError(ioutil.WriteFile(ncnMetadata, []byte(`Xname,Role,Subrole,BMC MAC,Bootstrap MAC,Bond0 MAC0,Bond0 MAC1
+x3000c0s1b0n0,Management,Master,94:40:c9:37:77:26,14:02:ec:d9:79:e8,14:02:ec:d9:79:e8,14:02:ec:d9:79:e9
+x3000c0s7b0n0,Management,Worker,94:40:c9:37:77:30,14:02:ec:d9:7a:38,14:02:ec:d9:7a:38,14:02:ec:d9:7a:39
+`), 0644))
+	switchMetadata := filepath.Join(dir, "switch_metadata.csv")
+	suite.NoError(ioutil.WriteFile(switchMetadata, []byte("Switch Xname,Type,Brand\nx3000c0w22,LeafBMC,Aruba\n"), 0644))
+	hmnConnections := filepath.Join(dir, "hmn_connections.json")
+	suite.NoError(ioutil.WriteFile(hmnConnections, []byte(`[
+  {"Source": "mn01", "SourceRack": "x3000", "SourceLocation": "u01", "DestinationRack": "x3000", "DestinationLocation": "u22", "DestinationPort": "j37"}
+]`), 0644))
+
+	errs, err := ValidateSeedFiles(ncnMetadata, switchMetadata, hmnConnections)
+	suite.NoError(err)
+	suite.Equal([]error{errors.New("ncn_metadata: x3000c0s7b0n0 has no source in hmn_connections at x3000c0s7")}, errs)
+
+	_, err = ValidateSeedFiles(ncnMetadata, switchMetadata, filepath.Join(dir, "missing.json"))
+	suite.Error(err)
+}
+
+func (suite *SeedsTestSuite) TestSwitchTypeCablingWarnings() {
+	switches := append(testSeedSwitches(), &ManagementSwitch{Xname: "x3000c0w38", SwitchType: ManagementSwitchTypeLeaf})
+	hmnRows := append(testSeedHMNRows(),
+		shcd_parser.HMNRow{Source: "sw-spine-001", SourceRack: "x3000", SourceLocation: "u38", DestinationRack: "x3000", DestinationLocation: "u22", DestinationPort: "j48"},
+		shcd_parser.HMNRow{Source: "sn01", SourceRack: "x3000", SourceLocation: "u10", DestinationRack: "x3000", DestinationLocation: "u38", DestinationPort: "j1"},
+	)
+	suite.Empty(SwitchTypeCablingWarnings(testSeedSwitches(), testSeedHMNRows()))
+	suite.Equal([]string{"switch x3000c0w38 is a Leaf in switch_metadata but hmn_connections names it sw-spine-001, a Spine"},
+		SwitchTypeCablingWarnings(switches, hmnRows))
+
+	switches[2].SwitchType = ManagementSwitchTypeSpine
+	suite.Equal([]string{"switch x3000c0w38 is a Spine in switch_metadata but sn01 is cabled to it in hmn_connections, which only LeafBMC, Leaf and CDU switches take"},
+		SwitchTypeCablingWarnings(switches, hmnRows))
+}
+
+func TestSeedsTestSuite(t *testing.T) {
+	suite.Run(t, new(SeedsTestSuite))
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+	"github.com/spf13/viper"
+)
+
+// Shasta13ConfigFiles are the Shasta 1.3 configuration files read by LoadShasta13Config
+var Shasta13ConfigFiles = []string{"system_config.yml", "networks_derived.yml"}
+
+// Shasta13ConfigKeys maps the settings of the Shasta 1.3 configuration files, as dotted paths within
+// each file, onto the config init keys they seed
+var Shasta13ConfigKeys = map[string]map[string]string{
+	"system_config.yml": {
+		"system_name":               "system-name",
+		"site_domain":               "site-domain",
+		"site_ip":                   "site-ip",
+		"site_gw":                   "site-gw",
+		"site_dns":                  "site-dns",
+		"site_nic":                  "site-nic",
+		"install_ncn":               "install-ncn",
+		"install_ncn_bond_members":  "install-ncn-bond-members",
+		"bgp_asn":                   "bgp-asn",
+		"ntp.pools":                 "ntp-pools",
+		"ntp.servers":               "ntp-servers",
+		"ntp.peers":                 "ntp-peers",
+		"ntp.timezone":              "ntp-timezone",
+		"river_cabinets":            "river-cabinets",
+		"starting_river_cabinet":    "starting-river-cabinet",
+		"mountain_cabinets":         "mountain-cabinets",
+		"starting_mountain_cabinet": "starting-mountain-cabinet",
+		"hill_cabinets":             "hill-cabinets",
+		"starting_hill_cabinet":     "starting-hill-cabinet",
+	},
+	"networks_derived.yml": {
+		"networks.nmn.network": "nmn-cidr",
+		"networks.nmn.vlan":    "nmn-bootstrap-vlan",
+		"networks.hmn.network": "hmn-cidr",
+		"networks.hmn.vlan":    "hmn-bootstrap-vlan",
+		"networks.can.network": "can-cidr",
+		"networks.can.vlan":    "can-bootstrap-vlan",
+		"networks.can.gateway": "can-gateway",
+		"networks.can.static":  "can-static-pool",
+		"networks.can.dynamic": "can-dynamic-pool",
+		"networks.mtl.network": "mtl-cidr",
+		"networks.hsn.network": "hsn-cidr",
+	},
+}
+
+// flattenShasta13Config turns nested YAML maps into dotted paths so they can be looked up in Shasta13ConfigKeys
+func flattenShasta13Config(prefix string, value interface{}, flat map[string]interface{}) {
+	nested, ok := value.(map[interface{}]interface{})
+	if !ok {
+		flat[prefix] = value
+		return
+	}
+	for key, child := range nested {
+		path := fmt.Sprint(key)
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		flattenShasta13Config(path, child, flat)
+	}
+}
+
+// LoadShasta13Config seeds config init from the Shasta 1.3 configuration files in dir.  Each mapped
+// setting becomes a default on v, so anything set explicitly still wins.  The settings that have no
+// config init equivalent are returned as <file>:<path> so they can be reported.
+func LoadShasta13Config(v *viper.Viper, dir string) ([]string, error) {
+	var unmapped []string
+	for _, name := range Shasta13ConfigFiles {
+		var config map[interface{}]interface{}
+		if err := csiFiles.ReadYAMLConfig(filepath.Join(dir, name), &config); err != nil {
+			return nil, fmt.Errorf("unable to read the Shasta 1.3 %s: %v", name, err)
+		}
+		flat := map[string]interface{}{}
+		flattenShasta13Config("", config, flat)
+
+		keys := Shasta13ConfigKeys[name]
+		for path, value := range flat {
+			key, ok := keys[path]
+			if !ok {
+				unmapped = append(unmapped, name+":"+path)
+				continue
+			}
+			if list, ok := value.([]interface{}); ok {
+				var values []string
+				for _, item := range list {
+					values = append(values, fmt.Sprint(item))
+				}
+				v.SetDefault(key, values)
+				continue
+			}
+			v.SetDefault(key, value)
+		}
+	}
+	sort.Strings(unmapped)
+	return unmapped, nil
+}
+
+// Shasta13UnmappedWarning describes the Shasta 1.3 settings LoadShasta13Config could not carry over
+func Shasta13UnmappedWarning(unmapped []string) string {
+	if len(unmapped) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d Shasta 1.3 settings have no config init equivalent and were ignored: %s",
+		len(unmapped), strings.Join(unmapped, ", "))
+}
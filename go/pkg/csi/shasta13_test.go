@@ -0,0 +1,81 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+)
+
+type Shasta13TestSuite struct {
+	suite.Suite
+}
+
+func (suite *Shasta13TestSuite) TestLoadShasta13Config() {
+	dir, err := ioutil.TempDir("", "shasta13")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+	suite.NoError(ioutil.WriteFile(filepath.Join(dir, "system_config.yml"), []byte(`
+system_name: sif
+site_domain: dev.cray.com
+site_nic: em1
+river_cabinets: 1
+ntp:
+  pools: [time.nist.gov]
+  timezone: UTC
+sms_ip: 10.1.1.1
+`), 0644))
+	suite.NoError(ioutil.WriteFile(filepath.Join(dir, "networks_derived.yml"), []byte(`
+networks:
+  nmn:
+    network: 10.252.0.0/17
+    vlan: 2
+  can:
+    network: 10.102.9.0/24
+    gateway: 10.102.9.20
+    bgp_peers: [10.102.9.2]
+`), 0644))
+
+	v := viper.New()
+	v.Set("site-domain", "explicit.example.com")
+	unmapped, err := LoadShasta13Config(v, dir)
+	suite.NoError(err)
+	suite.Equal([]string{"networks_derived.yml:networks.can.bgp_peers", "system_config.yml:sms_ip"}, unmapped)
+
+	suite.Equal("sif", v.GetString("system-name"))
+	suite.Equal("explicit.example.com", v.GetString("site-domain"))
+	suite.Equal("em1", v.GetString("site-nic"))
+	suite.Equal(1, v.GetInt("river-cabinets"))
+	suite.Equal([]string{"time.nist.gov"}, v.GetStringSlice("ntp-pools"))
+	suite.Equal("UTC", v.GetString("ntp-timezone"))
+	suite.Equal("10.252.0.0/17", v.GetString("nmn-cidr"))
+	suite.Equal(2, v.GetInt("nmn-bootstrap-vlan"))
+	suite.Equal("10.102.9.0/24", v.GetString("can-cidr"))
+	suite.Equal("10.102.9.20", v.GetString("can-gateway"))
+	suite.Equal("2 Shasta 1.3 settings have no config init equivalent and were ignored: networks_derived.yml:networks.can.bgp_peers, system_config.yml:sms_ip",
+		Shasta13UnmappedWarning(unmapped))
+}
+
+func (suite *Shasta13TestSuite) TestLoadShasta13Config_Missing() {
+	dir, err := ioutil.TempDir("", "shasta13")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+
+	_, err = LoadShasta13Config(viper.New(), dir)
+	suite.Error(err)
+	suite.Contains(err.Error(), "unable to read the Shasta 1.3 system_config.yml")
+}
+
+func TestShasta13TestSuite(t *testing.T) {
+	suite.Run(t, new(Shasta13TestSuite))
+}
@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
 	base "github.com/Cray-HPE/hms-base"
 	shcd_parser "github.com/Cray-HPE/hms-shcd-parser/pkg/shcd-parser"
 	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
@@ -132,6 +133,28 @@ func (applicationNodeConfig *SLSGeneratorApplicationNodeConfig) Normalize() erro
 	return nil
 }
 
+// ValidateAgainstSLS verifies that every xname with aliases in the SLSGeneratorApplicationNodeConfig exists in the given SLS state
+func (applicationNodeConfig *SLSGeneratorApplicationNodeConfig) ValidateAgainstSLS(slsState sls_common.SLSState) error {
+	var orphans []string
+	for xname := range applicationNodeConfig.Aliases {
+		if _, present := slsState.Hardware[base.NormalizeHMSCompID(xname)]; !present {
+			orphans = append(orphans, xname)
+		}
+	}
+	if len(orphans) > 0 {
+		sort.Strings(orphans)
+		return fmt.Errorf("application node aliases reference xnames not present in SLS: %v", orphans)
+	}
+	return nil
+}
+
+// LoadApplicationNodeConfig loads an application_node_config.yaml file from the filesystem
+func LoadApplicationNodeConfig(path string) (SLSGeneratorApplicationNodeConfig, error) {
+	var applicationNodeConfig SLSGeneratorApplicationNodeConfig
+	err := csiFiles.ReadYAMLConfig(path, &applicationNodeConfig)
+	return applicationNodeConfig, err
+}
+
 // SLSStateGenerator is a utility that can take an SLSGeneratorInputState to create a valid SLSState
 type SLSStateGenerator struct {
 	logger     *zap.Logger
@@ -902,3 +925,57 @@ func GenerateSLSState(inputState SLSGeneratorInputState, hmnRows []shcd_parser.H
 	g := NewSLSStateGenerator(logger, inputState, hmnRows)
 	return g.GenerateSLSState()
 }
+
+// CountRiverComputeNodes returns the number of river compute nodes (nid or cn sources) in the hmn_connections rows
+func CountRiverComputeNodes(hmnRows []shcd_parser.HMNRow) int {
+	count := 0
+	for _, row := range hmnRows {
+		sourceLowerCase := strings.ToLower(row.Source)
+		if strings.HasPrefix(sourceLowerCase, "nid") || strings.HasPrefix(sourceLowerCase, "cn") {
+			count++
+		}
+	}
+	return count
+}
+
+// ValidateHMNConnectionPorts verifies that no switch port in the hmn_connections rows is cabled to more than one source
+func ValidateHMNConnectionPorts(hmnRows []shcd_parser.HMNRow) error {
+	sourcesByPort := make(map[string][]string)
+	var ports []string
+	for _, row := range hmnRows {
+		if row.DestinationRack == "" || row.DestinationPort == "" {
+			continue
+		}
+
+		port := fmt.Sprintf("%s %s %s", row.DestinationRack, row.DestinationLocation, row.DestinationPort)
+		if _, ok := sourcesByPort[port]; !ok {
+			ports = append(ports, port)
+		}
+		source := fmt.Sprintf("%s (%s %s)", row.Source, row.SourceRack, row.SourceLocation)
+		sourcesByPort[port] = append(sourcesByPort[port], source)
+	}
+
+	var conflicts []string
+	for _, port := range ports {
+		if sources := sourcesByPort[port]; len(sources) > 1 {
+			conflicts = append(conflicts, fmt.Sprintf("%s is used by %s", port, strings.Join(sources, ", ")))
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("hmn_connections has switch ports cabled to more than one source: %s", strings.Join(conflicts, "; "))
+	}
+	return nil
+}
+
+// ValidateNIDRanges verifies that the river NIDs, growing from startingRiverNID, do not reach the mountain NID base
+func ValidateNIDRanges(startingRiverNID, riverNodeCount, startingMountainNID int) error {
+	if riverNodeCount == 0 || startingMountainNID < startingRiverNID {
+		return nil
+	}
+	riverNIDCeiling := startingRiverNID + riverNodeCount - 1
+	if riverNIDCeiling >= startingMountainNID {
+		return fmt.Errorf("river NIDs %d-%d for %d river nodes collide with the mountain NIDs starting at %d",
+			startingRiverNID, riverNIDCeiling, riverNodeCount, startingMountainNID)
+	}
+	return nil
+}
@@ -6,6 +6,7 @@
 package csi
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"testing"
@@ -1820,6 +1821,58 @@ func (suite *ConfigGeneratorTestSuite) TestApplicationNodeConfigValidate_Duplica
 	// suite.EqualError(err, "found duplicate application node alias: uan-01 for xnames x3000c0s26b0n0 x3000c0s28b0n0")
 }
 
+func (suite *ConfigGeneratorTestSuite) TestApplicationNodeConfigValidateAgainstSLS() {
+	slsState := sls_common.SLSState{Hardware: suite.allHardware}
+	suite.NoError(TestSLSInputState.ApplicationNodeConfig.ValidateAgainstSLS(slsState))
+}
+
+func (suite *ConfigGeneratorTestSuite) TestApplicationNodeConfigValidateAgainstSLS_Orphan() {
+	applicationNodeConfig := SLSGeneratorApplicationNodeConfig{
+		Aliases: map[string][]string{
+			"x3000c0s26b0n0": {"uan-01"},
+			"x3000c0s42b0n0": {"uan-99"},
+		},
+	}
+
+	slsState := sls_common.SLSState{Hardware: suite.allHardware}
+	err := applicationNodeConfig.ValidateAgainstSLS(slsState)
+	suite.Equal(errors.New("application node aliases reference xnames not present in SLS: [x3000c0s42b0n0]"), err)
+}
+
+func (suite *ConfigGeneratorTestSuite) TestCountRiverComputeNodes() {
+	suite.Equal(5, CountRiverComputeNodes(HMNConnections))
+}
+
+func (suite *ConfigGeneratorTestSuite) TestValidateHMNConnectionPorts() {
+	hmnRows := []shcd_parser.HMNRow{
+		{Source: "mn01", SourceRack: "x3000", SourceLocation: "u01", DestinationRack: "x3000", DestinationLocation: "u22", DestinationPort: "p25"},
+		{Source: "wn01", SourceRack: "x3000", SourceLocation: "u07", DestinationRack: "x3000", DestinationLocation: "u22", DestinationPort: "p26"},
+		{Source: "wn02", SourceRack: "x3000", SourceLocation: "u09", DestinationRack: "x3000", DestinationLocation: "u23", DestinationPort: "p25"},
+	}
+	suite.NoError(ValidateHMNConnectionPorts(hmnRows))
+}
+
+func (suite *ConfigGeneratorTestSuite) TestValidateHMNConnectionPorts_Duplicate() {
+	hmnRows := []shcd_parser.HMNRow{
+		{Source: "mn01", SourceRack: "x3000", SourceLocation: "u01", DestinationRack: "x3000", DestinationLocation: "u22", DestinationPort: "p25"},
+		{Source: "wn01", SourceRack: "x3000", SourceLocation: "u07", DestinationRack: "x3000", DestinationLocation: "u22", DestinationPort: "p26"},
+		{Source: "wn02", SourceRack: "x3000", SourceLocation: "u09", DestinationRack: "x3000", DestinationLocation: "u22", DestinationPort: "p25"},
+	}
+
+	err := ValidateHMNConnectionPorts(hmnRows)
+	suite.Equal(errors.New("hmn_connections has switch ports cabled to more than one source: x3000 u22 p25 is used by mn01 (x3000 u01), wn02 (x3000 u09)"), err)
+}
+
+func (suite *ConfigGeneratorTestSuite) TestValidateNIDRanges() {
+	suite.NoError(ValidateNIDRanges(1, 999, 1000))
+	suite.NoError(ValidateNIDRanges(1, 0, 1000))
+}
+
+func (suite *ConfigGeneratorTestSuite) TestValidateNIDRanges_Collision() {
+	err := ValidateNIDRanges(1, 1000, 1000)
+	suite.Equal(errors.New("river NIDs 1-1000 for 1000 river nodes collide with the mountain NIDs starting at 1000"), err)
+}
+
 func TestConfigGeneratorTestSuite(t *testing.T) {
 	suite.Run(t, new(ConfigGeneratorTestSuite))
 }
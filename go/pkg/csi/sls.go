@@ -20,15 +20,20 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"net"
 	"net/http"
 	"os"
+	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
 
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+	"github.com/Cray-HPE/csm-common/go/pkg/logging"
+	base "github.com/Cray-HPE/hms-base"
 	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
 )
 
@@ -107,7 +112,7 @@ func ExtractUANs(sls *sls_common.SLSState) ([]LogicalUAN, error) {
 			}
 			if extra.Role == "Application" && extra.SubRole == "UAN" {
 				if extra.Aliases == nil {
-					log.Fatal("ERROR: UANs must have at least one alias defined in the application-node-config-yaml file")
+					return uans, fmt.Errorf("UAN %s must have at least one alias defined in the application-node-config-yaml file", key)
 				}
 				uans = append(uans, LogicalUAN{
 					Xname:    key,
@@ -138,7 +143,7 @@ func ExtractSLSNCNs(sls *sls_common.SLSState) ([]LogicalNCN, error) {
 				// log.Printf("Node = %v and Extra = %v", node, extra)
 				mgmtSwitch, port, err := portForXname(sls.Hardware, node.Parent)
 				if err != nil { // Sometimes the port is not available.  We *should* be able to continue
-					log.Printf("%v %v\n", err, port)
+					logging.Warnf("%v %v", err, port)
 				}
 				ncns = append(ncns, LogicalNCN{
 					Xname:    key,
@@ -154,6 +159,28 @@ func ExtractSLSNCNs(sls *sls_common.SLSState) ([]LogicalNCN, error) {
 	return ncns, nil
 }
 
+// MatchSLSNCNs pairs every NCN from ncn_metadata with the NCN from SLS that has the same xname once both are
+// normalized, so that representation differences such as zero padding do not prevent a match. The matches are
+// keyed by the xname from ncn_metadata and the xnames that have no match in SLS are returned sorted.
+func MatchSLSNCNs(metadataNCNs []LogicalNCN, slsNCNs []LogicalNCN) (map[string]LogicalNCN, []string) {
+	slsByXname := make(map[string]LogicalNCN)
+	for _, ncn := range slsNCNs {
+		slsByXname[base.NormalizeHMSCompID(ncn.Xname)] = ncn
+	}
+
+	matches := make(map[string]LogicalNCN)
+	var unresolved []string
+	for _, ncn := range metadataNCNs {
+		if slsNCN, ok := slsByXname[base.NormalizeHMSCompID(ncn.Xname)]; ok {
+			matches[ncn.Xname] = slsNCN
+		} else {
+			unresolved = append(unresolved, ncn.Xname)
+		}
+	}
+	sort.Strings(unresolved)
+	return matches, unresolved
+}
+
 // Return a tuple of strings that match switch and switchport for the BMC
 func portForXname(hardware map[string]sls_common.GenericHardware, xname string) (string, string, error) {
 	for _, node := range hardware {
@@ -180,20 +207,62 @@ func portForXname(hardware map[string]sls_common.GenericHardware, xname string)
 // ExtractSLSSwitches reads the SLSState object and finds any switches
 func ExtractSLSSwitches(sls *sls_common.SLSState) ([]ManagementSwitch, error) {
 	var switches []ManagementSwitch
-	for _, node := range sls.Hardware {
-		if node.Type == sls_common.MgmtSwitch {
-			var extra sls_common.ComptypeMgmtSwitch
-			err := mapstructure.Decode(node.ExtraPropertiesRaw, &extra)
-			if err != nil {
+	for key, node := range sls.Hardware {
+		var extra sls_common.ComptypeMgmtHLSwitch
+		switch node.Type {
+		case sls_common.MgmtSwitch, sls_common.MgmtHLSwitch, sls_common.CDUMgmtSwitch:
+			// The CDU and leaf-bmc extra properties are a subset of the HL switch ones
+			if err := mapstructure.Decode(node.ExtraPropertiesRaw, &extra); err != nil {
 				return switches, err
 			}
-			// TODO: Map the switch data to either an SLS object or an internal object as needed by SLS
-			// Update the signature to return the lost of switches
+		default:
+			continue
+		}
+		switchType, err := slsSwitchType(node.Type, extra.Aliases)
+		if err != nil {
+			return switches, fmt.Errorf("unable to determine the type of switch %v: %v", key, err)
+		}
+		mySwitch := ManagementSwitch{
+			Xname:               key,
+			Brand:               ManagementSwitchBrand(extra.Brand),
+			Model:               extra.Model,
+			SwitchType:          switchType,
+			ManagementInterface: net.ParseIP(extra.IP4Addr),
 		}
+		if len(extra.Aliases) > 0 {
+			mySwitch.Name = extra.Aliases[0]
+		}
+		switches = append(switches, mySwitch)
 	}
+	sort.Slice(switches, func(i, j int) bool {
+		return switches[i].Xname < switches[j].Xname
+	})
 	return switches, nil
 }
 
+// slsSwitchType reverses the hardware type and alias given to each type of switch_metadata.csv switch in SLS
+func slsSwitchType(hardwareType sls_common.HMSStringType, aliases []string) (ManagementSwitchType, error) {
+	switch hardwareType {
+	case sls_common.MgmtSwitch:
+		return ManagementSwitchTypeLeafBMC, nil
+	case sls_common.CDUMgmtSwitch:
+		return ManagementSwitchTypeCDU, nil
+	}
+	for _, alias := range aliases {
+		switch {
+		case strings.HasPrefix(alias, "sw-spine"):
+			return ManagementSwitchTypeSpine, nil
+		case strings.HasPrefix(alias, "sw-leaf-bmc"):
+			return ManagementSwitchTypeLeafBMC, nil
+		case strings.HasPrefix(alias, "sw-leaf"):
+			return ManagementSwitchTypeLeaf, nil
+		case strings.HasPrefix(alias, "sw-edge"):
+			return ManagementSwitchTypeEdge, nil
+		}
+	}
+	return "", fmt.Errorf("no sw-spine, sw-leaf or sw-edge alias in %v", aliases)
+}
+
 // CabinetForXname extracts the cabinet identifier from an xname
 func CabinetForXname(xname string) (string, error) {
 	r := regexp.MustCompile("(x[0-9]+)") // the leading x is not part of the cabinet identifier
@@ -216,3 +285,324 @@ func GetSLSCabinets(state sls_common.SLSState, class sls_common.CabinetType) []s
 
 	return cabinets
 }
+
+// CabinetSummary is a breakdown of the nodes present in a single cabinet
+type CabinetSummary struct {
+	Xname            string                 `json:"xname"`
+	Class            sls_common.CabinetType `json:"class"`
+	NodeCount        int                    `json:"node_count"`
+	ManagementNodes  int                    `json:"management_nodes"`
+	ComputeNodes     int                    `json:"compute_nodes"`
+	ApplicationNodes int                    `json:"application_nodes"`
+}
+
+// GetSLSCabinetSummaries builds a per-cabinet summary of node counts from SLS, sorted by cabinet xname
+func GetSLSCabinetSummaries(state sls_common.SLSState) ([]CabinetSummary, error) {
+	summaries := map[string]*CabinetSummary{}
+	for _, hardware := range state.Hardware {
+		if hardware.Type == sls_common.Cabinet {
+			summaries[hardware.Xname] = &CabinetSummary{Xname: hardware.Xname, Class: hardware.Class}
+		}
+	}
+
+	for _, hardware := range state.Hardware {
+		if hardware.Type != sls_common.Node {
+			continue
+		}
+		cabinet, err := CabinetForXname(hardware.Xname)
+		if err != nil {
+			return nil, err
+		}
+		summary, ok := summaries[cabinet]
+		if !ok {
+			return nil, fmt.Errorf("node %v is in cabinet %v which is not present in SLS", hardware.Xname, cabinet)
+		}
+		var extra sls_common.ComptypeNode
+		if err := mapstructure.Decode(hardware.ExtraPropertiesRaw, &extra); err != nil {
+			return nil, err
+		}
+		summary.NodeCount++
+		switch extra.Role {
+		case "Management":
+			summary.ManagementNodes++
+		case "Compute":
+			summary.ComputeNodes++
+		case "Application":
+			summary.ApplicationNodes++
+		}
+	}
+
+	var result []CabinetSummary
+	for _, summary := range summaries {
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Xname < result[j].Xname
+	})
+	return result, nil
+}
+
+// DefaultSLSVersion is the SLS schema generated when sls-version is not set
+const DefaultSLSVersion = "v1"
+
+// SLSEncoder converts generated networks into the shape of a particular SLS schema version
+type SLSEncoder interface {
+	EncodeNetwork(network *IPV4Network) sls_common.Network
+}
+
+// SLSv1Encoder encodes networks with the sls_common.NetworkExtraProperties layout of the v1 SLS schema
+type SLSv1Encoder struct{}
+
+// SLSEncoders are the supported SLS schema versions
+var SLSEncoders = map[string]SLSEncoder{
+	"v1": SLSv1Encoder{},
+}
+
+// NewSLSEncoder returns the encoder for an SLS schema version, an empty version being DefaultSLSVersion
+func NewSLSEncoder(version string) (SLSEncoder, error) {
+	if version == "" {
+		version = DefaultSLSVersion
+	}
+	encoder, ok := SLSEncoders[strings.ToLower(version)]
+	if !ok {
+		var versions []string
+		for known := range SLSEncoders {
+			versions = append(versions, known)
+		}
+		sort.Strings(versions)
+		return nil, fmt.Errorf("unsupported sls-version %q, expected one of %s", version, strings.Join(versions, ", "))
+	}
+	return encoder, nil
+}
+
+// ConvertIPV4NetworkToSLS converts an IPV4Network into the v1 SLS representation of a network
+func ConvertIPV4NetworkToSLS(network *IPV4Network) sls_common.Network {
+	return SLSv1Encoder{}.EncodeNetwork(network)
+}
+
+// slsIPReservation6 is a v1 SLS reservation with the IPv6 address of a dual-stack subnet alongside
+type slsIPReservation6 struct {
+	sls_common.IPReservation
+	IPv6Address net.IP `json:"IPv6Address,omitempty"`
+}
+
+// slsSubnet6 is a v1 SLS subnet with the IPv6 half of a dual-stack subnet alongside
+type slsSubnet6 struct {
+	sls_common.IPV4Subnet
+	CIDR6          string              `json:"CIDR6,omitempty"`
+	Gateway6       net.IP              `json:"Gateway6,omitempty"`
+	IPReservations []slsIPReservation6 `json:"IPReservations,omitempty"`
+}
+
+// slsNetworkExtraProperties6 are the v1 SLS network extra properties of a dual-stack network.  The IPv6 fields
+// are additions to the v1 keys, so a reader that only knows v1 still finds everything it expects.
+type slsNetworkExtraProperties6 struct {
+	sls_common.NetworkExtraProperties
+	Subnets []slsSubnet6 `json:"Subnets"`
+}
+
+// EncodeNetwork converts an IPV4Network into the v1 SLS representation of a network.  The subnets and
+// reservations of a dual-stack network also carry their IPv6 addresses.
+func (SLSv1Encoder) EncodeNetwork(network *IPV4Network) sls_common.Network {
+	var subnets []sls_common.IPV4Subnet
+	var subnets6 []slsSubnet6
+	for _, subnet := range network.Subnets {
+		var reservations []sls_common.IPReservation
+		var reservations6 []slsIPReservation6
+		for _, reservation := range subnet.IPReservations {
+			slsReservation := sls_common.IPReservation{
+				Name:      reservation.Name,
+				IPAddress: reservation.IPAddress,
+				Aliases:   reservation.Aliases,
+				Comment:   reservation.Comment,
+			}
+			reservations = append(reservations, slsReservation)
+			reservations6 = append(reservations6, slsIPReservation6{slsReservation, reservation.IPv6Address})
+		}
+		slsSubnet := sls_common.IPV4Subnet{
+			FullName:         subnet.FullName,
+			CIDR:             subnet.CIDR.String(),
+			IPReservations:   reservations,
+			Name:             subnet.Name,
+			VlanID:           subnet.VlanID,
+			Gateway:          subnet.Gateway,
+			DHCPStart:        subnet.DHCPStart,
+			DHCPEnd:          subnet.DHCPEnd,
+			Comment:          subnet.Comment,
+			ReservationStart: subnet.ReservationStart,
+			ReservationEnd:   subnet.ReservationEnd,
+			MetalLBPoolName:  subnet.MetalLBPoolName,
+		}
+		subnets = append(subnets, slsSubnet)
+		subnet6 := slsSubnet6{IPV4Subnet: slsSubnet, Gateway6: subnet.Gateway6, IPReservations: reservations6}
+		if subnet.CIDR6.IP != nil {
+			subnet6.CIDR6 = subnet.CIDR6.String()
+		}
+		subnets6 = append(subnets6, subnet6)
+	}
+
+	ipRanges := []string{network.CIDR}
+	if network.CIDR6 != "" {
+		ipRanges = append(ipRanges, network.CIDR6)
+	}
+
+	extraProperties := sls_common.NetworkExtraProperties{
+		CIDR:               network.CIDR,
+		VlanRange:          network.VlanRange,
+		MTU:                network.MTU,
+		Comment:            network.Comment,
+		PeerASN:            network.PeerASN,
+		MyASN:              network.MyASN,
+		Subnets:            subnets,
+		SystemDefaultRoute: network.SystemDefaultRoute,
+	}
+	slsNetwork := sls_common.Network{
+		Name:               network.Name,
+		FullName:           network.FullName,
+		IPRanges:           ipRanges,
+		Type:               network.NetType,
+		ExtraPropertiesRaw: extraProperties,
+	}
+	if network.CIDR6 != "" {
+		slsNetwork.ExtraPropertiesRaw = slsNetworkExtraProperties6{NetworkExtraProperties: extraProperties, Subnets: subnets6}
+	}
+	return slsNetwork
+}
+
+// ConvertIPV4NetworksToSLS converts the networks into the networks section of a v1 SLS dump
+func ConvertIPV4NetworksToSLS(networks map[string]*IPV4Network) map[string]sls_common.Network {
+	return EncodeIPV4Networks(SLSv1Encoder{}, networks)
+}
+
+// EncodeIPV4Networks converts the networks into the networks section of an SLS dump using encoder
+func EncodeIPV4Networks(encoder SLSEncoder, networks map[string]*IPV4Network) map[string]sls_common.Network {
+	slsNetworks := make(map[string]sls_common.Network)
+	for _, network := range networks {
+		slsNetworks[network.Name] = encoder.EncodeNetwork(network)
+	}
+	return slsNetworks
+}
+
+// WriteSLSNetworksPayload writes the networks section of a v1 SLS dump which can be loaded into a running SLS
+func WriteSLSNetworksPayload(path string, networks map[string]*IPV4Network) error {
+	return WriteEncodedSLSNetworksPayload(path, SLSv1Encoder{}, networks)
+}
+
+// WriteEncodedSLSNetworksPayload writes the networks section of an SLS dump in the schema of encoder
+func WriteEncodedSLSNetworksPayload(path string, encoder SLSEncoder, networks map[string]*IPV4Network) error {
+	return csiFiles.WriteJSONConfig(path, EncodeIPV4Networks(encoder, networks))
+}
+
+// SLSStateDiff lists the hardware xnames and networks that a local SLS state would add, remove or change in a live one
+type SLSStateDiff struct {
+	AddedHardware   []string
+	RemovedHardware []string
+	ChangedHardware []string
+	AddedNetworks   []string
+	RemovedNetworks []string
+	ChangedNetworks []string
+}
+
+// Empty is true when the two SLS states match
+func (diff SLSStateDiff) Empty() bool {
+	return len(diff.AddedHardware)+len(diff.RemovedHardware)+len(diff.ChangedHardware)+
+		len(diff.AddedNetworks)+len(diff.RemovedNetworks)+len(diff.ChangedNetworks) == 0
+}
+
+// String presents the differences one per line, prefixed with +, - or ~ for added, removed and changed
+func (diff SLSStateDiff) String() string {
+	var lines []string
+	for _, section := range []struct {
+		prefix string
+		kind   string
+		names  []string
+	}{
+		{"+", "hardware", diff.AddedHardware},
+		{"-", "hardware", diff.RemovedHardware},
+		{"~", "hardware", diff.ChangedHardware},
+		{"+", "network", diff.AddedNetworks},
+		{"-", "network", diff.RemovedNetworks},
+		{"~", "network", diff.ChangedNetworks},
+	} {
+		for _, name := range section.names {
+			lines = append(lines, fmt.Sprintf("%s %s %s", section.prefix, section.kind, name))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CompareSLSStates compares a local SLS state, such as sls_input_file.json, with the live state of SLS.
+// The LastUpdated timestamps SLS maintains itself are ignored.
+func CompareSLSStates(local, live sls_common.SLSState) (SLSStateDiff, error) {
+	var diff SLSStateDiff
+
+	localHardware := make(map[string]interface{})
+	for xname, hardware := range local.Hardware {
+		hardware.LastUpdated, hardware.LastUpdatedTime = 0, ""
+		localHardware[xname] = hardware
+	}
+	liveHardware := make(map[string]interface{})
+	for xname, hardware := range live.Hardware {
+		hardware.LastUpdated, hardware.LastUpdatedTime = 0, ""
+		liveHardware[xname] = hardware
+	}
+	var err error
+	diff.AddedHardware, diff.RemovedHardware, diff.ChangedHardware, err = compareSLSObjects(localHardware, liveHardware)
+	if err != nil {
+		return diff, err
+	}
+
+	localNetworks := make(map[string]interface{})
+	for name, network := range local.Networks {
+		network.LastUpdated, network.LastUpdatedTime = 0, ""
+		localNetworks[name] = network
+	}
+	liveNetworks := make(map[string]interface{})
+	for name, network := range live.Networks {
+		network.LastUpdated, network.LastUpdatedTime = 0, ""
+		liveNetworks[name] = network
+	}
+	diff.AddedNetworks, diff.RemovedNetworks, diff.ChangedNetworks, err = compareSLSObjects(localNetworks, liveNetworks)
+	return diff, err
+}
+
+// compareSLSObjects compares the JSON representation of each object so typed and decoded extra properties compare equal
+func compareSLSObjects(local, live map[string]interface{}) (added, removed, changed []string, err error) {
+	for name, localObject := range local {
+		liveObject, ok := live[name]
+		if !ok {
+			added = append(added, name)
+			continue
+		}
+		localJSON, err := normalizeSLSObject(localObject)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		liveJSON, err := normalizeSLSObject(liveObject)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if !reflect.DeepEqual(localJSON, liveJSON) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range live {
+		if _, ok := local[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed, nil
+}
+
+func normalizeSLSObject(object interface{}) (interface{}, error) {
+	buf, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	err = json.Unmarshal(buf, &normalized)
+	return normalized, err
+}
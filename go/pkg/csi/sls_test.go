@@ -8,8 +8,15 @@ Copyright 2021 Hewlett Packard Enterprise Development LP
 package csi
 
 import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/Cray-HPE/csm-common/go/pkg/sls"
 	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
 	"github.com/stretchr/testify/suite"
 )
@@ -41,6 +48,263 @@ func (suite *SLSTestSuite) TestGetSLSCabinets() {
 	suite.Len(GetSLSCabinets(slsState, sls_common.ClassMountain), 3, "Mountain Cabinets")
 }
 
+func (suite *SLSTestSuite) TestGetSLSCabinetSummaries() {
+	slsState := sls_common.SLSState{
+		Hardware: map[string]sls_common.GenericHardware{
+			"x3000": {Xname: "x3000", Type: sls_common.Cabinet, Class: sls_common.ClassRiver},
+			"x1000": {Xname: "x1000", Type: sls_common.Cabinet, Class: sls_common.ClassMountain},
+			"x1001": {Xname: "x1001", Type: sls_common.Cabinet, Class: sls_common.ClassMountain},
+
+			"x3000c0s1b0n0": {Xname: "x3000c0s1b0n0", Type: sls_common.Node, Class: sls_common.ClassRiver,
+				ExtraPropertiesRaw: sls_common.ComptypeNode{Role: "Management", SubRole: "Master"}},
+			"x3000c0s4b0n0": {Xname: "x3000c0s4b0n0", Type: sls_common.Node, Class: sls_common.ClassRiver,
+				ExtraPropertiesRaw: sls_common.ComptypeNode{Role: "Management", SubRole: "Worker"}},
+			"x3000c0s19b1n0": {Xname: "x3000c0s19b1n0", Type: sls_common.Node, Class: sls_common.ClassRiver,
+				ExtraPropertiesRaw: map[string]interface{}{"Role": "Compute"}},
+			"x3000c0s26b0n0": {Xname: "x3000c0s26b0n0", Type: sls_common.Node, Class: sls_common.ClassRiver,
+				ExtraPropertiesRaw: map[string]interface{}{"Role": "Application", "SubRole": "UAN"}},
+			"x1000c0s0b0n0": {Xname: "x1000c0s0b0n0", Type: sls_common.Node, Class: sls_common.ClassMountain,
+				ExtraPropertiesRaw: sls_common.ComptypeNode{Role: "Compute"}},
+			"x1000c0s0b0n1": {Xname: "x1000c0s0b0n1", Type: sls_common.Node, Class: sls_common.ClassMountain,
+				ExtraPropertiesRaw: sls_common.ComptypeNode{Role: "Compute"}},
+
+			// Non-node hardware is not counted
+			"x3000c0w14": {Xname: "x3000c0w14", Type: sls_common.MgmtSwitch, Class: sls_common.ClassRiver},
+		},
+	}
+
+	summaries, err := GetSLSCabinetSummaries(slsState)
+	suite.NoError(err)
+	suite.Equal([]CabinetSummary{
+		{Xname: "x1000", Class: sls_common.ClassMountain, NodeCount: 2, ComputeNodes: 2},
+		{Xname: "x1001", Class: sls_common.ClassMountain},
+		{Xname: "x3000", Class: sls_common.ClassRiver, NodeCount: 4, ManagementNodes: 2, ComputeNodes: 1, ApplicationNodes: 1},
+	}, summaries)
+}
+
+func (suite *SLSTestSuite) TestConvertIPV4NetworksToSLS() {
+	_, cidr, _ := net.ParseCIDR("10.254.0.0/17")
+	_, subnetCIDR, _ := net.ParseCIDR("10.254.1.0/24")
+	networks := map[string]*IPV4Network{
+		"HMN": {
+			Name:      "HMN",
+			FullName:  "Hardware Management Network",
+			CIDR:      cidr.String(),
+			VlanRange: []int16{4},
+			MTU:       9000,
+			NetType:   sls_common.NetworkTypeEthernet,
+			Subnets: []*IPV4Subnet{{
+				Name:      "bootstrap_dhcp",
+				FullName:  "HMN Bootstrap DHCP Subnet",
+				CIDR:      *subnetCIDR,
+				VlanID:    4,
+				Gateway:   net.ParseIP("10.254.1.1"),
+				DHCPStart: net.ParseIP("10.254.1.100"),
+				DHCPEnd:   net.ParseIP("10.254.1.200"),
+				IPReservations: []IPReservation{
+					{Name: "ncn-m001-mgmt", IPAddress: net.ParseIP("10.254.1.4"), Comment: "x3000c0s1b0"},
+				},
+			}},
+		},
+	}
+
+	payload, err := json.Marshal(ConvertIPV4NetworksToSLS(networks))
+	suite.NoError(err)
+
+	// Decode the payload the same way a client of the SLS API would
+	var decoded map[string]struct {
+		Name            string                     `json:"Name"`
+		FullName        string                     `json:"FullName"`
+		IPRanges        []string                   `json:"IPRanges"`
+		Type            sls_common.NetworkType     `json:"Type"`
+		ExtraProperties sls.NetworkExtraProperties `json:"ExtraProperties"`
+	}
+	suite.NoError(json.Unmarshal(payload, &decoded))
+	suite.Len(decoded, 1)
+
+	hmn := decoded["HMN"]
+	suite.Equal("HMN", hmn.Name)
+	suite.Equal("Hardware Management Network", hmn.FullName)
+	suite.Equal([]string{"10.254.0.0/17"}, hmn.IPRanges)
+	suite.Equal(sls_common.NetworkTypeEthernet, hmn.Type)
+	suite.Equal("10.254.0.0/17", hmn.ExtraProperties.CIDR)
+	suite.Equal([]int16{4}, hmn.ExtraProperties.VlanRange)
+	suite.Equal(int16(9000), hmn.ExtraProperties.MTU)
+
+	subnet, err := hmn.ExtraProperties.LookupSubnet("bootstrap_dhcp")
+	suite.NoError(err)
+	suite.Equal("10.254.1.0/24", subnet.CIDR)
+	suite.Equal("10.254.1.1", subnet.Gateway)
+	suite.Equal("10.254.1.100", subnet.DHCPStart)
+	suite.Equal("10.254.1.200", subnet.DHCPEnd)
+	suite.Equal(sls.IPReservation{Name: "ncn-m001-mgmt", IPAddress: "10.254.1.4", Comment: "x3000c0s1b0"}, subnet.ReservationsByName()["ncn-m001-mgmt"])
+}
+
+func (suite *SLSTestSuite) TestConvertIPV4NetworksToSLS_DualStack() {
+	network := &IPV4Network{Name: "NMN_RVR", CIDR: "10.100.0.0/16", CIDR6: "fd00:100::/48", VlanRange: []int16{1770, 1999}}
+	subnet, err := network.AddSubnet(net.CIDRMask(24, 32), "cabinet_3000", 1770)
+	suite.NoError(err)
+	_, err = subnet.AddReservation("ncn-w001", "x3000c0s7b0n0")
+	suite.NoError(err)
+
+	payload, err := json.Marshal(ConvertIPV4NetworkToSLS(network))
+	suite.NoError(err)
+	var decoded struct {
+		IPRanges        []string `json:"IPRanges"`
+		ExtraProperties struct {
+			CIDR    string `json:"CIDR"`
+			Subnets []struct {
+				CIDR           string `json:"CIDR"`
+				CIDR6          string `json:"CIDR6"`
+				Gateway6       string `json:"Gateway6"`
+				IPReservations []struct {
+					Name        string `json:"Name"`
+					IPAddress   string `json:"IPAddress"`
+					IPv6Address string `json:"IPv6Address"`
+				} `json:"IPReservations"`
+			} `json:"Subnets"`
+		} `json:"ExtraProperties"`
+	}
+	suite.NoError(json.Unmarshal(payload, &decoded))
+	suite.Equal([]string{"10.100.0.0/16", "fd00:100::/48"}, decoded.IPRanges)
+	suite.Equal("10.100.0.0/16", decoded.ExtraProperties.CIDR)
+	suite.Len(decoded.ExtraProperties.Subnets, 1)
+	slsSubnet := decoded.ExtraProperties.Subnets[0]
+	suite.Equal("10.100.0.0/24", slsSubnet.CIDR)
+	suite.Equal("fd00:100::/64", slsSubnet.CIDR6)
+	suite.Equal("fd00:100::1", slsSubnet.Gateway6)
+	suite.Len(slsSubnet.IPReservations, 1)
+	suite.Equal("ncn-w001", slsSubnet.IPReservations[0].Name)
+	suite.Equal("10.100.0.2", slsSubnet.IPReservations[0].IPAddress)
+	suite.Equal("fd00:100::2", slsSubnet.IPReservations[0].IPv6Address)
+
+	// IPv4 only networks keep the plain v1 extra properties
+	network.CIDR6 = ""
+	_, ok := ConvertIPV4NetworkToSLS(network).ExtraPropertiesRaw.(sls_common.NetworkExtraProperties)
+	suite.True(ok)
+}
+
+func (suite *SLSTestSuite) TestWriteEncodedSLSNetworksPayload_V1Golden() {
+	_, cidr, _ := net.ParseCIDR("10.252.0.0/17")
+	_, bootstrap, _ := net.ParseCIDR("10.252.1.0/24")
+	_, metallb, _ := net.ParseCIDR("10.92.100.0/24")
+	networks := map[string]*IPV4Network{
+		"NMN": {
+			Name:      "NMN",
+			FullName:  "Node Management Network",
+			CIDR:      cidr.String(),
+			VlanRange: []int16{2},
+			MTU:       9000,
+			MyASN:     65533,
+			PeerASN:   65533,
+			NetType:   sls_common.NetworkTypeEthernet,
+			Subnets: []*IPV4Subnet{{
+				Name:      "bootstrap_dhcp",
+				FullName:  "NMN Bootstrap DHCP Subnet",
+				CIDR:      *bootstrap,
+				VlanID:    2,
+				Gateway:   net.ParseIP("10.252.1.1"),
+				DHCPStart: net.ParseIP("10.252.1.50"),
+				DHCPEnd:   net.ParseIP("10.252.1.100"),
+				IPReservations: []IPReservation{
+					{Name: "kubeapi-vip", IPAddress: net.ParseIP("10.252.1.2"), Aliases: []string{"kubeapi-vip.nmn"}, Comment: "k8s-virtual-ip"},
+					{Name: "ncn-m001", IPAddress: net.ParseIP("10.252.1.4"), Comment: "x3000c0s1b0n0"},
+				},
+			}, {
+				Name:            "nmn_metallb_address_pool",
+				FullName:        "NMN MetalLB",
+				CIDR:            *metallb,
+				VlanID:          2,
+				Gateway:         net.ParseIP("10.92.100.1"),
+				MetalLBPoolName: "node-management",
+			}},
+		},
+	}
+
+	tmpDir, err := ioutil.TempDir("", "sls-encoder")
+	suite.NoError(err)
+	defer os.RemoveAll(tmpDir)
+
+	encoder, err := NewSLSEncoder("")
+	suite.NoError(err)
+	path := filepath.Join(tmpDir, "sls_networks.json")
+	suite.NoError(WriteEncodedSLSNetworksPayload(path, encoder, networks))
+
+	actual, err := ioutil.ReadFile(path)
+	suite.NoError(err)
+	expected, err := ioutil.ReadFile(filepath.Join("testdata", "sls_networks_v1.json"))
+	suite.NoError(err)
+	suite.Equal(string(expected), string(actual))
+}
+
+func (suite *SLSTestSuite) TestNewSLSEncoder_Unsupported() {
+	encoder, err := NewSLSEncoder("v9")
+	suite.Nil(encoder)
+	suite.Equal(errors.New(`unsupported sls-version "v9", expected one of v1`), err)
+}
+
+func (suite *SLSTestSuite) TestMatchSLSNCNs_ZeroPadding() {
+	metadataNCNs := []LogicalNCN{
+		{Xname: "x3000c0s01b0n0"},
+		{Xname: "x03000c0s3b0n0"},
+		{Xname: "x3000c0s5b0n0"},
+	}
+	slsNCNs := []LogicalNCN{
+		{Xname: "x3000c0s1b0n0", Hostname: "ncn-m001"},
+		{Xname: "x3000c0s3b0n0", Hostname: "ncn-m002"},
+	}
+
+	matches, unresolved := MatchSLSNCNs(metadataNCNs, slsNCNs)
+	suite.Equal("ncn-m001", matches["x3000c0s01b0n0"].Hostname)
+	suite.Equal("ncn-m002", matches["x03000c0s3b0n0"].Hostname)
+	suite.Equal([]string{"x3000c0s5b0n0"}, unresolved)
+}
+
+func (suite *SLSTestSuite) TestCompareSLSStates() {
+	local := sls_common.SLSState{
+		Hardware: map[string]sls_common.GenericHardware{
+			"x3000":         {Xname: "x3000", Type: sls_common.Cabinet, Class: sls_common.ClassRiver},
+			"x3000c0s1b0n0": {Xname: "x3000c0s1b0n0", Type: sls_common.Node, ExtraPropertiesRaw: sls_common.ComptypeNode{Role: "Management", Aliases: []string{"ncn-m001"}}},
+			"x3000c0s3b0n0": {Xname: "x3000c0s3b0n0", Type: sls_common.Node, ExtraPropertiesRaw: sls_common.ComptypeNode{Role: "Management", Aliases: []string{"ncn-m002"}}},
+		},
+		Networks: map[string]sls_common.Network{
+			"HMN": {Name: "HMN", IPRanges: []string{"10.254.0.0/17"}},
+			"NMN": {Name: "NMN", IPRanges: []string{"10.252.0.0/17"}},
+		},
+	}
+	live := sls_common.SLSState{
+		Hardware: map[string]sls_common.GenericHardware{
+			"x3000": {Xname: "x3000", Type: sls_common.Cabinet, Class: sls_common.ClassRiver, LastUpdated: 1650000000, LastUpdatedTime: "2022-04-15 05:20:00"},
+			"x3000c0s1b0n0": {Xname: "x3000c0s1b0n0", Type: sls_common.Node, ExtraPropertiesRaw: map[string]interface{}{
+				"Role": "Management", "Aliases": []interface{}{"ncn-m001"},
+			}},
+			"x3000c0s3b0n0": {Xname: "x3000c0s3b0n0", Type: sls_common.Node, ExtraPropertiesRaw: map[string]interface{}{
+				"Role": "Management", "Aliases": []interface{}{"ncn-w002"},
+			}},
+			"x3000c0s5b0n0": {Xname: "x3000c0s5b0n0", Type: sls_common.Node},
+		},
+		Networks: map[string]sls_common.Network{
+			"HMN": {Name: "HMN", IPRanges: []string{"10.254.0.0/17"}},
+			"CAN": {Name: "CAN", IPRanges: []string{"10.102.11.0/24"}},
+		},
+	}
+
+	diff, err := CompareSLSStates(local, live)
+	suite.NoError(err)
+	suite.False(diff.Empty())
+	suite.Equal(SLSStateDiff{
+		RemovedHardware: []string{"x3000c0s5b0n0"},
+		ChangedHardware: []string{"x3000c0s3b0n0"},
+		AddedNetworks:   []string{"NMN"},
+		RemovedNetworks: []string{"CAN"},
+	}, diff)
+	suite.Equal("- hardware x3000c0s5b0n0\n~ hardware x3000c0s3b0n0\n+ network NMN\n- network CAN", diff.String())
+
+	diff, err = CompareSLSStates(local, local)
+	suite.NoError(err)
+	suite.True(diff.Empty())
+}
+
 func TestSLSTestSuite(t *testing.T) {
 	suite.Run(t, new(SLSTestSuite))
 }
@@ -27,6 +27,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strings"
 
 	base "github.com/Cray-HPE/hms-base"
 	"github.com/gocarina/gocsv"
@@ -57,6 +58,30 @@ const ManagementSwitchBrandCisco ManagementSwitchBrand = "Cisco"
 // ManagementSwitchBrandJuniper for Juniper Edge switches
 const ManagementSwitchBrandJuniper ManagementSwitchBrand = "Juniper"
 
+// managementSwitchBrandNames maps the lower case vendor names found in SHCDs and switch_metadata.csv to the brands SLS accepts
+var managementSwitchBrandNames = map[string]ManagementSwitchBrand{
+	"aruba":          ManagementSwitchBrandAruba,
+	"hpe aruba":      ManagementSwitchBrandAruba,
+	"aruba networks": ManagementSwitchBrandAruba,
+	"dell":           ManagementSwitchBrandDell,
+	"dell emc":       ManagementSwitchBrandDell,
+	"mellanox":       ManagementSwitchBrandMellanox,
+	"nvidia":         ManagementSwitchBrandMellanox,
+	"arista":         ManagementSwitchBrandArista,
+	"cisco":          ManagementSwitchBrandCisco,
+	"juniper":        ManagementSwitchBrandJuniper,
+}
+
+// NormalizeManagementSwitchBrand maps a vendor name, ignoring case and surrounding space, to the canonical
+// ManagementSwitchBrand.  Unknown vendors are an error rather than being passed through.
+func NormalizeManagementSwitchBrand(vendor string) (ManagementSwitchBrand, error) {
+	brand, ok := managementSwitchBrandNames[strings.ToLower(strings.TrimSpace(vendor))]
+	if !ok {
+		return "", fmt.Errorf("unknown management switch vendor %q (known brands: Aruba, Dell, Mellanox, Arista, Cisco, Juniper)", vendor)
+	}
+	return brand, nil
+}
+
 // ManagementSwitchType the type of management switch CDU/LeafBMC/Spine/Leaf/Edge
 type ManagementSwitchType string
 
@@ -166,6 +191,14 @@ func (mySwitch *ManagementSwitch) Normalize() error {
 	// Right now we only need to the normalize the xname for the switch. IE strip any leading 0s
 	mySwitch.Xname = base.NormalizeHMSCompID(mySwitch.Xname)
 
+	// A switch without a brand is left alone, the brand is optional in switch_metadata.csv
+	if mySwitch.Brand != "" {
+		brand, err := NormalizeManagementSwitchBrand(string(mySwitch.Brand))
+		if err != nil {
+			return fmt.Errorf("%s: %v", mySwitch.Xname, err)
+		}
+		mySwitch.Brand = brand
+	}
 	return nil
 }
 
@@ -181,6 +181,40 @@ func (suite *NetworkingTestSuite) TestNormalizeSwitch() {
 	}
 }
 
+func (suite *NetworkingTestSuite) TestNormalizeManagementSwitchBrand() {
+	tests := []struct {
+		vendor        string
+		expectedBrand ManagementSwitchBrand
+	}{
+		{"aruba", ManagementSwitchBrandAruba},
+		{"HPE Aruba", ManagementSwitchBrandAruba},
+		{"DELL", ManagementSwitchBrandDell},
+		{"mellanox", ManagementSwitchBrandMellanox},
+		{" Mellanox ", ManagementSwitchBrandMellanox},
+		{"arista", ManagementSwitchBrandArista},
+		{"cisco", ManagementSwitchBrandCisco},
+		{"Juniper", ManagementSwitchBrandJuniper},
+	}
+
+	for _, test := range tests {
+		brand, err := NormalizeManagementSwitchBrand(test.vendor)
+		suite.NoError(err, test.vendor)
+		suite.Equal(test.expectedBrand, brand, test.vendor)
+	}
+
+	_, err := NormalizeManagementSwitchBrand("netgear")
+	suite.Equal(errors.New(`unknown management switch vendor "netgear" (known brands: Aruba, Dell, Mellanox, Arista, Cisco, Juniper)`), err)
+}
+
+func (suite *NetworkingTestSuite) TestNormalizeSwitch_Brand() {
+	mySwitch := ManagementSwitch{Xname: "x3000c0h33s1", Brand: "mellanox"}
+	suite.NoError(mySwitch.Normalize())
+	suite.Equal(ManagementSwitchBrandMellanox, mySwitch.Brand)
+
+	mySwitch.Brand = "netgear"
+	suite.Equal(errors.New(`x3000c0h33s1: unknown management switch vendor "netgear" (known brands: Aruba, Dell, Mellanox, Arista, Cisco, Juniper)`), mySwitch.Normalize())
+}
+
 func TestNetworkingTestSuite(t *testing.T) {
 	suite.Run(t, new(NetworkingTestSuite))
 }
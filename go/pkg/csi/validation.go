@@ -0,0 +1,354 @@
+//
+//  MIT License
+//
+//  (C) Copyright 2022 Hewlett Packard Enterprise Development LP
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a
+//  copy of this software and associated documentation files (the "Software"),
+//  to deal in the Software without restriction, including without limitation
+//  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//  and/or sell copies of the Software, and to permit persons to whom the
+//  Software is furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included
+//  in all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+//  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+//  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+//  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+//  OTHER DEALINGS IN THE SOFTWARE.
+
+package csi
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Cray-HPE/csm-common/go/pkg/ipam"
+)
+
+// ValidateNCNBMCReservations verifies that every NCN has a BMC (<hostname>-mgmt) reservation
+// in the bootstrap_dhcp subnet of the HMN and returns an error for each NCN that is missing one
+func ValidateNCNBMCReservations(ncns []LogicalNCN, networks map[string]*IPV4Network) []error {
+	hmnNetwork, ok := networks["HMN"]
+	if !ok {
+		return []error{fmt.Errorf("unable to validate BMC reservations: no HMN network found")}
+	}
+	hmnSubnet, err := hmnNetwork.LookUpSubnet("bootstrap_dhcp")
+	if err != nil {
+		return []error{fmt.Errorf("unable to validate BMC reservations: %v", err)}
+	}
+
+	var errs []error
+	for _, ncn := range ncns {
+		bmcName := fmt.Sprintf("%s-mgmt", ncn.GetHostname())
+		found := false
+		for _, rsrv := range hmnSubnet.IPReservations {
+			if rsrv.Name == bmcName || stringInSlice(bmcName, rsrv.Aliases) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Errorf("no BMC reservation (%s) found on the HMN for %s (%s)", bmcName, ncn.GetHostname(), ncn.Xname))
+		}
+	}
+	return errs
+}
+
+// ValidateSwitchReservations verifies that every management switch has a reservation in a network_hardware subnet.
+// Edge switches are reserved in the bootstrap_dhcp subnet of the CHN instead.
+func ValidateSwitchReservations(switches []*ManagementSwitch, networks map[string]*IPV4Network) []error {
+	reserved := map[string]bool{}
+	for _, network := range networks {
+		for _, subnet := range network.Subnets {
+			if subnet.Name != "network_hardware" && !(network.Name == "CHN" && subnet.Name == "bootstrap_dhcp") {
+				continue
+			}
+			for _, rsrv := range subnet.IPReservations {
+				if rsrv.Comment != "" {
+					reserved[rsrv.Comment] = true
+				}
+			}
+		}
+	}
+
+	var errs []error
+	for _, mswitch := range switches {
+		if !reserved[mswitch.Xname] {
+			errs = append(errs, fmt.Errorf("no network_hardware reservation found for %s switch %s", mswitch.SwitchType, mswitch.Xname))
+		}
+	}
+	return errs
+}
+
+// reservationLocation is where a reservation was found, used to describe conflicts
+type reservationLocation struct {
+	name    string
+	network string
+	subnet  string
+}
+
+func (loc reservationLocation) String() string {
+	return fmt.Sprintf("%s in the %s %s subnet", loc.name, loc.network, loc.subnet)
+}
+
+// ValidateReservations verifies that no IP address is reserved twice, whether in the same subnet or in
+// subnets with overlapping CIDRs, and that no reservation name is used twice in a subnet.  Names are not
+// unique across subnets by design (kubeapi-vip is on every bootstrap network and NCNs are reserved in both
+// bootstrap_dhcp and uai_macvlan) so those are not reported.  There is one error per conflict.
+func ValidateReservations(networks map[string]*IPV4Network) []error {
+	var netNames []string
+	for name := range networks {
+		netNames = append(netNames, name)
+	}
+	sort.Strings(netNames)
+
+	var errs []error
+	reservedIPs := map[string]reservationLocation{}
+	for _, netName := range netNames {
+		for _, subnet := range networks[netName].Subnets {
+			names := map[string]int{}
+			for _, rsrv := range subnet.IPReservations {
+				names[rsrv.Name]++
+				if names[rsrv.Name] == 2 {
+					errs = append(errs, fmt.Errorf("reservation name %s is used more than once in the %s %s subnet", rsrv.Name, netName, subnet.Name))
+				}
+				if rsrv.IPAddress == nil {
+					continue
+				}
+				loc := reservationLocation{name: rsrv.Name, network: netName, subnet: subnet.Name}
+				if first, ok := reservedIPs[rsrv.IPAddress.String()]; ok {
+					errs = append(errs, fmt.Errorf("IP address %v is reserved for %v and for %v", rsrv.IPAddress, first, loc))
+					continue
+				}
+				reservedIPs[rsrv.IPAddress.String()] = loc
+			}
+		}
+	}
+	return errs
+}
+
+// ValidateReservationAddresses verifies that no reservation sits on the network or broadcast address of its
+// subnet.  AddReservation never hands those out but pins, AddReservationWithIP and manual edits can.
+func ValidateReservationAddresses(networks map[string]*IPV4Network) []error {
+	var netNames []string
+	for name := range networks {
+		netNames = append(netNames, name)
+	}
+	sort.Strings(netNames)
+
+	var errs []error
+	for _, netName := range netNames {
+		for _, subnet := range networks[netName].Subnets {
+			broadcast := ipam.Broadcast(subnet.CIDR)
+			for _, rsrv := range subnet.IPReservations {
+				if rsrv.IPAddress == nil {
+					continue
+				}
+				loc := reservationLocation{name: rsrv.Name, network: netName, subnet: subnet.Name}
+				if rsrv.IPAddress.Equal(subnet.CIDR.IP) {
+					errs = append(errs, fmt.Errorf("%v is reserved on the network address %v of %v", loc, rsrv.IPAddress, subnet.CIDR.String()))
+				} else if rsrv.IPAddress.Equal(broadcast) {
+					errs = append(errs, fmt.Errorf("%v is reserved on the broadcast address %v of %v", loc, rsrv.IPAddress, subnet.CIDR.String()))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// vlanBounds returns the first and last vlan of a VlanRange, which holds either a single vlan or a [min, max] pair
+func vlanBounds(vlanRange []int16) (int16, int16) {
+	if len(vlanRange) == 1 {
+		return vlanRange[0], vlanRange[0]
+	}
+	return vlanRange[0], vlanRange[1]
+}
+
+func vlanRangeString(vlanRange []int16) string {
+	low, high := vlanBounds(vlanRange)
+	if low == high {
+		return fmt.Sprintf("%d", low)
+	}
+	return fmt.Sprintf("%d-%d", low, high)
+}
+
+// ValidateVlanRanges verifies that the VlanRange of every network is disjoint from the others.
+// VLAN 0 represents untagged traffic and may be shared.
+func ValidateVlanRanges(networks map[string]*IPV4Network) []error {
+	var names []string
+	for name, network := range networks {
+		if len(network.VlanRange) == 0 {
+			continue
+		}
+		if low, high := vlanBounds(network.VlanRange); low == 0 && high == 0 {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for i, name := range names {
+		low, high := vlanBounds(networks[name].VlanRange)
+		for _, otherName := range names[i+1:] {
+			otherLow, otherHigh := vlanBounds(networks[otherName].VlanRange)
+			if low <= otherHigh && otherLow <= high {
+				errs = append(errs, fmt.Errorf("vlan range %s of the %s network overlaps vlan range %s of the %s network",
+					vlanRangeString(networks[name].VlanRange), name, vlanRangeString(networks[otherName].VlanRange), otherName))
+			}
+		}
+	}
+	return errs
+}
+
+// nestedNetworks maps a network to the network whose CIDR it may sit inside.  The default CHN is carved out of
+// the HMN_MTN range, so for this pair only the allocated subnets have to be disjoint.
+var nestedNetworks = map[string]string{
+	"CHN": "HMN_MTN",
+}
+
+// isNestedNetwork reports whether the inner network is an allowed nesting inside the outer network
+func isNestedNetwork(inner, outer string, innerCIDR, outerCIDR *net.IPNet) bool {
+	innerOnes, _ := innerCIDR.Mask.Size()
+	outerOnes, _ := outerCIDR.Mask.Size()
+	return nestedNetworks[inner] == outer && outerCIDR.Contains(innerCIDR.IP) && innerOnes >= outerOnes
+}
+
+// maskedSubnetCIDR returns the range of a subnet.  Subnets widened by the supernet hack keep their original IP.
+func maskedSubnetCIDR(subnet *IPV4Subnet) net.IPNet {
+	return net.IPNet{IP: subnet.CIDR.IP.Mask(subnet.CIDR.Mask), Mask: subnet.CIDR.Mask}
+}
+
+// sharedRange returns the range two overlapping CIDRs share, CIDRs nest so it is the smaller of the two
+func sharedRange(a, b net.IPNet) net.IPNet {
+	ones, _ := a.Mask.Size()
+	otherOnes, _ := b.Mask.Size()
+	if otherOnes > ones {
+		return b
+	}
+	return a
+}
+
+// ValidateNetworkOverlaps verifies that the CIDRs of the networks are disjoint and returns an error for every
+// pair that overlaps.  CIDRs nest, so the range two networks share is the smaller of the two.  Networks without
+// a CIDR are skipped, as is the 0.0.0.0/0 of the BICAN toggle, which is not a network of its own.  A network
+// listed in nestedNetworks may sit inside its outer network as long as none of their subnets collide.
+func ValidateNetworkOverlaps(networks map[string]*IPV4Network) []error {
+	var errs []error
+	var names []string
+	cidrs := map[string]*net.IPNet{}
+	for name, network := range networks {
+		if network.CIDR == "" {
+			continue
+		}
+		_, cidr, err := net.ParseCIDR(network.CIDR)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("the %s network has an invalid CIDR %q: %v", name, network.CIDR, err))
+			continue
+		}
+		if ones, _ := cidr.Mask.Size(); ones == 0 {
+			continue
+		}
+		cidrs[name] = cidr
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Error() < errs[j].Error()
+	})
+
+	for i, name := range names {
+		for _, otherName := range names[i+1:] {
+			cidr, other := cidrs[name], cidrs[otherName]
+			if !overlaps(*cidr, *other) {
+				continue
+			}
+			if isNestedNetwork(name, otherName, cidr, other) || isNestedNetwork(otherName, name, other, cidr) {
+				errs = append(errs, subnetCollisions(networks[name], networks[otherName])...)
+				continue
+			}
+			shared := sharedRange(*cidr, *other)
+			errs = append(errs, fmt.Errorf("the %s network %v overlaps the %s network %v in %v", name, cidr, otherName, other, &shared))
+		}
+	}
+	return errs
+}
+
+// subnetCollisions returns an error for every subnet of network that overlaps a subnet of other
+func subnetCollisions(network, other *IPV4Network) []error {
+	var errs []error
+	for _, subnet := range network.Subnets {
+		cidr := maskedSubnetCIDR(subnet)
+		for _, otherSubnet := range other.Subnets {
+			otherCIDR := maskedSubnetCIDR(otherSubnet)
+			if !overlaps(cidr, otherCIDR) {
+				continue
+			}
+			shared := sharedRange(cidr, otherCIDR)
+			errs = append(errs, fmt.Errorf("the %s %s subnet %v overlaps the %s %s subnet %v in %v",
+				network.Name, subnet.Name, &cidr, other.Name, otherSubnet.Name, &otherCIDR, &shared))
+		}
+	}
+	return errs
+}
+
+// SmallCabinetSubnetWarnings reports every cabinet subnet with a prefix longer than minimumMask.
+// Such subnets leave no room for growth and usually point at a misconfigured network CIDR.
+func SmallCabinetSubnetWarnings(networks map[string]*IPV4Network, minimumMask int) []string {
+	var names []string
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var warnings []string
+	for _, name := range names {
+		for _, subnet := range networks[name].Subnets {
+			if !strings.HasPrefix(subnet.Name, "cabinet_") {
+				continue
+			}
+			if ones, _ := subnet.CIDR.Mask.Size(); ones > minimumMask {
+				warnings = append(warnings, fmt.Sprintf("%s subnet %s (%s) is smaller than a /%d", name, subnet.Name, subnet.CIDR.String(), minimumMask))
+			}
+		}
+	}
+	return warnings
+}
+
+// hostnameLabelPattern is an RFC 1123 label: letters, digits and hyphens, not starting or ending with a hyphen
+var hostnameLabelPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+
+// ValidateNCNHostnames verifies that the hostname of every NCN is a valid RFC 1123 hostname and that no two
+// NCNs share a hostname, ignoring case as DNS does
+func ValidateNCNHostnames(ncns []LogicalNCN) []error {
+	var errs []error
+	seen := map[string]string{}
+	for _, ncn := range ncns {
+		hostname := ncn.GetHostname()
+		if len(hostname) > 253 {
+			errs = append(errs, fmt.Errorf("hostname %s of %s is longer than 253 characters", hostname, ncn.Xname))
+		} else {
+			for _, label := range strings.Split(hostname, ".") {
+				if !hostnameLabelPattern.MatchString(label) {
+					errs = append(errs, fmt.Errorf("hostname %q of %s is not a valid hostname, each label must be 1 to 63 letters, digits or hyphens and cannot start or end with a hyphen", hostname, ncn.Xname))
+					break
+				}
+			}
+		}
+
+		if other, ok := seen[strings.ToLower(hostname)]; ok {
+			errs = append(errs, fmt.Errorf("hostname %s is used by both %s and %s", hostname, other, ncn.Xname))
+			continue
+		}
+		seen[strings.ToLower(hostname)] = ncn.Xname
+	}
+	return errs
+}
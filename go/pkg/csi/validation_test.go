@@ -0,0 +1,252 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package csi
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ValidationTestSuite struct {
+	suite.Suite
+}
+
+func testHMNNetwork() map[string]*IPV4Network {
+	_, hmnNet, _ := net.ParseCIDR("10.254.0.0/17")
+	_, bootstrapNet, _ := net.ParseCIDR("10.254.0.0/24")
+	bootstrap := &IPV4Subnet{
+		Name:    "bootstrap_dhcp",
+		CIDR:    *bootstrapNet,
+		Gateway: net.ParseIP("10.254.0.1"),
+	}
+	bootstrap.AddReservation("ncn-m001-mgmt", "x3000c0s1b0")
+	bootstrap.AddReservation("ncn-w001-mgmt", "x3000c0s4b0")
+	return map[string]*IPV4Network{
+		"HMN": {
+			Name:    "HMN",
+			CIDR:    hmnNet.String(),
+			Subnets: []*IPV4Subnet{bootstrap},
+		},
+	}
+}
+
+func (suite *ValidationTestSuite) TestValidateNCNBMCReservations() {
+	ncns := []LogicalNCN{
+		{Xname: "x3000c0s1b0n0", Hostname: "ncn-m001"},
+		{Xname: "x3000c0s4b0n0", Hostname: "ncn-w001"},
+	}
+	suite.Empty(ValidateNCNBMCReservations(ncns, testHMNNetwork()))
+}
+
+func (suite *ValidationTestSuite) TestValidateNCNBMCReservations_Missing() {
+	ncns := []LogicalNCN{
+		{Xname: "x3000c0s1b0n0", Hostname: "ncn-m001"},
+		{Xname: "x3000c0s7b0n0", Hostname: "ncn-s001"},
+	}
+	errs := ValidateNCNBMCReservations(ncns, testHMNNetwork())
+	suite.Equal([]error{
+		errors.New("no BMC reservation (ncn-s001-mgmt) found on the HMN for ncn-s001 (x3000c0s7b0n0)"),
+	}, errs)
+}
+
+func (suite *ValidationTestSuite) TestValidateNCNBMCReservations_NoHMN() {
+	errs := ValidateNCNBMCReservations([]LogicalNCN{{Hostname: "ncn-m001"}}, map[string]*IPV4Network{})
+	suite.Equal([]error{errors.New("unable to validate BMC reservations: no HMN network found")}, errs)
+}
+
+func (suite *ValidationTestSuite) TestValidateSwitchReservations() {
+	switches := []*ManagementSwitch{
+		{Xname: "x3000c0h12s1", SwitchType: ManagementSwitchTypeSpine},
+		{Xname: "x3000c0w14", SwitchType: ManagementSwitchTypeLeafBMC},
+		{Xname: "d0w1", SwitchType: ManagementSwitchTypeCDU},
+		{Xname: "x3000c0h13s1", SwitchType: ManagementSwitchType("Aggregation")},
+	}
+
+	_, hardwareNet, _ := net.ParseCIDR("10.252.0.0/24")
+	hardware := &IPV4Subnet{Name: "network_hardware", CIDR: *hardwareNet}
+	hardware.ReserveNetMgmtIPs(
+		switchXnamesByType(switches, ManagementSwitchTypeSpine),
+		switchXnamesByType(switches, ManagementSwitchTypeLeaf),
+		switchXnamesByType(switches, ManagementSwitchTypeLeafBMC),
+		switchXnamesByType(switches, ManagementSwitchTypeCDU),
+	)
+	networks := map[string]*IPV4Network{
+		"NMN": {Name: "NMN", CIDR: "10.252.0.0/17", Subnets: []*IPV4Subnet{hardware}},
+	}
+
+	errs := ValidateSwitchReservations(switches, networks)
+	suite.Equal([]error{
+		errors.New("no network_hardware reservation found for Aggregation switch x3000c0h13s1"),
+	}, errs)
+}
+
+func (suite *ValidationTestSuite) TestValidateReservations() {
+	networks := testHMNNetwork()
+	_, nmnBootstrap, _ := net.ParseCIDR("10.252.1.0/24")
+	_, uaiMacvlan, _ := net.ParseCIDR("10.252.2.0/23")
+	bootstrap := &IPV4Subnet{Name: "bootstrap_dhcp", CIDR: *nmnBootstrap}
+	bootstrap.AddReservation("kubeapi-vip", "k8s-virtual-ip")
+	bootstrap.AddReservation("ncn-m001", "x3000c0s1b0n0")
+	uai := &IPV4Subnet{Name: "uai_macvlan", CIDR: *uaiMacvlan}
+	uai.AddReservation("ncn-m001", "x3000c0s1b0n0")
+	networks["NMN"] = &IPV4Network{Name: "NMN", CIDR: "10.252.0.0/17", Subnets: []*IPV4Subnet{bootstrap, uai}}
+
+	suite.Empty(ValidateReservations(networks))
+}
+
+func (suite *ValidationTestSuite) TestValidateReservations_Conflicts() {
+	networks := testHMNNetwork()
+	bootstrap := networks["HMN"].Subnets[0]
+	bootstrap.AddReservationWithIP("kubeapi-vip", "10.254.0.2", "k8s-virtual-ip")
+	bootstrap.AddReservation("ncn-w001-mgmt", "x3000c0s4b0")
+
+	_, superNet, _ := net.ParseCIDR("10.254.0.0/17")
+	networks["HMNLB"] = &IPV4Network{Name: "HMNLB", Subnets: []*IPV4Subnet{{
+		Name:           "hmn_metallb_address_pool",
+		CIDR:           *superNet,
+		IPReservations: []IPReservation{{Name: "kubeapi-vip", IPAddress: net.ParseIP("10.254.0.3")}},
+	}}}
+
+	suite.Equal([]error{
+		errors.New("IP address 10.254.0.2 is reserved for ncn-m001-mgmt in the HMN bootstrap_dhcp subnet and for kubeapi-vip in the HMN bootstrap_dhcp subnet"),
+		errors.New("reservation name ncn-w001-mgmt is used more than once in the HMN bootstrap_dhcp subnet"),
+		errors.New("IP address 10.254.0.3 is reserved for ncn-w001-mgmt in the HMN bootstrap_dhcp subnet and for kubeapi-vip in the HMNLB hmn_metallb_address_pool subnet"),
+	}, ValidateReservations(networks))
+}
+
+func (suite *ValidationTestSuite) TestValidateReservationAddresses() {
+	networks := testHMNNetwork()
+	suite.Empty(ValidateReservationAddresses(networks))
+
+	bootstrap := networks["HMN"].Subnets[0]
+	bootstrap.AddReservationWithIP("network", "10.254.0.0", "")
+	bootstrap.AddReservationWithIP("broadcast", "10.254.0.255", "")
+	suite.Equal([]error{
+		errors.New("network in the HMN bootstrap_dhcp subnet is reserved on the network address 10.254.0.0 of 10.254.0.0/24"),
+		errors.New("broadcast in the HMN bootstrap_dhcp subnet is reserved on the broadcast address 10.254.0.255 of 10.254.0.0/24"),
+	}, ValidateReservationAddresses(networks))
+}
+
+func (suite *ValidationTestSuite) TestValidateVlanRanges() {
+	networks := map[string]*IPV4Network{
+		"NMN":   {Name: "NMN", VlanRange: []int16{1770, 1999}},
+		"HMN":   {Name: "HMN", VlanRange: []int16{1513, 1769}},
+		"CMN":   {Name: "CMN", VlanRange: []int16{7}},
+		"MTL":   {Name: "MTL", VlanRange: []int16{0}},
+		"BICAN": {Name: "BICAN", VlanRange: []int16{0}},
+		"NMNLB": {Name: "NMNLB"},
+	}
+	suite.Empty(ValidateVlanRanges(networks))
+}
+
+func (suite *ValidationTestSuite) TestValidateVlanRanges_Overlap() {
+	networks := map[string]*IPV4Network{
+		"NMN": {Name: "NMN", VlanRange: []int16{1770, 1999}},
+		"HMN": {Name: "HMN", VlanRange: []int16{1513, 1780}},
+		"CAN": {Name: "CAN", VlanRange: []int16{6}},
+		"CHN": {Name: "CHN", VlanRange: []int16{6}},
+	}
+	suite.Equal([]error{
+		errors.New("vlan range 6 of the CAN network overlaps vlan range 6 of the CHN network"),
+		errors.New("vlan range 1513-1780 of the HMN network overlaps vlan range 1770-1999 of the NMN network"),
+	}, ValidateVlanRanges(networks))
+}
+
+func (suite *ValidationTestSuite) TestValidateNetworkOverlaps() {
+	networks := map[string]*IPV4Network{
+		"NMN":   {Name: "NMN", CIDR: "10.252.0.0/17"},
+		"HMN":   {Name: "HMN", CIDR: "10.254.0.0/17"},
+		"CAN":   {Name: "CAN", CIDR: "10.252.64.0/24"},
+		"CHN":   {Name: "CHN"},
+		"BICAN": {Name: "BICAN", CIDR: "0.0.0.0/0"},
+	}
+	suite.Equal([]error{
+		errors.New("the CAN network 10.252.64.0/24 overlaps the NMN network 10.252.0.0/17 in 10.252.64.0/24"),
+	}, ValidateNetworkOverlaps(networks))
+
+	networks["CAN"].CIDR = "10.102.9.0/24"
+	suite.Empty(ValidateNetworkOverlaps(networks))
+
+	networks["CAN"].CIDR = "10.102.9.0"
+	suite.Equal([]error{
+		errors.New(`the CAN network has an invalid CIDR "10.102.9.0": invalid CIDR address: 10.102.9.0`),
+	}, ValidateNetworkOverlaps(networks))
+}
+
+func (suite *ValidationTestSuite) TestValidateNetworkOverlaps_NestedCHN() {
+	_, bootstrap, _ := net.ParseCIDR("10.104.7.0/24")
+	_, cabinet1000, _ := net.ParseCIDR("10.104.0.0/22")
+	_, cabinet1001, _ := net.ParseCIDR("10.104.4.0/22")
+	networks := map[string]*IPV4Network{
+		"CHN":     {Name: "CHN", CIDR: "10.104.7.0/24", Subnets: []*IPV4Subnet{{Name: "bootstrap_dhcp", CIDR: *bootstrap}}},
+		"HMN_MTN": {Name: "HMN_MTN", CIDR: "10.104.0.0/17", Subnets: []*IPV4Subnet{{Name: "cabinet_1000", CIDR: *cabinet1000}}},
+	}
+	// The CHN may sit inside the HMN_MTN range as long as no cabinet subnet has reached it
+	suite.Empty(ValidateNetworkOverlaps(networks))
+
+	networks["HMN_MTN"].Subnets = append(networks["HMN_MTN"].Subnets, &IPV4Subnet{Name: "cabinet_1001", CIDR: *cabinet1001})
+	suite.Equal([]error{
+		errors.New("the CHN bootstrap_dhcp subnet 10.104.7.0/24 overlaps the HMN_MTN cabinet_1001 subnet 10.104.4.0/22 in 10.104.7.0/24"),
+	}, ValidateNetworkOverlaps(networks))
+
+	// A subnet widened by the supernet hack is compared by its masked range
+	widened := net.IPNet{IP: net.ParseIP("10.104.1.0").To4(), Mask: net.CIDRMask(17, 32)}
+	networks["HMN_MTN"].Subnets = []*IPV4Subnet{{Name: "bootstrap_dhcp", CIDR: widened}}
+	suite.Equal([]error{
+		errors.New("the CHN bootstrap_dhcp subnet 10.104.7.0/24 overlaps the HMN_MTN bootstrap_dhcp subnet 10.104.0.0/17 in 10.104.7.0/24"),
+	}, ValidateNetworkOverlaps(networks))
+
+	// Any other overlapping pair fails on the network CIDRs, whatever their subnets
+	_, nmnBootstrap, _ := net.ParseCIDR("10.252.1.0/24")
+	_, canBootstrap, _ := net.ParseCIDR("10.252.64.0/24")
+	networks = map[string]*IPV4Network{
+		"NMN": {Name: "NMN", CIDR: "10.252.0.0/17", Subnets: []*IPV4Subnet{{Name: "bootstrap_dhcp", CIDR: *nmnBootstrap}}},
+		"CAN": {Name: "CAN", CIDR: "10.252.64.0/24", Subnets: []*IPV4Subnet{{Name: "bootstrap_dhcp", CIDR: *canBootstrap}}},
+	}
+	suite.Equal([]error{
+		errors.New("the CAN network 10.252.64.0/24 overlaps the NMN network 10.252.0.0/17 in 10.252.64.0/24"),
+	}, ValidateNetworkOverlaps(networks))
+}
+
+func (suite *ValidationTestSuite) TestSmallCabinetSubnetWarnings() {
+	_, cabinet3000, _ := net.ParseCIDR("10.106.0.0/22")
+	_, cabinet3001, _ := net.ParseCIDR("10.106.4.0/30")
+	networks := map[string]*IPV4Network{
+		"NMN_RVR": {Name: "NMN_RVR", Subnets: []*IPV4Subnet{
+			{Name: "cabinet_3000", CIDR: *cabinet3000},
+			{Name: "cabinet_3001", CIDR: *cabinet3001},
+		}},
+	}
+
+	suite.Equal([]string{"NMN_RVR subnet cabinet_3001 (10.106.4.0/30) is smaller than a /27"}, SmallCabinetSubnetWarnings(networks, DefaultMinimumCabinetSubnetMask))
+	suite.Empty(SmallCabinetSubnetWarnings(networks, 30))
+}
+
+func (suite *ValidationTestSuite) TestValidateNCNHostnames() {
+	ncns := []LogicalNCN{
+		{Xname: "x3000c0s1b0n0", Hostname: "ncn-m001"},
+		{Xname: "x3000c0s3b0n0", Hostname: "ncn-m002"},
+		{Xname: "x3000c0s5b0n0", Hostname: "NCN-M001"},
+		{Xname: "x3000c0s7b0n0", Hostname: "ncn_w001"},
+		{Xname: "x3000c0s9b0n0", Hostname: "ncn-w002-"},
+	}
+
+	suite.Equal([]error{
+		errors.New("hostname NCN-M001 is used by both x3000c0s1b0n0 and x3000c0s5b0n0"),
+		errors.New(`hostname "ncn_w001" of x3000c0s7b0n0 is not a valid hostname, each label must be 1 to 63 letters, digits or hyphens and cannot start or end with a hyphen`),
+		errors.New(`hostname "ncn-w002-" of x3000c0s9b0n0 is not a valid hostname, each label must be 1 to 63 letters, digits or hyphens and cannot start or end with a hyphen`),
+	}, ValidateNCNHostnames(ncns))
+	suite.Empty(ValidateNCNHostnames(ncns[:2]))
+}
+
+func TestValidationTestSuite(t *testing.T) {
+	suite.Run(t, new(ValidationTestSuite))
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package httpretry
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultTimeout is the time allowed for each attempt of a request
+	DefaultTimeout = 30 * time.Second
+	// DefaultRetries is the number of times a failed request is retried
+	DefaultRetries = 3
+	// DefaultBackoff is the wait before the first retry, it doubles for every retry after that
+	DefaultBackoff = time.Second
+)
+
+// Transport retries the requests of its Base transport.  GET and HEAD requests are retried on any
+// error and on 429 and 5xx responses, while other methods are only retried when the connection
+// could not be made, so a request the server may have acted on is never sent twice.  A Timeout
+// bounds each attempt, including reading the response body.
+type Transport struct {
+	Base    http.RoundTripper
+	Retries int
+	Backoff time.Duration
+	Timeout time.Duration
+}
+
+// Option configures the client returned by NewClient
+type Option func(*Transport)
+
+// WithTimeout gives each attempt of a request timeout to complete, including reading the response body
+func WithTimeout(timeout time.Duration) Option {
+	return func(transport *Transport) {
+		transport.Timeout = timeout
+	}
+}
+
+// WithRetries retries a failed request retries times
+func WithRetries(retries int) Option {
+	return func(transport *Transport) {
+		transport.Retries = retries
+	}
+}
+
+// NewClient returns a client that skips TLS verification, as the BSS and SLS clients do, gives each
+// attempt DefaultTimeout to complete and retries failed requests DefaultRetries times with exponential
+// backoff.  The options override the timeout and the number of retries.
+func NewClient(options ...Option) *http.Client {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: true,
+	}
+	transport := &Transport{
+		Base:    base,
+		Retries: DefaultRetries,
+		Backoff: DefaultBackoff,
+		Timeout: DefaultTimeout,
+	}
+	for _, option := range options {
+		option(transport)
+	}
+	return &http.Client{Transport: transport}
+}
+
+// cancelBody releases the deadline of an attempt once its response body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (body cancelBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.cancel()
+	return err
+}
+
+func idempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// connectionError reports whether err happened before the request reached the server
+func connectionError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op == "dial"
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// RoundTrip sends the request, retrying it as described on Transport
+func (transport *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := transport.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	backoff := transport.Backoff
+	for attempt := 0; ; attempt++ {
+		// A RoundTripper must not modify the caller's request, so retries send a copy with a fresh body
+		try := req
+		if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return nil, fmt.Errorf("unable to retry the %s of %v, the request body cannot be rewound", req.Method, req.URL)
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			try = req.Clone(req.Context())
+			try.Body = body
+		}
+
+		cancel := context.CancelFunc(func() {})
+		if transport.Timeout > 0 {
+			var ctx context.Context
+			ctx, cancel = context.WithTimeout(req.Context(), transport.Timeout)
+			try = try.WithContext(ctx)
+		}
+
+		resp, err := base.RoundTrip(try)
+		if err != nil {
+			cancel()
+		} else {
+			resp.Body = cancelBody{resp.Body, cancel}
+		}
+		retry := false
+		if err != nil {
+			retry = idempotent(req.Method) || connectionError(err)
+		} else if idempotent(req.Method) && retryableStatus(resp.StatusCode) {
+			retry = true
+		}
+		if !retry || attempt >= transport.Retries {
+			if err != nil && attempt > 0 {
+				err = fmt.Errorf("gave up after %d attempts: %w", attempt+1, err)
+			}
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,160 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package httpretry
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TransportTestSuite struct {
+	suite.Suite
+}
+
+func testClient(retries int) *http.Client {
+	return &http.Client{Transport: &Transport{Retries: retries, Backoff: time.Millisecond}}
+}
+
+// flappingServer fails the first failures requests with a 503 and records every request body
+func flappingServer(failures int, bodies *[]string) *httptest.Server {
+	requests := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		*bodies = append(*bodies, string(body))
+		requests++
+		if requests <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("try again"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+}
+
+func (suite *TransportTestSuite) TestRoundTrip_RetriesGET() {
+	var bodies []string
+	server := flappingServer(2, &bodies)
+	defer server.Close()
+
+	resp, err := testClient(3).Get(server.URL)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Len(bodies, 3)
+}
+
+func (suite *TransportTestSuite) TestRoundTrip_ExhaustsRetries() {
+	var bodies []string
+	server := flappingServer(5, &bodies)
+	defer server.Close()
+
+	resp, err := testClient(2).Get(server.URL)
+	suite.NoError(err)
+	suite.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+	body, _ := ioutil.ReadAll(resp.Body)
+	suite.Equal("try again", string(body))
+	suite.Len(bodies, 3)
+}
+
+func (suite *TransportTestSuite) TestRoundTrip_PUTNotRetriedOnStatus() {
+	var bodies []string
+	server := flappingServer(1, &bodies)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL, bytes.NewBufferString("entry"))
+	resp, err := testClient(3).Do(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+	suite.Equal([]string{"entry"}, bodies)
+}
+
+func (suite *TransportTestSuite) TestRoundTrip_PUTRetriedOnConnectionError() {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	req, _ := http.NewRequest(http.MethodPut, url, bytes.NewBufferString("entry"))
+	_, err := testClient(2).Do(req)
+	suite.Error(err)
+	suite.Contains(err.Error(), "gave up after 3 attempts")
+}
+
+func (suite *TransportTestSuite) TestRoundTrip_RetryLeavesRequestUnchanged() {
+	var bodies []string
+	server := flappingServer(2, &bodies)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, bytes.NewBufferString("query"))
+	body := req.Body
+	resp, err := (&Transport{Retries: 3, Backoff: time.Millisecond}).RoundTrip(req)
+	suite.NoError(err)
+	resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal([]string{"query", "query", "query"}, bodies)
+	suite.True(body == req.Body)
+}
+
+func (suite *TransportTestSuite) TestRoundTrip_TimeoutStalledBody() {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := &http.Client{Transport: &Transport{Timeout: 50 * time.Millisecond}}
+	resp, err := client.Get(server.URL)
+	suite.NoError(err)
+	defer resp.Body.Close()
+	_, err = ioutil.ReadAll(resp.Body)
+	suite.ErrorIs(err, context.DeadlineExceeded)
+}
+
+func (suite *TransportTestSuite) TestRoundTrip_TimeoutRetried() {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{Retries: 1, Backoff: time.Millisecond, Timeout: 50 * time.Millisecond}}
+	resp, err := client.Get(server.URL)
+	suite.NoError(err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	suite.NoError(err)
+	suite.Equal("ok", string(body))
+	suite.Equal(2, requests)
+}
+
+func (suite *TransportTestSuite) TestNewClient_Options() {
+	transport := NewClient().Transport.(*Transport)
+	suite.Equal(DefaultTimeout, transport.Timeout)
+	suite.Equal(DefaultRetries, transport.Retries)
+
+	transport = NewClient(WithTimeout(5*time.Second), WithRetries(0)).Transport.(*Transport)
+	suite.Equal(5*time.Second, transport.Timeout)
+	suite.Equal(0, transport.Retries)
+}
+
+func TestTransportTestSuite(t *testing.T) {
+	suite.Run(t, new(TransportTestSuite))
+}
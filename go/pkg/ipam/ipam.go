@@ -12,6 +12,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/big"
 	"math/bits"
 	"net"
 	"reflect"
@@ -104,6 +105,72 @@ func Contains(network, subnet net.IPNet) bool {
 // Free takes a network, a mask, and a list of subnets.
 // An available network, within the first network, is returned.
 func Free(network net.IPNet, mask net.IPMask, subnets []net.IPNet) (net.IPNet, error) {
+	return free(network, mask, subnets, false)
+}
+
+// FreeFromTop is like Free, but returns the highest available network
+// within the first network instead of the lowest.
+func FreeFromTop(network net.IPNet, mask net.IPMask, subnets []net.IPNet) (net.IPNet, error) {
+	return free(network, mask, subnets, true)
+}
+
+// FreeBlocks takes a network and a list of subnets, which may overlap, and
+// returns the largest aligned networks that cover the space within the network
+// that none of the subnets use, from the bottom of the network up.
+// Only IPv4 subnets are considered.
+func FreeBlocks(network net.IPNet, subnets []net.IPNet) []net.IPNet {
+	start := ipToDecimal(network.IP.Mask(network.Mask))
+	end := start + size(network.Mask) - 1
+
+	used := IPNets{}
+	for _, subnet := range subnets {
+		if subnet.IP.To4() == nil {
+			continue
+		}
+		used = append(used, net.IPNet{IP: subnet.IP.Mask(subnet.Mask), Mask: subnet.Mask})
+	}
+	sort.Sort(used)
+
+	blocks := []net.IPNet{}
+	next := start
+	for _, subnet := range used {
+		subnetRange := NewIPRange(subnet)
+		subnetStart, subnetEnd := ipToDecimal(subnetRange.start), ipToDecimal(subnetRange.end)
+		if subnetEnd < next {
+			continue
+		}
+		if subnetStart > end {
+			break
+		}
+		if subnetStart > next {
+			blocks = append(blocks, rangeBlocks(next, subnetStart-1)...)
+		}
+		next = subnetEnd + 1
+	}
+	if next <= end {
+		blocks = append(blocks, rangeBlocks(next, end)...)
+	}
+	return blocks
+}
+
+// rangeBlocks splits the addresses first through last into the largest aligned networks.
+func rangeBlocks(first, last int) []net.IPNet {
+	blocks := []net.IPNet{}
+	for first <= last {
+		blockSize := 1 << 32
+		if first != 0 {
+			blockSize = first & -first
+		}
+		for blockSize > last-first+1 {
+			blockSize /= 2
+		}
+		blocks = append(blocks, net.IPNet{IP: decimalToIP(first), Mask: net.CIDRMask(32-bits.TrailingZeros(uint(blockSize)), 32)})
+		first += blockSize
+	}
+	return blocks
+}
+
+func free(network net.IPNet, mask net.IPMask, subnets []net.IPNet, fromTop bool) (net.IPNet, error) {
 	if size(network.Mask) < size(mask) {
 		return net.IPNet{},
 			fmt.Errorf("have: %v, requested: %v", network.Mask, mask)
@@ -125,7 +192,12 @@ func Free(network net.IPNet, mask net.IPMask, subnets []net.IPNet) (net.IPNet, e
 	}
 
 	// Attempt to find a free space, of the required size.
-	freeIP, err := space(freeIPRanges, mask)
+	var freeIP net.IP
+	if fromTop {
+		freeIP, err = spaceFromTop(freeIPRanges, mask)
+	} else {
+		freeIP, err = space(freeIPRanges, mask)
+	}
 	if err != nil {
 		return net.IPNet{}, err
 	}
@@ -344,6 +416,105 @@ func space(freeIPRanges []IPRange, mask net.IPMask) (net.IP, error) {
 	return nil, fmt.Errorf("tried to fit: %v", mask)
 }
 
+// spaceFromTop takes a list of free ip ranges, and a mask,
+// and returns the start IP of the highest aligned network that could fit the mask.
+func spaceFromTop(freeIPRanges []IPRange, mask net.IPMask) (net.IP, error) {
+	blockSize := size(mask)
+	for i := len(freeIPRanges) - 1; i >= 0; i-- {
+		start := ipToDecimal(freeIPRanges[i].start)
+		end := ipToDecimal(freeIPRanges[i].end)
+
+		// Align the last possible block in the range down to a multiple of the block size
+		candidate := (end + 1 - blockSize) &^ (blockSize - 1)
+		if candidate >= start {
+			return decimalToIP(candidate), nil
+		}
+	}
+
+	return nil, fmt.Errorf("tried to fit: %v", mask)
+}
+
+// Add6 increments the given IP by the number using 128-bit arithmetic.
+// e.g: Add6(fd00::, 1) -> fd00::1.
+// Negative values are allowed for decrementing.
+func Add6(ip net.IP, number int64) net.IP {
+	sum := new(big.Int).Add(ipToBig(ip), big.NewInt(number))
+	return bigToIP(sum)
+}
+
+// Broadcast6 takes a net.IPNet and returns its last address as net.IP using 128-bit arithmetic
+func Broadcast6(network net.IPNet) net.IP {
+	return bigToIP(new(big.Int).Add(ipToBig(network.IP), new(big.Int).Sub(size6(network.Mask), big.NewInt(1))))
+}
+
+// Free6 is like Free, but uses 128-bit arithmetic so it can allocate IPv6 subnets.
+func Free6(network net.IPNet, mask net.IPMask, subnets []net.IPNet) (net.IPNet, error) {
+	if size6(network.Mask).Cmp(size6(mask)) < 0 {
+		return net.IPNet{},
+			fmt.Errorf("have: %v, requested: %v", network.Mask, mask)
+	}
+
+	for _, subnet := range subnets {
+		if !network.Contains(subnet.IP) {
+			return net.IPNet{},
+				fmt.Errorf("%v is not contained by %v", subnet.IP, network)
+		}
+	}
+
+	sorted := append([]net.IPNet{}, subnets...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return ipToBig(sorted[i].IP).Cmp(ipToBig(sorted[j].IP)) < 0
+	})
+
+	one := big.NewInt(1)
+	blockSize := size6(mask)
+	networkEnd := ipToBig(Broadcast6(network))
+	candidate := ipToBig(network.IP)
+	for _, subnet := range sorted {
+		candidate = alignUp6(candidate, blockSize)
+		candidateEnd := new(big.Int).Sub(new(big.Int).Add(candidate, blockSize), one)
+		if candidateEnd.Cmp(ipToBig(subnet.IP)) < 0 {
+			break
+		}
+		if subnetEnd := new(big.Int).Add(ipToBig(Broadcast6(subnet)), one); subnetEnd.Cmp(candidate) > 0 {
+			candidate = subnetEnd
+		}
+	}
+	candidate = alignUp6(candidate, blockSize)
+
+	if new(big.Int).Sub(new(big.Int).Add(candidate, blockSize), one).Cmp(networkEnd) > 0 {
+		return net.IPNet{}, fmt.Errorf("tried to fit: %v", mask)
+	}
+	return net.IPNet{IP: bigToIP(candidate), Mask: mask}, nil
+}
+
+// alignUp6 rounds ip up to the next multiple of blockSize
+func alignUp6(ip, blockSize *big.Int) *big.Int {
+	remainder := new(big.Int).Mod(ip, blockSize)
+	if remainder.Sign() == 0 {
+		return ip
+	}
+	return new(big.Int).Add(ip, new(big.Int).Sub(blockSize, remainder))
+}
+
+// ipToBig converts a net.IP to a 128-bit integer.
+func ipToBig(ip net.IP) *big.Int {
+	return new(big.Int).SetBytes(ip.To16())
+}
+
+// bigToIP converts a 128-bit integer to a net.IP.
+func bigToIP(ip *big.Int) net.IP {
+	t := make(net.IP, net.IPv6len)
+	ip.FillBytes(t)
+	return t
+}
+
+// size6 takes a mask, and returns the number of addresses as a 128-bit integer.
+func size6(mask net.IPMask) *big.Int {
+	ones, bits := mask.Size()
+	return new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+}
+
 // SubnetWithin returns the smallest subnet than can contain (size) hosts
 func SubnetWithin(network net.IPNet, hostNumber int) (net.IPNet, error) {
 	var n net.IPNet
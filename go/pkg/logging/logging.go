@@ -0,0 +1,150 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package logging
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Levels are the accepted values of log-level, from least to most verbose
+var Levels = []string{"error", "warn", "info", "debug"}
+
+// Formats are the accepted values of log-format
+var Formats = []string{"text", "json"}
+
+const (
+	// DefaultLevel is used when log-level is not set
+	DefaultLevel = "info"
+	// DefaultFormat is used when log-format is not set
+	DefaultFormat = "text"
+)
+
+var logger = mustNew(DefaultLevel, DefaultFormat, os.Stderr)
+
+// summary is where Summaryf writes, it is discarded in silent mode
+var summary io.Writer = os.Stdout
+
+// New creates a logger that writes messages at or above level to w in the requested format
+func New(level, format string, w io.Writer) (*zap.Logger, error) {
+	var zapLevel zapcore.Level
+	if !stringInSlice(level, Levels) || zapLevel.UnmarshalText([]byte(level)) != nil {
+		return nil, fmt.Errorf("invalid log-level %q, must be one of %s", level, strings.Join(Levels, ", "))
+	}
+
+	var encoder zapcore.Encoder
+	switch format {
+	case "text":
+		config := zap.NewDevelopmentEncoderConfig()
+		config.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(config)
+	case "json":
+		encoder = zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	default:
+		return nil, fmt.Errorf("invalid log-format %q, must be one of %s", format, strings.Join(Formats, ", "))
+	}
+	return zap.New(zapcore.NewCore(encoder, zapcore.AddSync(w), zapLevel)), nil
+}
+
+func mustNew(level, format string, w io.Writer) *zap.Logger {
+	l, err := New(level, format, w)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// Configure replaces the shared logger with one at the requested level and format writing to stderr
+func Configure(level, format string) error {
+	l, err := New(level, format, os.Stderr)
+	if err != nil {
+		return err
+	}
+	SetLogger(l)
+	return nil
+}
+
+// ConfigureFromViper configures the shared logger from the log-level and log-format settings.  quiet drops the
+// progress messages by raising the level to at least warn, and silent also drops the Summaryf output.  Warnings
+// and errors are always logged.
+func ConfigureFromViper(v *viper.Viper) error {
+	level := DefaultLevel
+	if v.IsSet("log-level") {
+		level = strings.ToLower(v.GetString("log-level"))
+	}
+	if (v.GetBool("quiet") || v.GetBool("silent")) && (level == "info" || level == "debug") {
+		level = "warn"
+	}
+	format := DefaultFormat
+	if v.IsSet("log-format") {
+		format = strings.ToLower(v.GetString("log-format"))
+	}
+	if err := Configure(level, format); err != nil {
+		return err
+	}
+	if v.GetBool("silent") {
+		SetSummaryWriter(ioutil.Discard)
+	} else {
+		SetSummaryWriter(os.Stdout)
+	}
+	return nil
+}
+
+// SetSummaryWriter replaces where Summaryf writes
+func SetSummaryWriter(w io.Writer) {
+	summary = w
+}
+
+// SetLogger replaces the shared logger
+func SetLogger(l *zap.Logger) {
+	logger = l
+}
+
+// Logger returns the shared logger, e.g. for csi.NewSLSStateGenerator
+func Logger() *zap.Logger {
+	return logger
+}
+
+// Debugf logs a diagnostic message that is only of interest when debugging
+func Debugf(format string, args ...interface{}) {
+	logger.Sugar().Debugf(format, args...)
+}
+
+// Infof logs a progress message
+func Infof(format string, args ...interface{}) {
+	logger.Sugar().Infof(format, args...)
+}
+
+// Summaryf prints the final result of a command.  It is not a log message, so it survives quiet mode and is
+// only dropped in silent mode.
+func Summaryf(format string, args ...interface{}) {
+	fmt.Fprintf(summary, format+"\n", args...)
+}
+
+// Warnf logs a problem that does not stop the command
+func Warnf(format string, args ...interface{}) {
+	logger.Sugar().Warnf(format, args...)
+}
+
+// Errorf logs a problem that stops the command, the caller decides whether to exit
+func Errorf(format string, args ...interface{}) {
+	logger.Sugar().Errorf(format, args...)
+}
+
+func stringInSlice(a string, list []string) bool {
+	for _, b := range list {
+		if b == a {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap/zapcore"
+)
+
+type LoggingTestSuite struct {
+	suite.Suite
+}
+
+func (suite *LoggingTestSuite) TestNew_JSONLevel() {
+	var out bytes.Buffer
+	l, err := New("warn", "json", &out)
+	suite.NoError(err)
+	defer SetLogger(Logger())
+	SetLogger(l)
+
+	Debugf("Generating Subnets for %s", "NMN")
+	Infof("wrote %d bytes to %s", 10, "data.json")
+	Warnf("site-gw was not provided")
+	Errorf("unable to continue")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	suite.Len(lines, 2)
+	var entry map[string]interface{}
+	suite.NoError(json.Unmarshal([]byte(lines[0]), &entry))
+	suite.Equal("warn", entry["level"])
+	suite.Equal("site-gw was not provided", entry["msg"])
+	suite.NoError(json.Unmarshal([]byte(lines[1]), &entry))
+	suite.Equal("error", entry["level"])
+}
+
+func (suite *LoggingTestSuite) TestNew_Text() {
+	var out bytes.Buffer
+	l, err := New("debug", "text", &out)
+	suite.NoError(err)
+	l.Sugar().Debugf("Building Network for %s", "HMN")
+	suite.Contains(out.String(), "DEBUG\tBuilding Network for HMN")
+}
+
+func (suite *LoggingTestSuite) TestNew_Invalid() {
+	_, err := New("verbose", "text", &bytes.Buffer{})
+	suite.Equal(errors.New(`invalid log-level "verbose", must be one of error, warn, info, debug`), err)
+
+	_, err = New("info", "xml", &bytes.Buffer{})
+	suite.Equal(errors.New(`invalid log-format "xml", must be one of text, json`), err)
+}
+
+func (suite *LoggingTestSuite) TestConfigureFromViper_Quiet() {
+	defer SetLogger(Logger())
+	defer SetSummaryWriter(os.Stdout)
+
+	v := viper.New()
+	v.Set("quiet", true)
+	suite.NoError(ConfigureFromViper(v))
+	suite.False(Logger().Core().Enabled(zapcore.InfoLevel))
+	suite.True(Logger().Core().Enabled(zapcore.WarnLevel))
+	suite.True(Logger().Core().Enabled(zapcore.ErrorLevel))
+
+	var out bytes.Buffer
+	SetSummaryWriter(&out)
+	Summaryf("wrote %d networks", 3)
+	suite.Equal("wrote 3 networks\n", out.String())
+
+	v.Set("log-level", "error")
+	suite.NoError(ConfigureFromViper(v))
+	suite.False(Logger().Core().Enabled(zapcore.WarnLevel))
+}
+
+func (suite *LoggingTestSuite) TestConfigureFromViper_Silent() {
+	defer SetLogger(Logger())
+	defer SetSummaryWriter(os.Stdout)
+
+	v := viper.New()
+	v.Set("silent", true)
+	suite.NoError(ConfigureFromViper(v))
+	suite.False(Logger().Core().Enabled(zapcore.InfoLevel))
+	suite.True(Logger().Core().Enabled(zapcore.ErrorLevel))
+	suite.Equal(ioutil.Discard, summary)
+}
+
+func TestLoggingTestSuite(t *testing.T) {
+	suite.Run(t, new(LoggingTestSuite))
+}
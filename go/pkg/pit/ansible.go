@@ -0,0 +1,92 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"fmt"
+	"text/template"
+
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+	"github.com/Cray-HPE/csm-common/go/pkg/csi"
+)
+
+// AnsibleInventoryTemplate manages an INI Ansible inventory of the NCNs
+var AnsibleInventoryTemplate = []byte(`{{- range .}}[{{.Name}}]
+{{- range .Hosts}}
+{{.Name}} ansible_host={{.Host}} xname={{.Xname}}
+{{- end}}
+
+{{end -}}
+[ncn:children]
+{{- range .}}
+{{.Name}}
+{{- end}}
+`)
+
+// AnsibleInventoryGroups maps the NCN subroles to their Ansible inventory group, in the order they are written
+var AnsibleInventoryGroups = []struct {
+	Subrole string
+	Group   string
+}{
+	{"Master", "masters"},
+	{"Worker", "workers"},
+	{"Storage", "storage"},
+}
+
+// AnsibleHost is a single host entry with its host vars
+type AnsibleHost struct {
+	Name  string
+	Host  string
+	Xname string
+}
+
+// AnsibleGroup is a group of hosts in the inventory
+type AnsibleGroup struct {
+	Name  string
+	Hosts []AnsibleHost
+}
+
+// MakeAnsibleInventory groups the NCNs by subrole with the address of their NMN bootstrap_dhcp reservation
+func MakeAnsibleInventory(ncns []csi.LogicalNCN, shastaNetworks map[string]*csi.IPV4Network) ([]AnsibleGroup, error) {
+	nmnNetwork, ok := shastaNetworks["NMN"]
+	if !ok {
+		return nil, fmt.Errorf("couldn't find the NMN network")
+	}
+	nmnSubnet, err := nmnNetwork.LookUpSubnet("bootstrap_dhcp")
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []AnsibleGroup
+	for _, inventoryGroup := range AnsibleInventoryGroups {
+		group := AnsibleGroup{Name: inventoryGroup.Group}
+		for _, ncn := range ncns {
+			if ncn.Subrole != inventoryGroup.Subrole {
+				continue
+			}
+			rsrv := nmnSubnet.LookupReservation(ncn.Hostname)
+			if rsrv.IPAddress == nil {
+				return nil, fmt.Errorf("couldn't find an NMN reservation for %s", ncn.Hostname)
+			}
+			group.Hosts = append(group.Hosts, AnsibleHost{
+				Name:  ncn.Hostname,
+				Host:  rsrv.IPAddress.String(),
+				Xname: ncn.Xname,
+			})
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// WriteAnsibleInventory writes an INI Ansible inventory with a group for each NCN subrole
+func WriteAnsibleInventory(path string, ncns []csi.LogicalNCN, shastaNetworks map[string]*csi.IPV4Network) error {
+	groups, err := MakeAnsibleInventory(ncns, shastaNetworks)
+	if err != nil {
+		return err
+	}
+	tpl, _ := template.New("ansibleinventory").Parse(string(AnsibleInventoryTemplate))
+	return csiFiles.WriteTemplate(path, tpl, groups)
+}
@@ -0,0 +1,71 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+
+	"github.com/Cray-HPE/csm-common/go/pkg/csi"
+	"github.com/stretchr/testify/suite"
+)
+
+type AnsibleInventoryTestSuite struct {
+	suite.Suite
+}
+
+func testAnsibleNCNs(networks map[string]*csi.IPV4Network) []csi.LogicalNCN {
+	nmnSubnet, _ := networks["NMN"].LookUpSubnet("bootstrap_dhcp")
+	nmnSubnet.AddReservationWithIP("ncn-w001", "10.252.1.13", "x3000c0s7b0n0")
+	nmnSubnet.AddReservationWithIP("ncn-s001", "10.252.1.16", "x3000c0s13b0n0")
+	return []csi.LogicalNCN{
+		{Xname: "x3000c0s1b0n0", Hostname: "ncn-m001", Subrole: "Master"},
+		{Xname: "x3000c0s7b0n0", Hostname: "ncn-w001", Subrole: "Worker"},
+		{Xname: "x3000c0s13b0n0", Hostname: "ncn-s001", Subrole: "Storage"},
+	}
+}
+
+func (suite *AnsibleInventoryTestSuite) TestMakeAnsibleInventory() {
+	networks := testNMNNetworks()
+	groups, err := MakeAnsibleInventory(testAnsibleNCNs(networks), networks)
+	suite.NoError(err)
+	suite.Equal([]AnsibleGroup{
+		{Name: "masters", Hosts: []AnsibleHost{{Name: "ncn-m001", Host: "10.252.1.10", Xname: "x3000c0s1b0n0"}}},
+		{Name: "workers", Hosts: []AnsibleHost{{Name: "ncn-w001", Host: "10.252.1.13", Xname: "x3000c0s7b0n0"}}},
+		{Name: "storage", Hosts: []AnsibleHost{{Name: "ncn-s001", Host: "10.252.1.16", Xname: "x3000c0s13b0n0"}}},
+	}, groups)
+
+	var bs bytes.Buffer
+	tpl, _ := template.New("ansibleinventory").Parse(string(AnsibleInventoryTemplate))
+	suite.NoError(tpl.Execute(&bs, groups))
+	suite.Equal(`[masters]
+ncn-m001 ansible_host=10.252.1.10 xname=x3000c0s1b0n0
+
+[workers]
+ncn-w001 ansible_host=10.252.1.13 xname=x3000c0s7b0n0
+
+[storage]
+ncn-s001 ansible_host=10.252.1.16 xname=x3000c0s13b0n0
+
+[ncn:children]
+masters
+workers
+storage
+`, bs.String())
+}
+
+func (suite *AnsibleInventoryTestSuite) TestMakeAnsibleInventoryMissingReservation() {
+	ncns := []csi.LogicalNCN{{Xname: "x3000c0s9b0n0", Hostname: "ncn-w002", Subrole: "Worker"}}
+	_, err := MakeAnsibleInventory(ncns, testNMNNetworks())
+	suite.EqualError(err, "couldn't find an NMN reservation for ncn-w002")
+}
+
+func TestAnsibleInventoryTestSuite(t *testing.T) {
+	suite.Run(t, new(AnsibleInventoryTestSuite))
+}
@@ -26,12 +26,14 @@ package pit
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"strconv"
 	"strings"
 
 	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
 	"github.com/Cray-HPE/csm-common/go/pkg/csi"
+	"github.com/Cray-HPE/csm-common/go/pkg/logging"
 	"github.com/spf13/viper"
 )
 
@@ -76,6 +78,7 @@ type NtpModule struct {
 // WriteFiles enables use of the cloud-init write_files module
 type WriteFiles struct {
 	Content     string `json:"content"`
+	Encoding    string `json:"encoding,omitempty"`
 	Owner       string `json:"owner"`
 	Path        string `json:"path"`
 	Permissions string `json:"permissions"`
@@ -112,9 +115,9 @@ type BaseCampGlobals struct {
 }
 
 // Basecamp Defaults
-// We should try to make these customizable by the user at some point
+// These can be replaced with a runcmd-config file, see RunCMDConfig
 // k8sRunCMD has the list of scripts to run on NCN boot for
-// all members of the kubernetes cluster
+// the members of the kubernetes cluster with any other subrole
 var k8sRunCMD = []string{
 	"/srv/cray/scripts/metal/net-init.sh",
 	"/srv/cray/scripts/common/update_ca_certs.py",
@@ -124,6 +127,18 @@ var k8sRunCMD = []string{
 	"touch /etc/cloud/cloud-init.disabled",
 }
 
+// etcdRunCMD bootstraps the etcd member of a kubernetes master, the first master
+// (first-master-hostname) starts the cluster and the others join it
+const etcdRunCMD = "/srv/cray/scripts/common/etcd-cloudinit.sh"
+
+// masterRunCMD has the list of scripts to run on NCN boot for
+// the kubernetes masters, k8sRunCMD with etcd brought up before kubernetes
+var masterRunCMD = insertRunCMD(k8sRunCMD, "/srv/cray/scripts/common/kubernetes-cloudinit.sh", etcdRunCMD)
+
+// workerRunCMD has the list of scripts to run on NCN boot for
+// the kubernetes workers
+var workerRunCMD = k8sRunCMD
+
 // cephRunCMD has the list of scripts to run on NCN boot for
 // FIXME: MTL-1294 replace these with real usages of cloud-init when appropriate (some scripts may be necessary).
 // the first Ceph member which is responsible for installing the others
@@ -147,6 +162,23 @@ var cephWorkerRunCMD = []string{
 	"touch /etc/cloud/cloud-init.disabled",
 }
 
+// insertRunCMD returns a copy of runCMD with command inserted before the before command, or appended
+// when runCMD does not contain it
+func insertRunCMD(runCMD []string, before, command string) []string {
+	inserted := make([]string, 0, len(runCMD)+1)
+	for i, existing := range runCMD {
+		if existing == before {
+			inserted = append(append(inserted, command), runCMD[i:]...)
+			return inserted
+		}
+		inserted = append(inserted, existing)
+	}
+	return append(inserted, command)
+}
+
+// MinimumStorageNodes is the smallest number of storage NCNs ceph is deployed on
+const MinimumStorageNodes = 3
+
 // Make sure any "FIXME" added to this is updated in the MakeBasecampGlobals function below
 var basecampGlobalString = `{
 	"ceph-cephfs-image": "dtr.dev.cray.com/cray/cray-cephfs-provisioner:0.1.0-nautilus-1.3",
@@ -175,7 +207,7 @@ type BasecampHostRecord struct {
 }
 
 // MakeBasecampHostRecords uses the ncns to generate a list of host ips and their names for use in /etc/hosts
-func MakeBasecampHostRecords(ncns []csi.LogicalNCN, shastaNetworks map[string]*csi.IPV4Network, installNCN string) interface{} {
+func MakeBasecampHostRecords(v *viper.Viper, ncns []csi.LogicalNCN, shastaNetworks map[string]*csi.IPV4Network, installNCN string) interface{} {
 	var hostrecords []BasecampHostRecord
 	hmnNetwork, _ := shastaNetworks["HMN"].LookUpSubnet("bootstrap_dhcp")
 	for _, ncn := range ncns {
@@ -202,13 +234,36 @@ func MakeBasecampHostRecords(ncns []csi.LogicalNCN, shastaNetworks map[string]*c
 	k8sres := nmnNetwork.ReservationsByName()["kubeapi-vip"]
 	hostrecords = append(hostrecords, BasecampHostRecord{k8sres.IPAddress.String(), []string{k8sres.Name, fmt.Sprintf("%s.nmn", k8sres.Name)}})
 
-	rgwres := nmnNetwork.ReservationsByName()["rgw-vip"]
-	hostrecords = append(hostrecords, BasecampHostRecord{rgwres.IPAddress.String(), []string{rgwres.Name, fmt.Sprintf("%s.nmn", rgwres.Name)}})
+	// the rgw-vip may be reserved on several networks, only the primary one answers to the bare name
+	for i, rgwNetwork := range csi.RGWVIPNetworks(v) {
+		rgwres, found := lookupRGWVIP(shastaNetworks, rgwNetwork)
+		if !found {
+			continue
+		}
+		aliases := []string{fmt.Sprintf("%s.%s", rgwres.Name, strings.ToLower(rgwNetwork))}
+		if i == 0 {
+			aliases = append([]string{rgwres.Name}, aliases...)
+		}
+		hostrecords = append(hostrecords, BasecampHostRecord{rgwres.IPAddress.String(), aliases})
+	}
 
 	// using installNCN value as the host that pit.nmn will point to
 	pitres := nmnNetwork.ReservationsByName()[installNCN]
 	hostrecords = append(hostrecords, BasecampHostRecord{pitres.IPAddress.String(), []string{"pit", "pit.nmn"}})
 
+	// the PIT is reachable on every other network the installNCN participates in as pit.<net>
+	for _, ncn := range ncns {
+		if ncn.Hostname != installNCN {
+			continue
+		}
+		for _, iface := range ncn.Networks {
+			if iface.NetworkName == "NMN" || iface.IPAddress == "" {
+				continue
+			}
+			hostrecords = append(hostrecords, BasecampHostRecord{iface.IPAddress, []string{fmt.Sprintf("pit.%s", strings.ToLower(iface.NetworkName))}})
+		}
+	}
+
 	// adding packages.local and registry.local that point to api-gw to the host_records object
 	apigwres := nmnLbNetwork.ReservationsByName()["istio-ingressgateway"]
 	hostrecords = append(hostrecords, BasecampHostRecord{apigwres.IPAddress.String(), []string{"packages.local", "registry.local"}})
@@ -223,6 +278,20 @@ func MakeBasecampHostRecords(ncns []csi.LogicalNCN, shastaNetworks map[string]*c
 	return hostrecords
 }
 
+// lookupRGWVIP finds the rgw-vip reservation in the bootstrap_dhcp subnet of the named network
+func lookupRGWVIP(shastaNetworks map[string]*csi.IPV4Network, networkName string) (csi.IPReservation, bool) {
+	network, ok := shastaNetworks[networkName]
+	if !ok {
+		return csi.IPReservation{}, false
+	}
+	subnet, err := network.LookUpSubnet("bootstrap_dhcp")
+	if err != nil {
+		return csi.IPReservation{}, false
+	}
+	rgwres, ok := subnet.ReservationsByName()["rgw-vip"]
+	return rgwres, ok
+}
+
 // unique de-dupes an array of string
 func unique(arr []string) []string {
 	occured := map[string]bool{}
@@ -264,7 +333,7 @@ func MakeBasecampGlobals(v *viper.Viper, logicalNcns []csi.LogicalNCN, shastaNet
 	// Our install takes place on the nmn.  We'll need that subnet for several values
 	tempSubnet := shastaNetworks[installNetwork].SubnetbyName(installSubnet)
 	if tempSubnet.Name == "" {
-		log.Fatalf("Couldn't find a '%v' subnet in the %v network for generating basecamp's data.json.  Install is doomed.", installSubnet, installNetwork)
+		return global, fmt.Errorf("couldn't find a '%v' subnet in the %v network for generating basecamp's data.json", installSubnet, installNetwork)
 	}
 	reservations := tempSubnet.ReservationsByName()
 	var ncns []string
@@ -286,9 +355,14 @@ func MakeBasecampGlobals(v *viper.Viper, logicalNcns []csi.LogicalNCN, shastaNet
 
 	// "k8s-virtual-ip" is the nmn alias for k8s
 	global["k8s-virtual-ip"] = reservations["kubeapi-vip"].IPAddress.String()
-	global["rgw-virtual-ip"] = reservations["rgw-vip"].IPAddress.String()
+	rgwNetwork := csi.RGWVIPNetworks(v)[0]
+	rgwres, found := lookupRGWVIP(shastaNetworks, rgwNetwork)
+	if !found {
+		return global, fmt.Errorf("couldn't find an rgw-vip reservation in the bootstrap_dhcp subnet of the %v network for generating basecamp's data.json", rgwNetwork)
+	}
+	global["rgw-virtual-ip"] = rgwres.IPAddress.String()
 
-	global["host_records"] = MakeBasecampHostRecords(logicalNcns, shastaNetworks, installNCN)
+	global["host_records"] = MakeBasecampHostRecords(v, logicalNcns, shastaNetworks, installNCN)
 	// start storage count at zero
 	var s = 0
 	for _, ncn := range logicalNcns {
@@ -298,6 +372,11 @@ func MakeBasecampGlobals(v *viper.Viper, logicalNcns []csi.LogicalNCN, shastaNet
 		}
 	}
 	global["num_storage_nodes"] = s
+	// ceph is configured for the storage nodes actually present rather than the usual three
+	if s < MinimumStorageNodes {
+		logging.Warnf("Only %d storage NCNs were found, ceph expects at least %d", s, MinimumStorageNodes)
+	}
+	global["ceph-num-storage-nodes"] = strconv.Itoa(s)
 
 	global["first-master-hostname"] = v.GetString("first-master-hostname")
 
@@ -308,7 +387,7 @@ func MakeBasecampGlobals(v *viper.Viper, logicalNcns []csi.LogicalNCN, shastaNet
 // Add a route from the MTL bootstrap network to the NMN network via bond0.nmn.
 // Lastly, add the HMN/NMN k8s routes
 // Format for ifroute-<interface> files
-func getNCNStaticRoutes(v *viper.Viper, shastaNetworks map[string]*csi.IPV4Network) []WriteFiles {
+func getNCNStaticRoutes(v *viper.Viper, shastaNetworks map[string]*csi.IPV4Network) ([]WriteFiles, error) {
 	var nmnGateway string
 	var hmnGateway string
 	var ifrouteNMN bytes.Buffer
@@ -345,7 +424,7 @@ func getNCNStaticRoutes(v *viper.Viper, shastaNetworks map[string]*csi.IPV4Netwo
 
 	// we should always have routes at this point
 	if ifrouteNMN.Len() == 0 || ifrouteHMN.Len() == 0 {
-		log.Panic("Error generating routes")
+		return nil, errors.New("error generating routes, no NMN or HMN cabinet routes were found")
 	}
 
 	// add k8s routes
@@ -366,7 +445,7 @@ func getNCNStaticRoutes(v *viper.Viper, shastaNetworks map[string]*csi.IPV4Netwo
 			Permissions: "0644",
 		},
 	}
-	return writeFiles
+	return writeFiles, nil
 }
 
 // MakeBaseCampfromNCNs uses ncns and networks to create the basecamp config
@@ -374,10 +453,27 @@ func MakeBaseCampfromNCNs(v *viper.Viper, ncns []csi.LogicalNCN, shastaNetworks
 	basecampConfig := make(map[string]CloudInit)
 	uaiMacvlanSubnet, err := shastaNetworks["NMN"].LookUpSubnet("uai_macvlan")
 	if err != nil {
-		log.Fatal("basecamp_gen: Couldn't find the macvlan subnet in the NMN")
+		return basecampConfig, fmt.Errorf("basecamp_gen: couldn't find the macvlan subnet in the NMN: %v", err)
 	}
 	uaiReservations := uaiMacvlanSubnet.ReservationsByName()
-	writeFiles := getNCNStaticRoutes(v, shastaNetworks)
+	writeFiles, err := getNCNStaticRoutes(v, shastaNetworks)
+	if err != nil {
+		return basecampConfig, err
+	}
+	var siteWriteFiles []SiteWriteFile
+	if v.GetString("write-files-config") != "" {
+		siteWriteFiles, err = LoadSiteWriteFiles(v.GetString("write-files-config"))
+		if err != nil {
+			return basecampConfig, err
+		}
+	}
+	runCMDConfig := DefaultRunCMDConfig()
+	if v.GetString("runcmd-config") != "" {
+		runCMDConfig, err = LoadRunCMDConfig(v.GetString("runcmd-config"))
+		if err != nil {
+			return basecampConfig, err
+		}
+	}
 
 	for _, ncn := range ncns {
 		mac0Interface := make(map[string]interface{})
@@ -386,7 +482,7 @@ func MakeBaseCampfromNCNs(v *viper.Viper, ncns []csi.LogicalNCN, shastaNetworks
 		mac0Interface["gateway"] = uaiMacvlanSubnet.Gateway
 		tempAvailabilityZone, err := csi.CabinetForXname(ncn.Xname)
 		if err != nil {
-			log.Printf("Couldn't generate cabinet name for %v: %v \n", ncn.Xname, err)
+			logging.Warnf("Couldn't generate cabinet name for %v: %v", ncn.Xname, err)
 		}
 		ncnIPAM := make(map[string]interface{})
 		for _, ncnNetwork := range ncn.Networks {
@@ -421,15 +517,7 @@ func MakeBaseCampfromNCNs(v *viper.Viper, ncns []csi.LogicalNCN, shastaNetworks
 			IPAM:             ncnIPAM,
 		}
 		userDataMap := make(map[string]interface{})
-		if ncn.Subrole == "Storage" {
-			if strings.HasSuffix(ncn.Hostname, "001") {
-				userDataMap["runcmd"] = cephRunCMD
-			} else {
-				userDataMap["runcmd"] = cephWorkerRunCMD
-			}
-		} else {
-			userDataMap["runcmd"] = k8sRunCMD
-		}
+		userDataMap["runcmd"] = runCMDConfig.RunCMDForNCN(ncn.Role, ncn.Subrole, ncn.Hostname)
 		userDataMap["hostname"] = ncn.Hostname
 		userDataMap["local_hostname"] = ncn.Hostname
 		userDataMap["mac0"] = mac0Interface
@@ -485,8 +573,9 @@ func MakeBaseCampfromNCNs(v *viper.Viper, ncns []csi.LogicalNCN, shastaNetworks
 
 		userDataMap["ntp"] = ntpModule
 
-		if writeFiles != nil {
-			userDataMap["write_files"] = writeFiles
+		ncnWriteFiles := append(append([]WriteFiles{}, writeFiles...), SiteWriteFilesForSubrole(siteWriteFiles, ncn.Subrole)...)
+		if len(ncnWriteFiles) > 0 {
+			userDataMap["write_files"] = ncnWriteFiles
 		}
 	}
 
@@ -494,11 +583,18 @@ func MakeBaseCampfromNCNs(v *viper.Viper, ncns []csi.LogicalNCN, shastaNetworks
 }
 
 // WriteBasecampData writes basecamp data.json for the installer
-func WriteBasecampData(path string, ncns []csi.LogicalNCN, shastaNetworks map[string]*csi.IPV4Network, globals interface{}) {
+func WriteBasecampData(path string, ncns []csi.LogicalNCN, shastaNetworks map[string]*csi.IPV4Network, globals interface{}) error {
 	v := viper.GetViper()
 	basecampConfig, err := MakeBaseCampfromNCNs(v, ncns, shastaNetworks)
 	if err != nil {
-		log.Printf("Error extracting NCNs: %v", err)
+		return fmt.Errorf("error extracting NCNs: %v", err)
+	}
+	if errs := ValidateBasecampConfig(basecampConfig); len(errs) > 0 {
+		var problems []string
+		for _, err := range errs {
+			problems = append(problems, err.Error())
+		}
+		return fmt.Errorf("invalid cloud-init data:\n%s", strings.Join(problems, "\n"))
 	}
 	// To write this the way we want to consume it, we need to convert it to a map of strings and interfaces
 	data := make(map[string]interface{})
@@ -511,9 +607,9 @@ func WriteBasecampData(path string, ncns []csi.LogicalNCN, shastaNetworks map[st
 
 	err = csiFiles.WriteJSONConfig(path, data)
 	if err != nil {
-		log.Printf("Error writing data.json: %v", err)
+		return fmt.Errorf("error writing data.json: %v", err)
 	}
-
+	return nil
 }
 
 func stringInSlice(a string, list []string) bool {
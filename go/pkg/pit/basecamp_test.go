@@ -0,0 +1,357 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Cray-HPE/csm-common/go/pkg/csi"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+)
+
+type BasecampTestSuite struct {
+	suite.Suite
+}
+
+func testSubnet(name, cidr string) *csi.IPV4Subnet {
+	_, subnetNet, _ := net.ParseCIDR(cidr)
+	return &csi.IPV4Subnet{Name: name, CIDR: *subnetNet}
+}
+
+func testBasecampNetworks() map[string]*csi.IPV4Network {
+	nmnBootstrap := testSubnet("bootstrap_dhcp", "10.252.1.0/24")
+	nmnBootstrap.AddReservationWithIP("ncn-m001", "10.252.1.10", "x3000c0s1b0n0")
+	nmnBootstrap.AddReservationWithIP("kubeapi-vip", "10.252.1.2", "k8s-virtual-ip")
+	nmnBootstrap.AddReservationWithIP("rgw-vip", "10.252.1.3", "rgw-virtual-ip")
+
+	hmnBootstrap := testSubnet("bootstrap_dhcp", "10.254.1.0/24")
+	hmnBootstrap.AddReservationWithIP("ncn-m001-mgmt", "10.254.1.4", "x3000c0s1b0")
+
+	metallb := testSubnet("nmn_metallb_address_pool", "10.92.100.0/24")
+	metallb.AddReservationWithIP("istio-ingressgateway", "10.92.100.71", "api-gw-service")
+
+	return map[string]*csi.IPV4Network{
+		"NMN":   {Name: "NMN", CIDR: "10.252.0.0/17", Subnets: []*csi.IPV4Subnet{nmnBootstrap}},
+		"HMN":   {Name: "HMN", CIDR: "10.254.0.0/17", Subnets: []*csi.IPV4Subnet{hmnBootstrap, testSubnet("network_hardware", "10.254.0.0/24")}},
+		"NMNLB": {Name: "NMNLB", CIDR: "10.92.100.0/24", Subnets: []*csi.IPV4Subnet{metallb}},
+	}
+}
+
+func (suite *BasecampTestSuite) TestMakeBasecampHostRecords_PITAliases() {
+	ncns := []csi.LogicalNCN{{
+		Xname:    "x3000c0s1b0n0",
+		Hostname: "ncn-m001",
+		Networks: []csi.NCNNetwork{
+			{NetworkName: "NMN", IPAddress: "10.252.1.10"},
+			{NetworkName: "HMN", IPAddress: "10.254.1.10"},
+			{NetworkName: "CAN", IPAddress: "10.102.11.10"},
+		},
+	}}
+
+	hostrecords := MakeBasecampHostRecords(viper.New(), ncns, testBasecampNetworks(), "ncn-m001").([]BasecampHostRecord)
+	suite.Contains(hostrecords, BasecampHostRecord{"10.252.1.10", []string{"pit", "pit.nmn"}})
+	suite.Contains(hostrecords, BasecampHostRecord{"10.254.1.10", []string{"pit.hmn"}})
+	suite.Contains(hostrecords, BasecampHostRecord{"10.102.11.10", []string{"pit.can"}})
+}
+
+func (suite *BasecampTestSuite) TestMakeBasecampHostRecords_RGWVIPOnCAN() {
+	v := viper.New()
+	v.Set("rgw-vip-networks", []string{"CAN", "NMN"})
+
+	networks := testBasecampNetworks()
+	canBootstrap := testSubnet("bootstrap_dhcp", "10.102.11.0/24")
+	canBootstrap.AddReservationWithIP("rgw-vip", "10.102.11.3", "rgw-virtual-ip")
+	networks["CAN"] = &csi.IPV4Network{Name: "CAN", CIDR: "10.102.11.0/24", Subnets: []*csi.IPV4Subnet{canBootstrap}}
+
+	hostrecords := MakeBasecampHostRecords(v, nil, networks, "ncn-m001").([]BasecampHostRecord)
+	suite.Contains(hostrecords, BasecampHostRecord{"10.102.11.3", []string{"rgw-vip", "rgw-vip.can"}})
+	suite.Contains(hostrecords, BasecampHostRecord{"10.252.1.3", []string{"rgw-vip.nmn"}})
+}
+
+// testBasecampNCNNetworks has the subnets MakeBaseCampfromNCNs needs for the uai and static routes
+func testBasecampNCNNetworks(ncns []csi.LogicalNCN) map[string]*csi.IPV4Network {
+	uai := testSubnet("uai_macvlan", "10.252.2.0/23")
+	uai.Gateway = net.ParseIP("10.252.0.1")
+	for _, ncn := range ncns {
+		uai.AddReservation(ncn.Hostname, ncn.Xname)
+	}
+	nmnHardware := testSubnet("network_hardware", "10.252.0.0/24")
+	nmnHardware.Gateway = net.ParseIP("10.252.0.1")
+	hmnHardware := testSubnet("network_hardware", "10.254.0.0/24")
+	hmnHardware.Gateway = net.ParseIP("10.254.0.1")
+
+	return map[string]*csi.IPV4Network{
+		"NMN":   {Name: "NMN", CIDR: "10.252.0.0/17", Subnets: []*csi.IPV4Subnet{nmnHardware, uai, testSubnet("cabinet_3000", "10.252.4.0/22")}},
+		"HMN":   {Name: "HMN", CIDR: "10.254.0.0/17", Subnets: []*csi.IPV4Subnet{hmnHardware, testSubnet("cabinet_3000", "10.254.4.0/22")}},
+		"NMNLB": {Name: "NMNLB", CIDR: "10.92.100.0/24"},
+		"HMNLB": {Name: "HMNLB", CIDR: "10.94.100.0/24"},
+	}
+}
+
+func testBasecampNCNs() []csi.LogicalNCN {
+	return []csi.LogicalNCN{
+		{Xname: "x3000c0s1b0n0", Hostname: "ncn-m001", Subrole: "Master", NmnMac: "14:02:ec:d9:79:e8"},
+		{Xname: "x3000c0s7b0n0", Hostname: "ncn-w001", Subrole: "Worker", NmnMac: "14:02:ec:d9:7a:38"},
+		{Xname: "x3000c0s13b0n0", Hostname: "ncn-s001", Subrole: "Storage", NmnMac: "14:02:ec:d9:7b:10"},
+	}
+}
+
+func writeFilePaths(cloudInit CloudInit) []string {
+	var paths []string
+	for _, file := range cloudInit.UserData["write_files"].([]WriteFiles) {
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
+
+func (suite *BasecampTestSuite) TestMakeBaseCampfromNCNs_SiteWriteFiles() {
+	dir, err := ioutil.TempDir("", "basecamp")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, "write_files.yaml")
+	suite.NoError(ioutil.WriteFile(config, []byte(`
+write_files:
+  - path: /etc/pki/trust/anchors/site.crt
+    content: c2l0ZSBjZXJ0aWZpY2F0ZQ==
+    encoding: b64
+    owner: root:root
+    permissions: "0644"
+    subroles: [Worker]
+`), 0644))
+
+	v := viper.New()
+	v.Set("write-files-config", config)
+	ncns := testBasecampNCNs()
+	basecamp, err := MakeBaseCampfromNCNs(v, ncns, testBasecampNCNNetworks(ncns))
+	suite.NoError(err)
+
+	routes := []string{"/etc/sysconfig/network/ifroute-bond0.nmn0", "/etc/sysconfig/network/ifroute-bond0.hmn0"}
+	suite.Equal(routes, writeFilePaths(basecamp["14:02:ec:d9:79:e8"]))
+	suite.Equal(append(routes, "/etc/pki/trust/anchors/site.crt"), writeFilePaths(basecamp["14:02:ec:d9:7a:38"]))
+	suite.Equal(routes, writeFilePaths(basecamp["14:02:ec:d9:7b:10"]))
+}
+
+func (suite *BasecampTestSuite) TestMakeBaseCampfromNCNs_RunCMDConfig() {
+	dir, err := ioutil.TempDir("", "basecamp")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, "runcmd.yaml")
+	suite.NoError(ioutil.WriteFile(config, []byte(`
+first-node-suffix: "002"
+runcmd:
+  - subrole: storage
+    first-node: true
+    runcmd: [/srv/cray/scripts/common/storage-ceph-cloudinit.sh]
+  - subrole: Storage
+    runcmd: [/srv/cray/scripts/metal/install.sh]
+  - subrole: Master
+    runcmd: [/srv/cray/scripts/common/kubernetes-cloudinit.sh]
+`), 0644))
+
+	v := viper.New()
+	v.Set("runcmd-config", config)
+	ncns := append(testBasecampNCNs(), csi.LogicalNCN{Xname: "x3000c0s15b0n0", Hostname: "ncn-s002", Subrole: "Storage", NmnMac: "14:02:ec:d9:7b:20"})
+	basecamp, err := MakeBaseCampfromNCNs(v, ncns, testBasecampNCNNetworks(ncns))
+	suite.NoError(err)
+
+	suite.Equal([]string{"/srv/cray/scripts/common/kubernetes-cloudinit.sh"}, basecamp["14:02:ec:d9:79:e8"].UserData["runcmd"])
+	suite.Equal(workerRunCMD, basecamp["14:02:ec:d9:7a:38"].UserData["runcmd"])
+	suite.Equal([]string{"/srv/cray/scripts/metal/install.sh"}, basecamp["14:02:ec:d9:7b:10"].UserData["runcmd"])
+	suite.Equal([]string{"/srv/cray/scripts/common/storage-ceph-cloudinit.sh"}, basecamp["14:02:ec:d9:7b:20"].UserData["runcmd"])
+}
+
+func (suite *BasecampTestSuite) TestMakeBaseCampfromNCNs_DefaultRunCMD() {
+	ncns := append(testBasecampNCNs(), csi.LogicalNCN{Xname: "x3000c0s15b0n0", Hostname: "ncn-s002", Subrole: "Storage", NmnMac: "14:02:ec:d9:7b:20"})
+	basecamp, err := MakeBaseCampfromNCNs(viper.New(), ncns, testBasecampNCNNetworks(ncns))
+	suite.NoError(err)
+
+	suite.Equal(masterRunCMD, basecamp["14:02:ec:d9:79:e8"].UserData["runcmd"])
+	suite.Equal(workerRunCMD, basecamp["14:02:ec:d9:7a:38"].UserData["runcmd"])
+	suite.Equal(cephRunCMD, basecamp["14:02:ec:d9:7b:10"].UserData["runcmd"])
+	suite.Equal(cephWorkerRunCMD, basecamp["14:02:ec:d9:7b:20"].UserData["runcmd"])
+	suite.Contains(masterRunCMD, "/srv/cray/scripts/join-spire-on-storage.sh")
+	suite.Contains(workerRunCMD, "/srv/cray/scripts/join-spire-on-storage.sh")
+}
+
+func (suite *BasecampTestSuite) TestMakeBaseCampfromNCNs_MasterRunCMD() {
+	ncns := testBasecampNCNs()
+	basecamp, err := MakeBaseCampfromNCNs(viper.New(), ncns, testBasecampNCNNetworks(ncns))
+	suite.NoError(err)
+
+	master := basecamp["14:02:ec:d9:79:e8"].UserData["runcmd"]
+	worker := basecamp["14:02:ec:d9:7a:38"].UserData["runcmd"]
+	suite.NotEqual(master, worker)
+	suite.Equal([]string{
+		"/srv/cray/scripts/metal/net-init.sh",
+		"/srv/cray/scripts/common/update_ca_certs.py",
+		"/srv/cray/scripts/metal/install.sh",
+		"/srv/cray/scripts/common/etcd-cloudinit.sh",
+		"/srv/cray/scripts/common/kubernetes-cloudinit.sh",
+		"/srv/cray/scripts/join-spire-on-storage.sh",
+		"touch /etc/cloud/cloud-init.disabled",
+	}, master)
+	suite.Equal(k8sRunCMD, worker)
+	suite.NotContains(worker, etcdRunCMD)
+}
+
+func (suite *BasecampTestSuite) TestInsertRunCMD() {
+	suite.Equal([]string{"a", "x", "b"}, insertRunCMD([]string{"a", "b"}, "b", "x"))
+	suite.Equal([]string{"a", "b", "x"}, insertRunCMD([]string{"a", "b"}, "c", "x"))
+}
+
+func (suite *BasecampTestSuite) TestMakeBaseCampfromNCNs_UnknownSubroleRunCMD() {
+	ncns := append(testBasecampNCNs(), csi.LogicalNCN{Xname: "x3000c0s17b0n0", Hostname: "ncn-x001", Subrole: "Visualization", NmnMac: "14:02:ec:d9:7b:30"})
+	basecamp, err := MakeBaseCampfromNCNs(viper.New(), ncns, testBasecampNCNNetworks(ncns))
+	suite.NoError(err)
+	suite.Equal(k8sRunCMD, basecamp["14:02:ec:d9:7b:30"].UserData["runcmd"])
+}
+
+func (suite *BasecampTestSuite) TestLoadRunCMDConfig_Invalid() {
+	dir, err := ioutil.TempDir("", "basecamp")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, "runcmd.yaml")
+	suite.NoError(ioutil.WriteFile(config, []byte("runcmd:\n  - subrole: Worker\n"), 0644))
+
+	_, err = LoadRunCMDConfig(config)
+	suite.Equal(fmt.Errorf(`runcmd entry 1 (role "", subrole "Worker") in %s has no commands`, config), err)
+}
+
+func (suite *BasecampTestSuite) TestMakeBaseCampfromNCNs_RunCMDMerge() {
+	dir, err := ioutil.TempDir("", "basecamp")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, "runcmd.yaml")
+	suite.NoError(ioutil.WriteFile(config, []byte(`
+runcmd:
+  - subrole: Master
+    merge: prepend
+    runcmd: [/srv/cray/scripts/site/pre-boot.sh]
+  - subrole: Worker
+    merge: append
+    runcmd: [/srv/cray/scripts/site/post-boot.sh]
+  - subrole: Storage
+    merge: replace
+    runcmd: [/srv/cray/scripts/site/storage.sh]
+`), 0644))
+
+	v := viper.New()
+	v.Set("runcmd-config", config)
+	ncns := testBasecampNCNs()
+	basecamp, err := MakeBaseCampfromNCNs(v, ncns, testBasecampNCNNetworks(ncns))
+	suite.NoError(err)
+
+	suite.Equal(append([]string{"/srv/cray/scripts/site/pre-boot.sh"}, masterRunCMD...), basecamp["14:02:ec:d9:79:e8"].UserData["runcmd"])
+	suite.Equal(append(append([]string{}, workerRunCMD...), "/srv/cray/scripts/site/post-boot.sh"), basecamp["14:02:ec:d9:7a:38"].UserData["runcmd"])
+	suite.Equal([]string{"/srv/cray/scripts/site/storage.sh"}, basecamp["14:02:ec:d9:7b:10"].UserData["runcmd"])
+	// Merging never modifies the built-in runcmd
+	suite.Len(workerRunCMD, 6)
+}
+
+func (suite *BasecampTestSuite) TestMakeBaseCampfromNCNs_RunCMDMergeFirstNodeSuffix() {
+	dir, err := ioutil.TempDir("", "basecamp")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, "runcmd.yaml")
+	suite.NoError(ioutil.WriteFile(config, []byte(`
+first-node-suffix: "002"
+runcmd:
+  - subrole: Storage
+    merge: append
+    runcmd: [/srv/cray/scripts/site/storage.sh]
+`), 0644))
+
+	v := viper.New()
+	v.Set("runcmd-config", config)
+	ncns := append(testBasecampNCNs(), csi.LogicalNCN{Xname: "x3000c0s15b0n0", Hostname: "ncn-s002", Subrole: "Storage", NmnMac: "14:02:ec:d9:7b:20"})
+	basecamp, err := MakeBaseCampfromNCNs(v, ncns, testBasecampNCNNetworks(ncns))
+	suite.NoError(err)
+
+	// The defaults are merged by the configured first node, ncn-s002
+	suite.Equal(append(append([]string{}, cephWorkerRunCMD...), "/srv/cray/scripts/site/storage.sh"), basecamp["14:02:ec:d9:7b:10"].UserData["runcmd"])
+	suite.Equal(append(append([]string{}, cephRunCMD...), "/srv/cray/scripts/site/storage.sh"), basecamp["14:02:ec:d9:7b:20"].UserData["runcmd"])
+}
+
+func (suite *BasecampTestSuite) TestRunCMDForNCN_ReturnsCopy() {
+	config := DefaultRunCMDConfig()
+	runCMD := config.RunCMDForNCN("Management", "Worker", "ncn-w001")
+	runCMD[0] = "/srv/cray/scripts/site/changed.sh"
+	suite.Equal("/srv/cray/scripts/metal/net-init.sh", workerRunCMD[0])
+
+	runCMD = config.RunCMDForNCN("Management", "Visualization", "ncn-x001")
+	runCMD[0] = "/srv/cray/scripts/site/changed.sh"
+	suite.Equal("/srv/cray/scripts/metal/net-init.sh", k8sRunCMD[0])
+
+	config = RunCMDConfig{Entries: []RunCMDEntry{{Subrole: "Master", RunCMD: []string{"/srv/cray/scripts/site/master.sh"}}}}
+	runCMD = config.RunCMDForNCN("Management", "Master", "ncn-m001")
+	runCMD[0] = "/srv/cray/scripts/site/changed.sh"
+	suite.Equal("/srv/cray/scripts/site/master.sh", config.Entries[0].RunCMD[0])
+}
+
+func (suite *BasecampTestSuite) TestLoadRunCMDConfig_InvalidMerge() {
+	dir, err := ioutil.TempDir("", "basecamp")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, "runcmd.yaml")
+	suite.NoError(ioutil.WriteFile(config, []byte("runcmd:\n  - subrole: Worker\n    merge: before\n    runcmd: [/srv/cray/scripts/site/pre-boot.sh]\n"), 0644))
+
+	_, err = LoadRunCMDConfig(config)
+	suite.Equal(fmt.Errorf(`runcmd entry 1 (role "", subrole "Worker") in %s has merge "before", expected replace, prepend or append`, config), err)
+}
+
+func (suite *BasecampTestSuite) TestMakeBasecampGlobals_StorageNodes() {
+	networks := testBasecampNetworks()
+	networks["HMNLB"] = &csi.IPV4Network{Name: "HMNLB"}
+	ncns := []csi.LogicalNCN{{Hostname: "ncn-m001", Subrole: "Master"}}
+	for i := 1; i <= 5; i++ {
+		ncns = append(ncns, csi.LogicalNCN{Hostname: fmt.Sprintf("ncn-s%03d", i), Subrole: "Storage"})
+	}
+
+	global, err := MakeBasecampGlobals(viper.New(), ncns, networks, "NMN", "bootstrap_dhcp", "ncn-m001")
+	suite.NoError(err)
+	suite.Equal("5", global["ceph-num-storage-nodes"])
+	suite.Equal(5, global["num_storage_nodes"])
+}
+
+func (suite *BasecampTestSuite) TestMakeBasecampGlobals_RGWVIPNetworks() {
+	networks := testBasecampNetworks()
+	networks["HMNLB"] = &csi.IPV4Network{Name: "HMNLB"}
+	canBootstrap := testSubnet("bootstrap_dhcp", "10.102.11.0/24")
+	canBootstrap.AddReservationWithIP("rgw-vip", "10.102.11.3", "rgw-virtual-ip")
+	networks["CAN"] = &csi.IPV4Network{Name: "CAN", CIDR: "10.102.11.0/24", Subnets: []*csi.IPV4Subnet{canBootstrap}}
+
+	// the globals and the host records both follow the viper passed in
+	v := viper.New()
+	v.Set("rgw-vip-networks", []string{"CAN", "NMN"})
+	global, err := MakeBasecampGlobals(v, nil, networks, "NMN", "bootstrap_dhcp", "ncn-m001")
+	suite.NoError(err)
+	suite.Equal("10.102.11.3", global["rgw-virtual-ip"])
+	suite.Contains(global["host_records"], BasecampHostRecord{"10.102.11.3", []string{"rgw-vip", "rgw-vip.can"}})
+}
+
+func (suite *BasecampTestSuite) TestMakeBasecampGlobals_RGWVIPMissing() {
+	networks := testBasecampNetworks()
+	networks["HMNLB"] = &csi.IPV4Network{Name: "HMNLB"}
+	networks["CAN"] = &csi.IPV4Network{Name: "CAN", CIDR: "10.102.11.0/24", Subnets: []*csi.IPV4Subnet{testSubnet("bootstrap_dhcp", "10.102.11.0/24")}}
+
+	v := viper.New()
+	v.Set("rgw-vip-networks", []string{"CAN"})
+	_, err := MakeBasecampGlobals(v, nil, networks, "NMN", "bootstrap_dhcp", "ncn-m001")
+	suite.Equal(errors.New("couldn't find an rgw-vip reservation in the bootstrap_dhcp subnet of the CAN network for generating basecamp's data.json"), err)
+}
+
+func TestBasecampTestSuite(t *testing.T) {
+	suite.Run(t, new(BasecampTestSuite))
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"fmt"
+	"sort"
+)
+
+// cloudInitRequiredUserData are the user-data keys every NCN needs from basecamp
+var cloudInitRequiredUserData = []string{"hostname", "local_hostname", "runcmd"}
+
+// Validate checks that the cloud-init data has the keys an NCN needs and that runcmd has the structure
+// cloud-init expects, a list whose entries are a command string or a list of arguments
+func (cloudInit CloudInit) Validate() []error {
+	var errs []error
+	for key, value := range map[string]string{
+		"local-hostname": cloudInit.MetaData.Hostname,
+		"xname":          cloudInit.MetaData.Xname,
+		"instance-id":    cloudInit.MetaData.InstanceID,
+	} {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("meta-data is missing %s", key))
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Error() < errs[j].Error()
+	})
+
+	for _, key := range cloudInitRequiredUserData {
+		if _, ok := cloudInit.UserData[key]; !ok {
+			errs = append(errs, fmt.Errorf("user-data is missing %s", key))
+		}
+	}
+	if runcmd, ok := cloudInit.UserData["runcmd"]; ok {
+		errs = append(errs, validateRunCMD(runcmd)...)
+	}
+	return errs
+}
+
+func validateRunCMD(runcmd interface{}) []error {
+	switch commands := runcmd.(type) {
+	case []string:
+		return nil
+	case []interface{}:
+		var errs []error
+		for i, command := range commands {
+			if !isRunCMDCommand(command) {
+				errs = append(errs, fmt.Errorf("runcmd entry %d is a %T, not a command string or a list of arguments", i+1, command))
+			}
+		}
+		return errs
+	}
+	return []error{fmt.Errorf("runcmd is a %T, not a list of commands", runcmd)}
+}
+
+func isRunCMDCommand(command interface{}) bool {
+	switch args := command.(type) {
+	case string, []string:
+		return true
+	case []interface{}:
+		for _, arg := range args {
+			if _, ok := arg.(string); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// ValidateBasecampConfig validates the cloud-init data of every entry in the basecamp config, returning the
+// problems prefixed with the entry and its hostname
+func ValidateBasecampConfig(basecampConfig map[string]CloudInit) []error {
+	var keys []string
+	for key := range basecampConfig {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		for _, err := range basecampConfig[key].Validate() {
+			errs = append(errs, fmt.Errorf("%s (%s): %v", key, basecampConfig[key].MetaData.Hostname, err))
+		}
+	}
+	return errs
+}
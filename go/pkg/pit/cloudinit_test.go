@@ -0,0 +1,67 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Cray-HPE/csm-common/go/pkg/csi"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+)
+
+type CloudInitTestSuite struct {
+	suite.Suite
+}
+
+func (suite *CloudInitTestSuite) TestValidateBasecampConfig_Generated() {
+	ncns := testBasecampNCNs()
+	for i := range ncns {
+		ncns[i].InstanceID = csi.GenerateInstanceID()
+	}
+	basecamp, err := MakeBaseCampfromNCNs(viper.New(), ncns, testBasecampNCNNetworks(ncns))
+	suite.NoError(err)
+	suite.Empty(ValidateBasecampConfig(basecamp))
+}
+
+func (suite *CloudInitTestSuite) TestValidate_MalformedRunCMD() {
+	cloudInit := CloudInit{
+		MetaData: MetaData{Hostname: "ncn-w001", Xname: "x3000c0s4b0n0", InstanceID: "i-123456"},
+		UserData: map[string]interface{}{
+			"hostname":       "ncn-w001",
+			"local_hostname": "ncn-w001",
+			"runcmd":         "/srv/cray/scripts/common/kubernetes-cloudinit.sh",
+		},
+	}
+	suite.Equal([]error{errors.New("runcmd is a string, not a list of commands")}, cloudInit.Validate())
+
+	cloudInit.UserData["runcmd"] = []interface{}{"/srv/cray/scripts/metal/install.sh", []interface{}{"touch", 5}, 7}
+	suite.Equal([]error{
+		errors.New("runcmd entry 2 is a []interface {}, not a command string or a list of arguments"),
+		errors.New("runcmd entry 3 is a int, not a command string or a list of arguments"),
+	}, cloudInit.Validate())
+}
+
+func (suite *CloudInitTestSuite) TestValidateBasecampConfig_MissingKeys() {
+	basecamp := map[string]CloudInit{
+		"14:02:ec:d9:79:e8": {
+			MetaData: MetaData{Hostname: "ncn-m001"},
+			UserData: map[string]interface{}{"hostname": "ncn-m001", "runcmd": []string{"/srv/cray/scripts/metal/install.sh"}},
+		},
+	}
+	suite.Equal([]error{
+		errors.New("14:02:ec:d9:79:e8 (ncn-m001): meta-data is missing instance-id"),
+		errors.New("14:02:ec:d9:79:e8 (ncn-m001): meta-data is missing xname"),
+		errors.New("14:02:ec:d9:79:e8 (ncn-m001): user-data is missing local_hostname"),
+	}, ValidateBasecampConfig(basecamp))
+}
+
+func TestCloudInitTestSuite(t *testing.T) {
+	suite.Run(t, new(CloudInitTestSuite))
+}
@@ -5,6 +5,8 @@ Copyright 2021 Hewlett Packard Enterprise Development LP
 package pit
 
 import (
+	"fmt"
+	"strings"
 	"text/template"
 
 	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
@@ -25,33 +27,81 @@ GLOBAL seropts="115200,8n1"
 GLOBAL log="console.%N"
 GLOBAL logopts="sanitize,timestamp"
 {{range .}}
+{{- if eq .Method "redfish"}}
+console name="{{.Hostname}}-mgmt"     dev="{{.Script}} {{.IP}} {{.User}} {{.Pass}}"
+{{- else}}
 console name="{{.Hostname}}-mgmt"     dev="ipmi:{{.IP}}" ipmiopts="U:{{.User}},P:{{.Pass}},W:solpayloadsize"
 {{- end}}
+{{- end}}
 `)
 
-// WriteConmanConfig provides conman configuration for the installer
-func WriteConmanConfig(path string, ncns []csi.LogicalNCN) {
-	type conmanLine struct {
-		Hostname string
-		User     string
-		IP       string
-		Pass     string
+// Supported conman console access methods
+const (
+	ConmanConsoleMethodIPMI    = "ipmi"
+	ConmanConsoleMethodRedfish = "redfish"
+)
+
+// ConmanRedfishConsoleScript is the conman exec script used to attach to a Redfish serial console
+var ConmanRedfishConsoleScript = "/usr/lib/conman/exec/redfish-console.exp"
+
+// ConmanConsole is a single console definition in the conman configuration
+type ConmanConsole struct {
+	Hostname string
+	Method   string
+	Script   string
+	User     string
+	IP       string
+	Pass     string
+}
+
+// GetConmanConsoleMethod returns the console access method for a subrole.
+// A conman-console-method-<subrole> setting takes precedence over the global conman-console-method, which defaults to ipmi.
+func GetConmanConsoleMethod(v *viper.Viper, subrole string) (string, error) {
+	method := v.GetString("conman-console-method")
+	subroleKey := fmt.Sprintf("conman-console-method-%s", strings.ToLower(subrole))
+	if subrole != "" && v.IsSet(subroleKey) {
+		method = v.GetString(subroleKey)
+	}
+	method = strings.ToLower(method)
+	switch method {
+	case "":
+		return ConmanConsoleMethodIPMI, nil
+	case ConmanConsoleMethodIPMI, ConmanConsoleMethodRedfish:
+		return method, nil
 	}
-	v := viper.GetViper()
+	return "", fmt.Errorf("invalid conman console method %q for subrole %q, must be %s or %s", method, subrole, ConmanConsoleMethodIPMI, ConmanConsoleMethodRedfish)
+}
+
+// MakeConmanConsoles builds the conman console definitions for the NCNs
+func MakeConmanConsoles(v *viper.Viper, ncns []csi.LogicalNCN) ([]ConmanConsole, error) {
 	ncnBMCUser := v.GetString("bootstrap-ncn-bmc-user")
 	ncnBMCPass := v.GetString("bootstrap-ncn-bmc-pass")
 
-	var conmanNCNs []conmanLine
-
+	var conmanNCNs []ConmanConsole
 	for _, k := range ncns {
-		conmanNCNs = append(conmanNCNs, conmanLine{
+		method, err := GetConmanConsoleMethod(v, k.Subrole)
+		if err != nil {
+			return nil, err
+		}
+		conmanNCNs = append(conmanNCNs, ConmanConsole{
 			Hostname: k.Hostname,
+			Method:   method,
+			Script:   ConmanRedfishConsoleScript,
 			User:     ncnBMCUser,
 			Pass:     ncnBMCPass,
 			IP:       k.BmcIP,
 		})
 	}
+	return conmanNCNs, nil
+}
+
+// WriteConmanConfig provides conman configuration for the installer
+func WriteConmanConfig(path string, ncns []csi.LogicalNCN) error {
+	conmanNCNs, err := MakeConmanConsoles(viper.GetViper(), ncns)
+	if err != nil {
+		return err
+	}
 
 	tpl6, _ := template.New("conmanconfig").Parse(string(ConmanConfigTemplate))
-	csiFiles.WriteTemplate(path, tpl6, conmanNCNs)
+	return csiFiles.WriteTemplate(path, tpl6, conmanNCNs)
 }
@@ -0,0 +1,70 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/Cray-HPE/csm-common/go/pkg/csi"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConmanTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ConmanTestSuite) renderConsoles(v *viper.Viper, ncns []csi.LogicalNCN) string {
+	consoles, err := MakeConmanConsoles(v, ncns)
+	suite.NoError(err)
+
+	var bs bytes.Buffer
+	tpl, _ := template.New("conmanconfig").Parse(string(ConmanConfigTemplate))
+	suite.NoError(tpl.Execute(&bs, consoles))
+	return bs.String()
+}
+
+func (suite *ConmanTestSuite) TestConmanConfig_DefaultIPMI() {
+	v := viper.New()
+	v.Set("bootstrap-ncn-bmc-user", "root")
+	v.Set("bootstrap-ncn-bmc-pass", "secret")
+
+	config := suite.renderConsoles(v, []csi.LogicalNCN{{Hostname: "ncn-m002", Subrole: "Master", BmcIP: "10.254.1.5"}})
+	suite.Contains(config, `console name="ncn-m002-mgmt"     dev="ipmi:10.254.1.5" ipmiopts="U:root,P:secret,W:solpayloadsize"`)
+}
+
+func (suite *ConmanTestSuite) TestConmanConfig_RedfishPerSubrole() {
+	v := viper.New()
+	v.Set("bootstrap-ncn-bmc-user", "root")
+	v.Set("bootstrap-ncn-bmc-pass", "secret")
+	v.Set("conman-console-method-storage", "redfish")
+
+	config := suite.renderConsoles(v, []csi.LogicalNCN{
+		{Hostname: "ncn-m002", Subrole: "Master", BmcIP: "10.254.1.5"},
+		{Hostname: "ncn-s001", Subrole: "Storage", BmcIP: "10.254.1.7"},
+	})
+	suite.Contains(config, `console name="ncn-m002-mgmt"     dev="ipmi:10.254.1.5"`)
+	suite.Contains(config, `console name="ncn-s001-mgmt"     dev="/usr/lib/conman/exec/redfish-console.exp 10.254.1.7 root secret"`)
+	suite.Equal(1, strings.Count(config, "redfish-console.exp"))
+}
+
+func (suite *ConmanTestSuite) TestConmanConfig_InvalidMethod() {
+	v := viper.New()
+	v.Set("conman-console-method", "telnet")
+
+	_, err := MakeConmanConsoles(v, []csi.LogicalNCN{{Hostname: "ncn-m002", Subrole: "Master"}})
+	suite.Equal(errors.New(`invalid conman console method "telnet" for subrole "Master", must be ipmi or redfish`), err)
+}
+
+func TestConmanTestSuite(t *testing.T) {
+	suite.Run(t, new(ConmanTestSuite))
+}
@@ -4,9 +4,91 @@ Copyright 2021 Hewlett Packard Enterprise Development LP
 
 package pit
 
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+)
+
 //PasswordCredential is a struct for holding username/password credentials
 type PasswordCredential struct {
 	Username   string `form:"username" json:"username"`
 	Password   string `form:"password" json:"password"`
 	ServiceURL string `form:"service_url" json:"service_url" binding:"omitempty"`
 }
+
+// String keeps the password out of log messages
+func (c PasswordCredential) String() string {
+	return fmt.Sprintf("{Username:%s Password:<redacted> ServiceURL:%s}", c.Username, c.ServiceURL)
+}
+
+// GoString keeps the password out of %#v as well
+func (c PasswordCredential) GoString() string {
+	return fmt.Sprintf("pit.PasswordCredential{Username:%q, Password:\"<redacted>\", ServiceURL:%q}", c.Username, c.ServiceURL)
+}
+
+const (
+	// CredentialsFormatJSON writes credentials as a plain PasswordCredential
+	CredentialsFormatJSON = "json"
+	// CredentialsFormatVault writes credentials as a Vault KV payload under hms-creds
+	CredentialsFormatVault = "vault"
+	// VaultCredentialsMount is the Vault KV mount referenced by vault://hms-creds/<name> in SLS
+	VaultCredentialsMount = "hms-creds"
+)
+
+// VaultCredential is a Vault KV payload for a credential stored under hms-creds
+type VaultCredential struct {
+	Path string              `json:"path"`
+	Data VaultCredentialData `json:"data"`
+}
+
+// VaultCredentialData is the secret stored at the Vault path
+type VaultCredentialData struct {
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	ServiceURL string `json:"service_url,omitempty"`
+}
+
+// VaultCredentialURL is how SLS refers to the credential, e.g. vault://hms-creds/x3000c0r22b0
+func VaultCredentialURL(name string) string {
+	return fmt.Sprintf("vault://%s/%s", VaultCredentialsMount, name)
+}
+
+// MakeVaultCredential structures a credential as a Vault KV payload
+func MakeVaultCredential(name string, credential PasswordCredential) VaultCredential {
+	return VaultCredential{
+		Path: path.Join(VaultCredentialsMount, name),
+		Data: VaultCredentialData{
+			Username:   credential.Username,
+			Password:   credential.Password,
+			ServiceURL: credential.ServiceURL,
+		},
+	}
+}
+
+// ValidateCredentialName verifies that a credential name can be used as both a file name and a Vault path
+// under hms-creds, so it cannot be empty, contain a path separator or be . or ..
+func ValidateCredentialName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return fmt.Errorf("credential name %q is not valid, it cannot be empty, contain a path separator or contain ..", name)
+	}
+	return nil
+}
+
+// WriteCredential writes the credential to <dir>/<name>.json in the requested format
+func WriteCredential(dir, name string, credential PasswordCredential, format string) error {
+	if err := ValidateCredentialName(name); err != nil {
+		return err
+	}
+	target := filepath.Join(dir, fmt.Sprintf("%s.json", name))
+	switch format {
+	case CredentialsFormatJSON, "":
+		return csiFiles.WriteJSONConfig(target, credential)
+	case CredentialsFormatVault:
+		return csiFiles.WriteJSONConfig(target, MakeVaultCredential(name, credential))
+	}
+	return fmt.Errorf("unknown credentials-format %q for credential %s, must be %s or %s", format, name, CredentialsFormatJSON, CredentialsFormatVault)
+}
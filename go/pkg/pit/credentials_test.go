@@ -0,0 +1,73 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CredentialsTestSuite struct {
+	suite.Suite
+}
+
+func (suite *CredentialsTestSuite) TestWriteCredential_Vault() {
+	dir, err := ioutil.TempDir("", "credentials")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+
+	credential := PasswordCredential{Username: "root", Password: "initial0"}
+	suite.NoError(WriteCredential(dir, "x3000c0r22b0", credential, CredentialsFormatVault))
+
+	bs, err := ioutil.ReadFile(filepath.Join(dir, "x3000c0r22b0.json"))
+	suite.NoError(err)
+	suite.JSONEq(`{
+		"path": "hms-creds/x3000c0r22b0",
+		"data": {"username": "root", "password": "initial0"}
+	}`, string(bs))
+	suite.Equal("vault://hms-creds/x3000c0r22b0", VaultCredentialURL("x3000c0r22b0"))
+}
+
+func (suite *CredentialsTestSuite) TestWriteCredential_InvalidFormat() {
+	err := WriteCredential("", "bmc", PasswordCredential{}, "yaml")
+	suite.Equal(errors.New(`unknown credentials-format "yaml" for credential bmc, must be json or vault`), err)
+}
+
+func (suite *CredentialsTestSuite) TestPasswordCredential_String() {
+	credential := PasswordCredential{Username: "root", Password: "initial0"}
+	suite.NotContains(fmt.Sprintf("%v %+v %#v", credential, credential, credential), "initial0")
+	suite.Contains(fmt.Sprintf("%#v", credential), `Username:"root"`)
+}
+
+func (suite *CredentialsTestSuite) TestWriteCredential_InvalidName() {
+	dir, err := ioutil.TempDir("", "credentials")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+
+	credential := PasswordCredential{Username: "root", Password: "initial0"}
+	for _, name := range []string{"", ".", "..", "../bmc", "hms-creds/bmc", `..\bmc`, "bmc.."} {
+		err := WriteCredential(dir, name, credential, CredentialsFormatVault)
+		suite.Equal(fmt.Errorf("credential name %q is not valid, it cannot be empty, contain a path separator or contain ..", name), err)
+		suite.NotContains(err.Error(), "initial0")
+	}
+	files, err := ioutil.ReadDir(filepath.Dir(dir))
+	suite.NoError(err)
+	for _, file := range files {
+		suite.NotEqual("bmc.json", file.Name())
+	}
+}
+
+func TestCredentialsTestSuite(t *testing.T) {
+	suite.Run(t, new(CredentialsTestSuite))
+}
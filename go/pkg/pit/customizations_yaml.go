@@ -91,14 +91,12 @@ func (c *CustomizationsYaml) IsValid() bool {
 }
 
 // GenCustomizationsYaml generates our configurations.yaml nested struct
-func GenCustomizationsYaml(ncns []csi.LogicalNCN, shastaNetworks map[string]*csi.IPV4Network, switches []*csi.ManagementSwitch) CustomizationsYaml {
+func GenCustomizationsYaml(ncns []csi.LogicalNCN, shastaNetworks map[string]*csi.IPV4Network, switches []*csi.ManagementSwitch) (CustomizationsYaml, error) {
 	v := viper.GetViper()
 	systemName := v.GetString("system-name")
 	siteDomain := v.GetString("site-domain")
 
 	var output CustomizationsYaml
-	var metallb MetalLBConfigMap
-
 	// nmnMacvlanSubnet, _ := shastaNetworks["NMN"].LookUpSubnet("uai_macvlan")
 	var masters []net.IP
 	var storage []net.IP
@@ -111,7 +109,10 @@ func GenCustomizationsYaml(ncns []csi.LogicalNCN, shastaNetworks map[string]*csi
 		}
 	}
 
-	metallb = GetMetalLBConfig(v, shastaNetworks, switches)
+	metallb, err := GetMetalLBConfig(v, shastaNetworks, switches)
+	if err != nil {
+		return output, err
+	}
 
 	nmnLBs, _ := shastaNetworks["NMNLB"].LookUpSubnet("nmn_metallb_address_pool")
 	hmnLBs, _ := shastaNetworks["HMNLB"].LookUpSubnet("hmn_metallb_address_pool")
@@ -163,7 +164,7 @@ func GenCustomizationsYaml(ncns []csi.LogicalNCN, shastaNetworks map[string]*csi
 			ExternalS3:        strings.ToLower(fmt.Sprintf("s3.%s.%s", systemName, siteDomain)),
 			ExternalAuth:      strings.ToLower(fmt.Sprintf("auth.%s.%s", systemName, siteDomain)),
 			ExternalAPI:       strings.ToLower(fmt.Sprintf("api.%s.%s", systemName, siteDomain)),
-			InternalS3:        "rgw-vip.nmn",
+			InternalS3:        fmt.Sprintf("rgw-vip.%s", strings.ToLower(csi.RGWVIPNetworks(v)[0])),
 			InternalAPI:       "api-gw-service-nmn.local",
 			PrimaryServerName: v.GetString("primary-server-name"),
 			SecondaryServers:  v.GetString("secondary-servers"),
@@ -223,7 +224,7 @@ func GenCustomizationsYaml(ncns []csi.LogicalNCN, shastaNetworks map[string]*csi
 			}
 		}
 	}
-	return output
+	return output, nil
 }
 
 func init() {
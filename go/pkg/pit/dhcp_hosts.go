@@ -0,0 +1,80 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"strings"
+
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+	"github.com/Cray-HPE/csm-common/go/pkg/csi"
+)
+
+// DHCPHostEntry is one row of the MAC to IP table for importing the NCNs into an external DHCP server
+type DHCPHostEntry struct {
+	MAC      string `csv:"MAC"`
+	IP       string `csv:"IP"`
+	Hostname string `csv:"Hostname"`
+	Network  string `csv:"Network"`
+}
+
+// bootstrapReservationIP returns the address reserved for name in the bootstrap_dhcp subnet of a network
+func bootstrapReservationIP(networks map[string]*csi.IPV4Network, netName, name string) string {
+	network, ok := networks[netName]
+	if !ok {
+		return ""
+	}
+	subnet, err := network.LookUpSubnet("bootstrap_dhcp")
+	if err != nil {
+		return ""
+	}
+	if reservation, ok := subnet.ReservationsByName()[name]; ok && reservation.IPAddress != nil {
+		return reservation.IPAddress.String()
+	}
+	return ""
+}
+
+// MakeDHCPHostEntries joins the MACs of every NCN with its NMN and HMN reservations.  Each bond0 MAC, and
+// the NMN MAC when it is not one of them, maps to the NMN address of the NCN, and the BMC MAC maps to the
+// <hostname>-mgmt address on the HMN.  MACs without an address are left out.
+func MakeDHCPHostEntries(ncns []csi.LogicalNCN, networks map[string]*csi.IPV4Network) []DHCPHostEntry {
+	var entries []DHCPHostEntry
+	for _, ncn := range ncns {
+		hostname := ncn.GetHostname()
+
+		nmnIP := bootstrapReservationIP(networks, "NMN", hostname)
+		if nmnIP == "" {
+			for _, ncnNetwork := range ncn.Networks {
+				if ncnNetwork.NetworkName == "NMN" {
+					nmnIP = ncnNetwork.IPAddress
+				}
+			}
+		}
+		if nmnIP != "" {
+			seen := map[string]bool{}
+			for _, mac := range []string{ncn.Bond0Mac0, ncn.Bond0Mac1, ncn.NmnMac} {
+				mac = strings.ToLower(strings.TrimSpace(mac))
+				if mac == "" || seen[mac] {
+					continue
+				}
+				seen[mac] = true
+				entries = append(entries, DHCPHostEntry{MAC: mac, IP: nmnIP, Hostname: hostname, Network: "NMN"})
+			}
+		}
+
+		bmcIP := bootstrapReservationIP(networks, "HMN", hostname+"-mgmt")
+		if bmcIP == "" {
+			bmcIP = ncn.BmcIP
+		}
+		if mac := strings.ToLower(strings.TrimSpace(ncn.BmcMac)); mac != "" && bmcIP != "" {
+			entries = append(entries, DHCPHostEntry{MAC: mac, IP: bmcIP, Hostname: hostname + "-mgmt", Network: "HMN"})
+		}
+	}
+	return entries
+}
+
+// WriteDHCPHostEntries writes the MAC to IP table as a csv file
+func WriteDHCPHostEntries(path string, entries []DHCPHostEntry) error {
+	return csiFiles.WriteCSVConfig(path, entries)
+}
@@ -0,0 +1,57 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Cray-HPE/csm-common/go/pkg/csi"
+	"github.com/stretchr/testify/suite"
+)
+
+type DHCPHostsTestSuite struct {
+	suite.Suite
+}
+
+func (suite *DHCPHostsTestSuite) TestMakeDHCPHostEntries() {
+	ncns := []csi.LogicalNCN{{
+		Xname:     "x3000c0s1b0n0",
+		Hostname:  "ncn-m001",
+		BmcMac:    "94:40:C9:37:77:26",
+		NmnMac:    "14:02:ec:d9:79:e8",
+		Bond0Mac0: "14:02:ec:d9:79:e8",
+		Bond0Mac1: "14:02:ec:d9:79:e9",
+	}}
+
+	entries := MakeDHCPHostEntries(ncns, testBasecampNetworks())
+	suite.Equal([]DHCPHostEntry{
+		{MAC: "14:02:ec:d9:79:e8", IP: "10.252.1.10", Hostname: "ncn-m001", Network: "NMN"},
+		{MAC: "14:02:ec:d9:79:e9", IP: "10.252.1.10", Hostname: "ncn-m001", Network: "NMN"},
+		{MAC: "94:40:c9:37:77:26", IP: "10.254.1.4", Hostname: "ncn-m001-mgmt", Network: "HMN"},
+	}, entries)
+
+	dir, err := ioutil.TempDir("", "dhcp")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "dhcp_hosts.csv")
+	suite.NoError(WriteDHCPHostEntries(path, entries))
+	written, err := ioutil.ReadFile(path)
+	suite.NoError(err)
+	suite.Equal(`MAC,IP,Hostname,Network
+14:02:ec:d9:79:e8,10.252.1.10,ncn-m001,NMN
+14:02:ec:d9:79:e9,10.252.1.10,ncn-m001,NMN
+94:40:c9:37:77:26,10.254.1.4,ncn-m001-mgmt,HMN
+`, string(written))
+}
+
+func TestDHCPHostsTestSuite(t *testing.T) {
+	suite.Run(t, new(DHCPHostsTestSuite))
+}
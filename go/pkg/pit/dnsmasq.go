@@ -27,6 +27,7 @@ import (
 	"fmt"
 	"net"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"text/template"
 
@@ -46,8 +47,8 @@ interface=bond0.cmn0
 cname=packages.cmn,pit.cmn
 cname=registry.cmn,pit.cmn
 dhcp-option=interface:bond0.cmn0,option:router,{{.Gateway}}
-dhcp-range=interface:bond0.cmn0,{{.DHCPStart}},{{.DHCPEnd}},10m
-`)
+{{range .DHCPRangeList}}dhcp-range=interface:bond0.cmn0,{{.Start}},{{.End}},{{$.Lease}}
+{{end}}`)
 
 // CANConfigTemplate manages the CAN portion of the DNSMasq configuration
 var CANConfigTemplate = []byte(`
@@ -60,8 +61,8 @@ interface=bond0.can0
 cname=packages.can,pit.can
 cname=registry.can,pit.can
 dhcp-option=interface:bond0.can0,option:router,{{.Gateway}}
-dhcp-range=interface:bond0.can0,{{.DHCPStart}},{{.DHCPEnd}},10m
-`)
+{{range .DHCPRangeList}}dhcp-range=interface:bond0.can0,{{.Start}},{{.End}},{{$.Lease}}
+{{end}}`)
 
 // HMNConfigTemplate manages the HMN portion of the DNSMasq configuration typically bond0.hmn0
 var HMNConfigTemplate = []byte(`
@@ -78,8 +79,8 @@ cname=registry.hmn,pit.hmn
 dhcp-option=interface:bond0.hmn0,option:dns-server,{{.PITServer}}
 dhcp-option=interface:bond0.hmn0,option:ntp-server,{{.PITServer}}
 dhcp-option=interface:bond0.hmn0,option:router,{{.Gateway}}
-dhcp-range=interface:bond0.hmn0,{{.DHCPStart}},{{.DHCPEnd}},10m
-`)
+{{range .DHCPRangeList}}dhcp-range=interface:bond0.hmn0,{{.Start}},{{.End}},{{$.Lease}}
+{{end}}`)
 
 // MTLConfigTemplate manages the MTL portion of the DNSMasq configuration
 var MTLConfigTemplate = []byte(`
@@ -95,8 +96,8 @@ dhcp-option=interface:bond0,option:dns-server,{{.PITServer}}
 dhcp-option=interface:bond0,option:ntp-server,{{.PITServer}}
 # This must point at the router for the network; the L3/IP for the VLAN.
 dhcp-option=interface:bond0,option:router,{{.Gateway}}
-dhcp-range=interface:bond0,{{.DHCPStart}},{{.DHCPEnd}},10m
-`)
+{{range .DHCPRangeList}}dhcp-range=interface:bond0,{{.Start}},{{.End}},{{$.Lease}}
+{{end}}`)
 
 // NMNConfigTemplate manages the NMN portion of the DNSMasq configuration
 var NMNConfigTemplate = []byte(`
@@ -113,8 +114,8 @@ cname=registry.nmn,pit.nmn
 dhcp-option=interface:bond0.nmn0,option:dns-server,{{.PITServer}}
 dhcp-option=interface:bond0.nmn0,option:ntp-server,{{.PITServer}}
 dhcp-option=interface:bond0.nmn0,option:router,{{.Gateway}}
-dhcp-range=interface:bond0.nmn0,{{.DHCPStart}},{{.DHCPEnd}},10m
-`)
+{{range .DHCPRangeList}}dhcp-range=interface:bond0.nmn0,{{.Start}},{{.End}},{{$.Lease}}
+{{end}}`)
 
 // StaticConfigTemplate manages the static portion of the DNSMasq configuration
 // Systems with onboard NICs will have a MTL MAC.  Others will also use the NMN
@@ -139,12 +140,37 @@ dhcp-option-force=tag:{{.Hostname}},17,{{.Xname}}
 {{end}}
 # Virtual IP Addresses for k8s and the rados gateway
 host-record=kubeapi-vip,kubeapi-vip.nmn,{{.KUBEVIP}} # k8s-virtual-ip
-host-record=rgw-vip,rgw-vip.nmn,{{.RGWVIP}} # rgw-virtual-ip
+host-record=rgw-vip,rgw-vip.{{.RGWNET}},{{.RGWVIP}} # rgw-virtual-ip
 host-record={{.APIGWALIASES}},{{.APIGWIP}} # api gateway
 
 cname=kubernetes-api.vshasta.io,ncn-m001
 `)
 
+// DefaultDHCPLeaseTime is the lease time handed out on the bootstrap networks unless <net>-dhcp-lease is set
+const DefaultDHCPLeaseTime = "10m"
+
+// dhcpLeasePattern matches the lease times accepted by the dnsmasq dhcp-range option
+var dhcpLeasePattern = regexp.MustCompile(`^(infinite|[0-9]+[smhdw]?)$`)
+
+// DHCPLeaseTime returns the dnsmasq lease time for the network from the <net>-dhcp-lease setting
+func DHCPLeaseTime(v *viper.Viper, networkName string) (string, error) {
+	key := fmt.Sprintf("%s-dhcp-lease", strings.ToLower(networkName))
+	lease := v.GetString(key)
+	if lease == "" {
+		return DefaultDHCPLeaseTime, nil
+	}
+	if !dhcpLeasePattern.MatchString(lease) {
+		return "", fmt.Errorf("invalid %s %q, must be a number optionally followed by s, m, h, d or w, or infinite", key, lease)
+	}
+	return lease, nil
+}
+
+// dnsmasqSubnet is the data passed to the per network dnsmasq templates
+type dnsmasqSubnet struct {
+	csi.IPV4Subnet
+	Lease string
+}
+
 // DNSMasqBootstrapNetwork holds information for configuring DNSMasq on the LiveCD
 type DNSMasqBootstrapNetwork struct {
 	Subnet    csi.IPV4Subnet
@@ -152,7 +178,7 @@ type DNSMasqBootstrapNetwork struct {
 }
 
 // WriteDNSMasqConfig writes the dnsmasq configuration files necssary for installation
-func WriteDNSMasqConfig(path string, v *viper.Viper, bootstrap []csi.LogicalNCN, networks map[string]*csi.IPV4Network) {
+func WriteDNSMasqConfig(path string, v *viper.Viper, bootstrap []csi.LogicalNCN, networks map[string]*csi.IPV4Network) error {
 	for i, tmpNcn := range bootstrap {
 		for _, tmpNet := range tmpNcn.Networks {
 			if tmpNet.NetworkName == "NMN" {
@@ -176,9 +202,10 @@ func WriteDNSMasqConfig(path string, v *viper.Viper, bootstrap []csi.LogicalNCN,
 		if reservation.Name == "kubeapi-vip" {
 			kubevip = reservation.IPAddress.String()
 		}
-		if reservation.Name == "rgw-vip" {
-			rgwvip = reservation.IPAddress.String()
-		}
+	}
+	rgwNetwork := csi.RGWVIPNetworks(v)[0]
+	if rgwres, found := lookupRGWVIP(networks, rgwNetwork); found {
+		rgwvip = rgwres.IPAddress.String()
 	}
 
 	var apigwAliases, apigwIP string
@@ -191,12 +218,14 @@ func WriteDNSMasqConfig(path string, v *viper.Viper, bootstrap []csi.LogicalNCN,
 		NCNS         []csi.LogicalNCN
 		KUBEVIP      string
 		RGWVIP       string
+		RGWNET       string
 		APIGWALIASES string
 		APIGWIP      string
 	}{
 		bootstrap,
 		kubevip,
 		rgwvip,
+		strings.ToLower(rgwNetwork),
 		apigwAliases,
 		apigwIP,
 	}
@@ -206,22 +235,32 @@ func WriteDNSMasqConfig(path string, v *viper.Viper, bootstrap []csi.LogicalNCN,
 	netHMN, _ := template.New("hmnconfig").Parse(string(HMNConfigTemplate))
 	netNMN, _ := template.New("nmnconfig").Parse(string(NMNConfigTemplate))
 	netMTL, _ := template.New("mtlconfig").Parse(string(MTLConfigTemplate))
-	writeConfig("CMN", path, *netCMN, networks)
-	writeConfig("HMN", path, *netHMN, networks)
-	writeConfig("NMN", path, *netNMN, networks)
-	writeConfig("MTL", path, *netMTL, networks)
+	if err := writeConfig("CMN", path, *netCMN, networks); err != nil {
+		return err
+	}
+	if err := writeConfig("HMN", path, *netHMN, networks); err != nil {
+		return err
+	}
+	if err := writeConfig("NMN", path, *netNMN, networks); err != nil {
+		return err
+	}
+	if err := writeConfig("MTL", path, *netMTL, networks); err != nil {
+		return err
+	}
 	// Work some BICAN required magic
 	if v.GetString("bican-user-network-name") == "CAN" || v.GetBool("retain-unused-user-network") {
 		netCAN, _ := template.New("canconfig").Parse(string(CANConfigTemplate))
-		writeConfig("CAN", path, *netCAN, networks)
+		if err := writeConfig("CAN", path, *netCAN, networks); err != nil {
+			return err
+		}
 	}
 
 	// Expected NCNs (and other devices) reserved DHCP leases:
 	netIPAM, _ := template.New("statics").Parse(string(StaticConfigTemplate))
-	csiFiles.WriteTemplate(filepath.Join(path, "dnsmasq.d/statics.conf"), netIPAM, data)
+	return csiFiles.WriteTemplate(filepath.Join(path, "dnsmasq.d/statics.conf"), netIPAM, data)
 }
 
-func writeConfig(name, path string, tpl template.Template, networks map[string]*csi.IPV4Network) {
+func writeConfig(name, path string, tpl template.Template, networks map[string]*csi.IPV4Network) error {
 	// Pointer to the IPV4Network
 	tempNet := networks[name]
 
@@ -247,5 +286,10 @@ func writeConfig(name, path string, tpl template.Template, networks map[string]*
 
 	nmnLBSubnet, _ := networks["NMNLB"].LookUpSubnet("nmn_metallb_address_pool")
 	tempSubnet.DNSServer = nmnLBSubnet.LookupReservation("unbound").IPAddress
-	csiFiles.WriteTemplate(filepath.Join(path, fmt.Sprintf("dnsmasq.d/%v.conf", name)), &tpl, tempSubnet)
+
+	lease, err := DHCPLeaseTime(v, name)
+	if err != nil {
+		return err
+	}
+	return csiFiles.WriteTemplate(filepath.Join(path, fmt.Sprintf("dnsmasq.d/%v.conf", name)), &tpl, dnsmasqSubnet{tempSubnet, lease})
 }
@@ -0,0 +1,86 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+)
+
+type DNSMasqTestSuite struct {
+	suite.Suite
+}
+
+func (suite *DNSMasqTestSuite) TearDownTest() {
+	viper.Reset()
+}
+
+func (suite *DNSMasqTestSuite) TestDHCPLeaseTime_Default() {
+	lease, err := DHCPLeaseTime(viper.GetViper(), "NMN")
+	suite.NoError(err)
+	suite.Equal(DefaultDHCPLeaseTime, lease)
+}
+
+func (suite *DNSMasqTestSuite) TestDHCPLeaseTime_Invalid() {
+	viper.Set("nmn-dhcp-lease", "10 minutes")
+	_, err := DHCPLeaseTime(viper.GetViper(), "NMN")
+	suite.Equal(errors.New(`invalid nmn-dhcp-lease "10 minutes", must be a number optionally followed by s, m, h, d or w, or infinite`), err)
+}
+
+func (suite *DNSMasqTestSuite) TestWriteConfig_CustomLease() {
+	viper.Set("nmn-dhcp-lease", "2h")
+
+	networks := testBasecampNetworks()
+	nmnBootstrap, _ := networks["NMN"].LookUpSubnet("bootstrap_dhcp")
+	suite.NoError(nmnBootstrap.UpdateDHCPRange(false))
+
+	dir, err := ioutil.TempDir("", "dnsmasq")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+	suite.NoError(os.Mkdir(filepath.Join(dir, "dnsmasq.d"), 0755))
+
+	tpl := template.Must(template.New("nmnconfig").Parse(string(NMNConfigTemplate)))
+	suite.NoError(writeConfig("NMN", dir, *tpl, networks))
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "dnsmasq.d", "NMN.conf"))
+	suite.NoError(err)
+	suite.Contains(string(contents), "dhcp-range=interface:bond0.nmn0,"+nmnBootstrap.DHCPStart.String()+","+nmnBootstrap.DHCPEnd.String()+",2h\n")
+}
+
+func (suite *DNSMasqTestSuite) TestWriteConfig_DHCPExclusions() {
+	networks := testBasecampNetworks()
+	nmnBootstrap, _ := networks["NMN"].LookUpSubnet("bootstrap_dhcp")
+	_, external, _ := net.ParseCIDR("10.252.1.64/26")
+	nmnBootstrap.DHCPExclusions = []net.IPNet{*external}
+	suite.NoError(nmnBootstrap.UpdateDHCPRange(false))
+
+	dir, err := ioutil.TempDir("", "dnsmasq")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+	suite.NoError(os.Mkdir(filepath.Join(dir, "dnsmasq.d"), 0755))
+
+	tpl := template.Must(template.New("nmnconfig").Parse(string(NMNConfigTemplate)))
+	suite.NoError(writeConfig("NMN", dir, *tpl, networks))
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "dnsmasq.d", "NMN.conf"))
+	suite.NoError(err)
+	suite.Contains(string(contents), "dhcp-range=interface:bond0.nmn0,"+nmnBootstrap.DHCPStart.String()+",10.252.1.63,10m\n"+
+		"dhcp-range=interface:bond0.nmn0,10.252.1.128,10.252.1.254,10m\n")
+}
+
+func TestDNSMasqTestSuite(t *testing.T) {
+	suite.Run(t, new(DNSMasqTestSuite))
+}
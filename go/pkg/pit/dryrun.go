@@ -0,0 +1,22 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+)
+
+// PlannedWrite is a file the pit write helpers would have written in dry-run mode
+type PlannedWrite = csiFiles.PlannedWrite
+
+// SetDryRun makes the pit write helpers record the files they would write instead of writing them
+func SetDryRun(enabled bool) {
+	csiFiles.SetDryRun(enabled)
+}
+
+// PlannedWrites lists the files recorded since dry-run mode was turned on
+func PlannedWrites() []PlannedWrite {
+	return csiFiles.PlannedWrites()
+}
@@ -0,0 +1,46 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type DryRunTestSuite struct {
+	suite.Suite
+}
+
+func (suite *DryRunTestSuite) TestWriteAnsibleInventory_DryRun() {
+	dir, err := ioutil.TempDir("", "dryrun")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+
+	SetDryRun(true)
+	defer SetDryRun(false)
+
+	path := filepath.Join(dir, "inventory.ini")
+	suite.NoError(WriteAnsibleInventory(path, testBasecampNCNs()[:1], testBasecampNetworks()))
+
+	planned := PlannedWrites()
+	suite.Len(planned, 1)
+	suite.Equal(path, planned[0].Path)
+	suite.NotZero(planned[0].Size)
+
+	entries, err := ioutil.ReadDir(dir)
+	suite.NoError(err)
+	suite.Empty(entries)
+}
+
+func TestDryRunTestSuite(t *testing.T) {
+	suite.Run(t, new(DryRunTestSuite))
+}
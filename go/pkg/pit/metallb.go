@@ -24,7 +24,7 @@
 package pit
 
 import (
-	"log"
+	"fmt"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -83,7 +83,7 @@ type MetalLBConfigMap struct {
 }
 
 // GetMetalLBConfig gathers the information for the metallb config map
-func GetMetalLBConfig(v *viper.Viper, networks map[string]*csi.IPV4Network, switches []*csi.ManagementSwitch) MetalLBConfigMap {
+func GetMetalLBConfig(v *viper.Viper, networks map[string]*csi.IPV4Network, switches []*csi.ManagementSwitch) (MetalLBConfigMap, error) {
 
 	var configStruct MetalLBConfigMap
 
@@ -132,26 +132,54 @@ func GetMetalLBConfig(v *viper.Viper, networks map[string]*csi.IPV4Network, swit
 		}
 	}
 
-	configStruct.PeerSwitches = getMetalLBPeerSwitches(bgpPeers, configStruct)
+	if err := validateExpectedSpines(v.GetInt("expect-spines"), switches); err != nil {
+		return configStruct, err
+	}
+
+	peerSwitches, err := getMetalLBPeerSwitches(bgpPeers, configStruct)
+	if err != nil {
+		return configStruct, err
+	}
+	configStruct.PeerSwitches = peerSwitches
 
-	return configStruct
+	return configStruct, nil
 }
 
 // WriteMetalLBConfigMap creates the yaml configmap
-func WriteMetalLBConfigMap(path string, v *viper.Viper, networks map[string]*csi.IPV4Network, switches []*csi.ManagementSwitch) {
+func WriteMetalLBConfigMap(path string, v *viper.Viper, networks map[string]*csi.IPV4Network, switches []*csi.ManagementSwitch) error {
 
 	tpl, err := template.New("mtllbconfigmap").Parse(string(MetalLBConfigMapTemplate))
 	if err != nil {
-		log.Printf("The template failed to render because: %v \n", err)
+		return fmt.Errorf("the template failed to render because: %v", err)
+	}
+
+	configStruct, err := GetMetalLBConfig(v, networks, switches)
+	if err != nil {
+		return err
 	}
 
-	configStruct := GetMetalLBConfig(v, networks, switches)
+	return csiFiles.WriteTemplate(filepath.Join(path, "metallb.yaml"), tpl, configStruct)
+}
 
-	csiFiles.WriteTemplate(filepath.Join(path, "metallb.yaml"), tpl, configStruct)
+// validateExpectedSpines verifies that switch_metadata.csv has the expected number of spine switches, zero skips the check
+func validateExpectedSpines(expected int, switches []*csi.ManagementSwitch) error {
+	if expected <= 0 {
+		return nil
+	}
+	var spines int
+	for _, mySwitch := range switches {
+		if mySwitch.SwitchType == csi.ManagementSwitchTypeSpine {
+			spines++
+		}
+	}
+	if spines != expected {
+		return fmt.Errorf("expect-spines: expected %d spine switches but %d are defined in switch_metadata.csv", expected, spines)
+	}
+	return nil
 }
 
 // getMetalLBPeerSwitches returns a list of switches  that should be used as metallb peers
-func getMetalLBPeerSwitches(bgpPeers []string, configStruct MetalLBConfigMap) []PeerDetail {
+func getMetalLBPeerSwitches(bgpPeers []string, configStruct MetalLBConfigMap) ([]PeerDetail, error) {
 
 	switchTypeMap := map[string][]PeerDetail{
 		"spine": configStruct.SpineSwitches,
@@ -162,13 +190,13 @@ func getMetalLBPeerSwitches(bgpPeers []string, configStruct MetalLBConfigMap) []
 	for _, peerType := range bgpPeers {
 		if peerSwitches, ok := switchTypeMap[peerType]; ok {
 			if len(peerSwitches) == 0 {
-				log.Fatalf("bgp-peer-types: %s specified but none defined in switch_metadata.csv\n", peerType)
+				return nil, fmt.Errorf("bgp-peer-types: %s specified but none defined in switch_metadata.csv", peerType)
 			}
 			configStruct.PeerSwitches = append(configStruct.PeerSwitches, peerSwitches...)
 		} else {
-			log.Fatalf("bgp-peer-types: unrecognized option: %s\n", peerType)
+			return nil, fmt.Errorf("bgp-peer-types: unrecognized option: %s", peerType)
 		}
 	}
 
-	return configStruct.PeerSwitches
+	return configStruct.PeerSwitches, nil
 }
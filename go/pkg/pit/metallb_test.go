@@ -0,0 +1,46 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Cray-HPE/csm-common/go/pkg/csi"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+)
+
+type MetalLBTestSuite struct {
+	suite.Suite
+}
+
+func testMetalLBSwitches() []*csi.ManagementSwitch {
+	return []*csi.ManagementSwitch{
+		{Xname: "x3000c0h33s1", Name: "sw-spine-001", SwitchType: csi.ManagementSwitchTypeSpine},
+		{Xname: "x3000c0w14", Name: "sw-leaf-bmc-001", SwitchType: csi.ManagementSwitchTypeLeafBMC},
+	}
+}
+
+func (suite *MetalLBTestSuite) TestGetMetalLBConfig_ExpectSpines() {
+	v := viper.New()
+	v.Set("expect-spines", 1)
+	_, err := GetMetalLBConfig(v, map[string]*csi.IPV4Network{}, testMetalLBSwitches())
+	suite.NoError(err)
+}
+
+func (suite *MetalLBTestSuite) TestGetMetalLBConfig_MissingSpine() {
+	v := viper.New()
+	v.Set("expect-spines", 2)
+	_, err := GetMetalLBConfig(v, map[string]*csi.IPV4Network{}, testMetalLBSwitches())
+	suite.Equal(errors.New("expect-spines: expected 2 spine switches but 1 are defined in switch_metadata.csv"), err)
+}
+
+func TestMetalLBTestSuite(t *testing.T) {
+	suite.Run(t, new(MetalLBTestSuite))
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"text/template"
 
@@ -15,6 +16,8 @@ import (
 
 	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
 	"github.com/Cray-HPE/csm-common/go/pkg/csi"
+	"github.com/Cray-HPE/csm-common/go/pkg/ipam"
+	"github.com/Cray-HPE/csm-common/go/pkg/logging"
 )
 
 // WriteCPTNetworkConfig writes the Network Configuration details for the installation node  (PIT)
@@ -43,13 +46,32 @@ func WriteCPTNetworkConfig(path string, v *viper.Viper, ncn csi.LogicalNCN, shas
 		Bond1 string
 		Mask  string
 		CIDR  string
+		MTU   int16
 	}{
 		Bond0: strings.Split(v.GetString("install-ncn-bond-members"), ",")[0],
 		Bond1: strings.Split(v.GetString("install-ncn-bond-members"), ",")[1],
 		Mask:  bond0Net.Mask,
 		CIDR:  bond0Net.CIDR,
+		MTU:   bootstrapMTU(shastaNetworks, "MTL"),
+	}
+	siteGW := v.GetString("site-gw")
+	if siteGW == "" {
+		derived, err := DeriveSiteGateway(v.GetString("site-ip"))
+		if err != nil {
+			return err
+		}
+		logging.Warnf("site-gw was not provided, defaulting to %v from the site-ip network", derived)
+		siteGW = derived.String()
+	}
+	if err := ValidateSiteGateway(v.GetString("site-ip"), siteGW); err != nil {
+		return err
+	}
+	if err := ValidateSiteNIC(v.GetString("site-nic")); err != nil {
+		return err
+	}
+	if err := csiFiles.WriteTemplate(filepath.Join(path, "ifcfg-bond0"), template.Must(template.New("bond0").Parse(string(Bond0ConfigTemplate))), bond0Struct); err != nil {
+		return err
 	}
-	csiFiles.WriteTemplate(filepath.Join(path, "ifcfg-bond0"), template.Must(template.New("bond0").Parse(string(Bond0ConfigTemplate))), bond0Struct)
 	siteNetDef := strings.Split(v.GetString("site-ip"), "/")
 	lan0struct := struct {
 		Nic, IP, IPPrefix string
@@ -62,29 +84,97 @@ func WriteCPTNetworkConfig(path string, v *viper.Viper, ncn csi.LogicalNCN, shas
 		CIDR    string
 		Mask    string
 		Gateway string
-	}{"default", "-", v.GetString("site-gw")}
+	}{"default", "-", siteGW}
 
-	csiFiles.WriteTemplate(filepath.Join(path, "ifcfg-lan0"), template.Must(template.New("lan0").Parse(string(Lan0ConfigTemplate))), lan0struct)
+	if err := csiFiles.WriteTemplate(filepath.Join(path, "ifcfg-lan0"), template.Must(template.New("lan0").Parse(string(Lan0ConfigTemplate))), lan0struct); err != nil {
+		return err
+	}
 	lan0sysconfig := struct {
 		SiteDNS string
 	}{
 		v.GetString("site-dns"),
 	}
-	csiFiles.WriteTemplate(filepath.Join(path, "config"), template.Must(template.New("netcofig").Parse(string(sysconfigNetworkConfigTemplate))), lan0sysconfig)
-	csiFiles.WriteTemplate(filepath.Join(path, "ifroute-lan0"), template.Must(template.New("vlan").Parse(string(VlanRouteTemplate))), []interface{}{lan0RouteStruct})
+	if err := csiFiles.WriteTemplate(filepath.Join(path, "config"), template.Must(template.New("netcofig").Parse(string(sysconfigNetworkConfigTemplate))), lan0sysconfig); err != nil {
+		return err
+	}
+	if err := csiFiles.WriteTemplate(filepath.Join(path, "ifroute-lan0"), template.Must(template.New("vlan").Parse(string(VlanRouteTemplate))), []interface{}{lan0RouteStruct}); err != nil {
+		return err
+	}
 	for _, network := range ncn.Networks {
 		if stringInSlice(network.NetworkName, csi.ValidNetNames) {
 			if network.Vlan != 0 && network.NetworkName != "CHN" {
-				csiFiles.WriteTemplate(filepath.Join(path, fmt.Sprintf("ifcfg-bond0.%s0", strings.ToLower(network.NetworkName))), template.Must(template.New("vlan").Parse(string(VlanConfigTemplate))), network)
+				if err := csiFiles.WriteTemplate(filepath.Join(path, fmt.Sprintf("ifcfg-bond0.%s0", strings.ToLower(network.NetworkName))), template.Must(template.New("vlan").Parse(string(VlanConfigTemplate))), ncnInterface{network, bootstrapMTU(shastaNetworks, network.NetworkName)}); err != nil {
+					return err
+				}
 			}
 			if network.NetworkName == "NMN" {
-				csiFiles.WriteTemplate(filepath.Join(path, fmt.Sprintf("ifroute-bond0.%s0", strings.ToLower(network.NetworkName))), template.Must(template.New("vlan").Parse(string(VlanRouteTemplate))), []Route{metalLBRoute})
+				if err := csiFiles.WriteTemplate(filepath.Join(path, fmt.Sprintf("ifroute-bond0.%s0", strings.ToLower(network.NetworkName))), template.Must(template.New("vlan").Parse(string(VlanRouteTemplate))), []Route{metalLBRoute}); err != nil {
+					return err
+				}
 			}
 		}
 	}
 	return nil
 }
 
+// ncnInterface is the data passed to VlanConfigTemplate, an NCN network along with the MTU of its subnet
+type ncnInterface struct {
+	csi.NCNNetwork
+	MTU int16
+}
+
+// bootstrapMTU returns the MTU of the bootstrap_dhcp subnet of a network, which holds the addresses of the NCNs.
+// Zero leaves the MTU out of the interface configuration.
+func bootstrapMTU(shastaNetworks map[string]*csi.IPV4Network, netName string) int16 {
+	network, ok := shastaNetworks[netName]
+	if !ok {
+		return 0
+	}
+	subnet, err := network.LookUpSubnet("bootstrap_dhcp")
+	if err != nil {
+		return network.MTU
+	}
+	return network.SubnetMTU(subnet)
+}
+
+// ValidateSiteGateway verifies that the site gateway is within the site-ip network
+func ValidateSiteGateway(siteIP, siteGW string) error {
+	_, siteNet, err := net.ParseCIDR(siteIP)
+	if err != nil {
+		return fmt.Errorf("site-ip %q is not a valid CIDR: %v", siteIP, err)
+	}
+	gateway := net.ParseIP(siteGW)
+	if gateway == nil {
+		return fmt.Errorf("site-gw %q is not a valid IP address", siteGW)
+	}
+	if !ipam.Contains(*siteNet, net.IPNet{IP: gateway, Mask: net.CIDRMask(32, 32)}) {
+		return fmt.Errorf("site-gw %v is not within the site-ip network %v", gateway, siteNet)
+	}
+	return nil
+}
+
+// siteNICPattern matches the names Linux accepts for an interface, at most 15 characters and none of
+// whitespace, / or :
+var siteNICPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,15}$`)
+
+// ValidateSiteNIC verifies that site-nic is a plausible Linux interface name.  Whether the NIC exists can
+// only be seen on the PIT itself, but a name that could never exist is caught before the lan0 bridge is written.
+func ValidateSiteNIC(nic string) error {
+	if !siteNICPattern.MatchString(nic) || nic == "." || nic == ".." {
+		return fmt.Errorf("site-nic %q is not a valid interface name, expected up to 15 letters, digits, '.', '-' or '_' such as em1 or p1p1", nic)
+	}
+	return nil
+}
+
+// DeriveSiteGateway returns the first host address of the site-ip network
+func DeriveSiteGateway(siteIP string) (net.IP, error) {
+	_, siteNet, err := net.ParseCIDR(siteIP)
+	if err != nil {
+		return nil, fmt.Errorf("site-ip %q is not a valid CIDR: %v", siteIP, err)
+	}
+	return ipam.Add(siteNet.IP, 1), nil
+}
+
 // VlanConfigTemplate is the text/template to bootstrap the install cd
 var VlanConfigTemplate = []byte(`
 NAME='{{.FullName}}'
@@ -93,6 +183,9 @@ NAME='{{.FullName}}'
 BOOTPROTO='static'
 IPADDR='{{.CIDR}}'    # i.e. '192.168.80.1/20'
 PREFIXLEN='{{.Mask}}' # i.e. '20'
+{{- if .MTU}}
+MTU='{{.MTU}}'
+{{- end}}
 
 # CHANGE AT OWN RISK:
 ETHERDEVICE='bond0'
@@ -124,6 +217,9 @@ BONDING_SLAVE1='{{.Bond1}}'
 BOOTPROTO='static'
 IPADDR='{{.CIDR}}'    # i.e. '192.168.64.1/20'
 PREFIXLEN='{{.Mask}}' # i.e. '20'
+{{- if .MTU}}
+MTU='{{.MTU}}'
+{{- end}}
 
 # CHANGE AT OWN RISK:
 BONDING_MODULE_OPTS='mode=802.3ad miimon=100 lacp_rate=fast xmit_hash_policy=layer2+3'# DO NOT CHANGE THESE:
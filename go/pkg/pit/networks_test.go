@@ -0,0 +1,163 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+
+	"github.com/Cray-HPE/csm-common/go/pkg/csi"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+)
+
+type PITNetworksTestSuite struct {
+	suite.Suite
+}
+
+func (suite *PITNetworksTestSuite) TestValidateSiteGateway() {
+	suite.NoError(ValidateSiteGateway("172.30.52.72/20", "172.30.48.1"))
+}
+
+func (suite *PITNetworksTestSuite) TestValidateSiteGateway_Invalid() {
+	tests := []struct {
+		siteIP        string
+		siteGW        string
+		expectedError error
+	}{{
+		siteIP:        "172.30.52.72/20",
+		siteGW:        "172.30.64.1",
+		expectedError: errors.New("site-gw 172.30.64.1 is not within the site-ip network 172.30.48.0/20"),
+	}, {
+		siteIP:        "172.30.52.72",
+		siteGW:        "172.30.48.1",
+		expectedError: errors.New(`site-ip "172.30.52.72" is not a valid CIDR: invalid CIDR address: 172.30.52.72`),
+	}, {
+		siteIP:        "172.30.52.72/20",
+		siteGW:        "gateway",
+		expectedError: errors.New(`site-gw "gateway" is not a valid IP address`),
+	}}
+
+	for _, test := range tests {
+		suite.Equal(test.expectedError, ValidateSiteGateway(test.siteIP, test.siteGW))
+	}
+}
+
+func (suite *PITNetworksTestSuite) TestDeriveSiteGateway() {
+	siteGW, err := DeriveSiteGateway("172.30.52.72/20")
+	suite.NoError(err)
+	suite.Equal("172.30.48.1", siteGW.String())
+	suite.NoError(ValidateSiteGateway("172.30.52.72/20", siteGW.String()))
+}
+
+func (suite *PITNetworksTestSuite) TestDeriveSiteGateway_Invalid() {
+	_, err := DeriveSiteGateway("172.30.52.72")
+	suite.Equal(errors.New(`site-ip "172.30.52.72" is not a valid CIDR: invalid CIDR address: 172.30.52.72`), err)
+}
+
+func (suite *PITNetworksTestSuite) TestValidateSiteNIC() {
+	for _, nic := range []string{"em1", "p1p1", "enp65s0f0", "lan0.100", "eth_site-1"} {
+		suite.NoError(ValidateSiteNIC(nic), nic)
+	}
+}
+
+func (suite *PITNetworksTestSuite) TestValidateSiteNIC_Invalid() {
+	for _, nic := range []string{"", "em 1", "em1/0", "eth0:1", "..", "enp65s0f0np0-site"} {
+		suite.Equal(fmt.Errorf("site-nic %q is not a valid interface name, expected up to 15 letters, digits, '.', '-' or '_' such as em1 or p1p1", nic), ValidateSiteNIC(nic))
+	}
+}
+
+func (suite *PITNetworksTestSuite) TestVlanConfigTemplate_MTU() {
+	_, bootstrap, _ := net.ParseCIDR("10.252.1.0/24")
+	networks := map[string]*csi.IPV4Network{
+		"NMN": {Name: "NMN", CIDR: "10.252.0.0/17", MTU: 1500, Subnets: []*csi.IPV4Subnet{{Name: "bootstrap_dhcp", CIDR: *bootstrap, MTU: 9000}}},
+		"HMN": {Name: "HMN", CIDR: "10.254.0.0/17", MTU: 1500},
+	}
+	suite.Equal(int16(9000), bootstrapMTU(networks, "NMN"))
+	suite.Equal(int16(1500), bootstrapMTU(networks, "HMN"))
+	suite.Equal(int16(0), bootstrapMTU(networks, "CAN"))
+
+	tpl := template.Must(template.New("vlan").Parse(string(VlanConfigTemplate)))
+	network := csi.NCNNetwork{NetworkName: "NMN", FullName: "Node Management Network", CIDR: "10.252.1.4/17", Mask: "17", Vlan: 2}
+	var rendered bytes.Buffer
+	suite.NoError(tpl.Execute(&rendered, ncnInterface{network, bootstrapMTU(networks, "NMN")}))
+	suite.Contains(rendered.String(), "PREFIXLEN='17' # i.e. '20'\nMTU='9000'\n\n# CHANGE AT OWN RISK:")
+
+	rendered.Reset()
+	suite.NoError(tpl.Execute(&rendered, ncnInterface{network, 0}))
+	suite.NotContains(rendered.String(), "MTU=")
+}
+
+func testCPTNetworkConfig() (*viper.Viper, csi.LogicalNCN, map[string]*csi.IPV4Network) {
+	v := viper.New()
+	v.Set("install-ncn-bond-members", "p1p1,p10p1")
+	v.Set("site-ip", "172.30.52.72/20")
+	v.Set("site-gw", "172.30.48.1")
+	v.Set("site-nic", "em1")
+	v.Set("site-dns", "172.30.84.40")
+
+	_, hardware, _ := net.ParseCIDR("10.252.0.0/24")
+	networks := map[string]*csi.IPV4Network{
+		"NMNLB": {Name: "NMNLB", CIDR: "10.92.100.0/24"},
+		"NMN": {Name: "NMN", CIDR: "10.252.0.0/17", Subnets: []*csi.IPV4Subnet{
+			{Name: "network_hardware", CIDR: *hardware, Gateway: net.ParseIP("10.252.0.1")},
+		}},
+	}
+	ncn := csi.LogicalNCN{Hostname: "ncn-m001", Networks: []csi.NCNNetwork{
+		{NetworkName: "MTL", CIDR: "10.1.1.2/16", Mask: "16"},
+		{NetworkName: "NMN", FullName: "Node Management Network", CIDR: "10.252.1.4/17", Mask: "17", Vlan: 2},
+	}}
+	return v, ncn, networks
+}
+
+func (suite *PITNetworksTestSuite) TestWriteCPTNetworkConfig() {
+	dir, err := ioutil.TempDir("", "pit-networks")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+
+	v, ncn, networks := testCPTNetworkConfig()
+	suite.NoError(WriteCPTNetworkConfig(dir, v, ncn, networks))
+	for _, name := range []string{"ifcfg-bond0", "ifcfg-lan0", "config", "ifroute-lan0", "ifcfg-bond0.nmn0", "ifroute-bond0.nmn0"} {
+		suite.FileExists(filepath.Join(dir, name))
+	}
+}
+
+func (suite *PITNetworksTestSuite) TestWriteCPTNetworkConfig_DerivedSiteGateway() {
+	dir, err := ioutil.TempDir("", "pit-networks")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+
+	v, ncn, networks := testCPTNetworkConfig()
+	v.Set("site-gw", "")
+	suite.NoError(WriteCPTNetworkConfig(dir, v, ncn, networks))
+	suite.Empty(v.GetString("site-gw"))
+
+	route, err := ioutil.ReadFile(filepath.Join(dir, "ifroute-lan0"))
+	suite.NoError(err)
+	suite.Equal("default 172.30.48.1 - -\n", string(route))
+}
+
+func (suite *PITNetworksTestSuite) TestWriteCPTNetworkConfig_WriteError() {
+	dir, err := ioutil.TempDir("", "pit-networks")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+
+	v, ncn, networks := testCPTNetworkConfig()
+	suite.Error(WriteCPTNetworkConfig(filepath.Join(dir, "missing"), v, ncn, networks))
+}
+
+func TestPITNetworksTestSuite(t *testing.T) {
+	suite.Run(t, new(PITNetworksTestSuite))
+}
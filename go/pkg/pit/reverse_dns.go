@@ -0,0 +1,167 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+	"github.com/Cray-HPE/csm-common/go/pkg/csi"
+	"github.com/Cray-HPE/csm-common/go/pkg/ipam"
+)
+
+// ReverseZoneTemplate is a BIND zone file for one reverse zone
+var ReverseZoneTemplate = []byte(`; csm-generated reverse zone for {{.Name}}. Do not modify--changes can be overwritten
+$TTL 3600
+@	IN	SOA	{{.Nameserver}}. hostmaster.{{.Nameserver}}. (
+		1	; serial
+		3600	; refresh
+		600	; retry
+		86400	; expire
+		3600 )	; minimum
+@	IN	NS	{{.Nameserver}}.
+{{- range .Records}}
+{{.Owner}}	IN	PTR	{{.Target}}
+{{- end}}
+`)
+
+// PTRRecord points the address named by Owner, relative to its zone, at the reservation name Target
+type PTRRecord struct {
+	IP     net.IP
+	Owner  string
+	Target string
+}
+
+// ReverseZone holds the PTR records of a /8, /16 or /24 in-addr.arpa zone
+type ReverseZone struct {
+	// Prefix is the network part of the zone in dotted order, e.g. 10.252 for 252.10.in-addr.arpa
+	Prefix     string
+	Name       string
+	Nameserver string
+	Records    []PTRRecord
+}
+
+// FileName is the db.<reversed-prefix> name of the zone file
+func (zone ReverseZone) FileName() string {
+	return "db." + strings.TrimSuffix(zone.Name, ".in-addr.arpa")
+}
+
+// reverseZonePrefix rounds a subnet out to the octet boundary of its zone.  Subnets of /24 or longer get a /24
+// zone, /16 to /23 a /16 zone, and anything larger a /8 zone.
+func reverseZonePrefix(cidr net.IPNet) string {
+	ones, _ := cidr.Mask.Size()
+	octets := strings.Split(cidr.IP.Mask(cidr.Mask).To4().String(), ".")
+	switch {
+	case ones >= 24:
+		return strings.Join(octets[:3], ".")
+	case ones >= 16:
+		return strings.Join(octets[:2], ".")
+	}
+	return octets[0]
+}
+
+func reverseOctets(octets []string) string {
+	reversed := make([]string, len(octets))
+	for i, octet := range octets {
+		reversed[len(octets)-1-i] = octet
+	}
+	return strings.Join(reversed, ".")
+}
+
+// MakeReverseZones builds the reverse zones for the reservations of every IPv4 subnet.  A /24 zone that falls
+// inside a /16 zone, as the subnets of a supernet do, is folded into the /16 so the zones never overlap.
+// Every address gets one PTR record for the Name of its first reservation, in network name order, and the
+// target is <name>.<network>.
+func MakeReverseZones(networks map[string]*csi.IPV4Network, nameserver string) []ReverseZone {
+	var netNames []string
+	for name := range networks {
+		netNames = append(netNames, name)
+	}
+	sort.Strings(netNames)
+
+	prefixes := map[string]bool{}
+	for _, netName := range netNames {
+		for _, subnet := range networks[netName].Subnets {
+			if subnet.CIDR.IP.To4() != nil {
+				prefixes[reverseZonePrefix(subnet.CIDR)] = true
+			}
+		}
+	}
+	// zoneFor returns the shortest generated prefix containing the address
+	zoneFor := func(ip net.IP) string {
+		octets := strings.Split(ip.To4().String(), ".")
+		for i := 1; i <= 3; i++ {
+			if prefix := strings.Join(octets[:i], "."); prefixes[prefix] {
+				return prefix
+			}
+		}
+		return ""
+	}
+
+	zones := map[string]*ReverseZone{}
+	seen := map[string]bool{}
+	for _, netName := range netNames {
+		for _, subnet := range networks[netName].Subnets {
+			if subnet.CIDR.IP.To4() == nil {
+				continue
+			}
+			for _, reservation := range subnet.IPReservations {
+				if reservation.IPAddress.To4() == nil || seen[reservation.IPAddress.String()] {
+					continue
+				}
+				seen[reservation.IPAddress.String()] = true
+				prefix := zoneFor(reservation.IPAddress)
+				if prefix == "" {
+					continue
+				}
+				zone, ok := zones[prefix]
+				if !ok {
+					prefixOctets := strings.Split(prefix, ".")
+					zone = &ReverseZone{
+						Prefix:     prefix,
+						Name:       reverseOctets(prefixOctets) + ".in-addr.arpa",
+						Nameserver: nameserver,
+					}
+					zones[prefix] = zone
+				}
+				octets := strings.Split(reservation.IPAddress.To4().String(), ".")
+				zone.Records = append(zone.Records, PTRRecord{
+					IP:     reservation.IPAddress,
+					Owner:  reverseOctets(octets[len(strings.Split(prefix, ".")):]),
+					Target: fmt.Sprintf("%s.%s.", reservation.Name, strings.ToLower(netName)),
+				})
+			}
+		}
+	}
+
+	var out []ReverseZone
+	for _, zone := range zones {
+		sort.Slice(zone.Records, func(i, j int) bool {
+			return ipam.IPLessThan(zone.Records[i].IP.To4(), zone.Records[j].IP.To4())
+		})
+		out = append(out, *zone)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// WriteReverseZones writes a db.<reversed-prefix> zone file into dir for each zone
+func WriteReverseZones(dir string, zones []ReverseZone) error {
+	tpl, err := template.New("reversezone").Parse(string(ReverseZoneTemplate))
+	if err != nil {
+		return err
+	}
+	for _, zone := range zones {
+		if err := csiFiles.WriteTemplate(filepath.Join(dir, zone.FileName()), tpl, zone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Cray-HPE/csm-common/go/pkg/csi"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReverseDNSTestSuite struct {
+	suite.Suite
+}
+
+func testReverseDNSNetworks() map[string]*csi.IPV4Network {
+	networks := testBasecampNetworks()
+	// The NMN bootstrap_dhcp subnet is a supernet, so the NMN gets a single /16 zone
+	nmnBootstrap, _ := networks["NMN"].LookUpSubnet("bootstrap_dhcp")
+	nmnBootstrap.CIDR.Mask = testSubnet("supernet", "10.252.0.0/17").CIDR.Mask
+	nmnHardware := testSubnet("network_hardware", "10.252.0.0/24")
+	nmnHardware.AddReservationWithIP("sw-spine-001", "10.252.0.2", "x3000c0h33s1")
+	networks["NMN"].Subnets = append(networks["NMN"].Subnets, nmnHardware)
+	return networks
+}
+
+func (suite *ReverseDNSTestSuite) TestMakeReverseZones() {
+	zones := MakeReverseZones(testReverseDNSNetworks(), "ncn-m001.nmn")
+
+	suite.Len(zones, 3)
+	suite.Equal("1.254.10.in-addr.arpa", zones[0].Name)
+	suite.Equal("db.1.254.10", zones[0].FileName())
+	suite.Equal([]PTRRecord{{IP: zones[0].Records[0].IP, Owner: "4", Target: "ncn-m001-mgmt.hmn."}}, zones[0].Records)
+	suite.Equal("100.92.10.in-addr.arpa", zones[1].Name)
+	suite.Equal([]string{"71"}, ptrOwners(zones[1]))
+
+	// The /24 network_hardware subnet is folded into the /16 zone of the NMN supernet
+	suite.Equal("252.10.in-addr.arpa", zones[2].Name)
+	suite.Equal([]string{"2.0", "2.1", "3.1", "10.1"}, ptrOwners(zones[2]))
+	suite.Equal("sw-spine-001.nmn.", zones[2].Records[0].Target)
+	suite.Equal("kubeapi-vip.nmn.", zones[2].Records[1].Target)
+}
+
+func (suite *ReverseDNSTestSuite) TestWriteReverseZones() {
+	dir, err := ioutil.TempDir("", "reverse-dns")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+
+	suite.NoError(WriteReverseZones(dir, MakeReverseZones(testReverseDNSNetworks(), "ncn-m001.nmn")))
+	db, err := ioutil.ReadFile(filepath.Join(dir, "db.252.10"))
+	suite.NoError(err)
+	suite.Contains(string(db), "@\tIN\tNS\tncn-m001.nmn.\n")
+	suite.Contains(string(db), "10.1\tIN\tPTR\tncn-m001.nmn.\n")
+	suite.FileExists(filepath.Join(dir, "db.100.92.10"))
+}
+
+func ptrOwners(zone ReverseZone) []string {
+	var owners []string
+	for _, record := range zone.Records {
+		owners = append(owners, record.Owner)
+	}
+	return owners
+}
+
+func TestReverseDNSTestSuite(t *testing.T) {
+	suite.Run(t, new(ReverseDNSTestSuite))
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"fmt"
+	"strings"
+
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+)
+
+// DefaultFirstNodeSuffix is the hostname suffix of the NCN that installs the rest of its subrole
+const DefaultFirstNodeSuffix = "001"
+
+// The ways the runcmd of a RunCMDEntry is combined with the built-in runcmd of the NCN
+const (
+	RunCMDMergeReplace = "replace"
+	RunCMDMergePrepend = "prepend"
+	RunCMDMergeAppend  = "append"
+)
+
+// RunCMDEntry is the list of runcmd scripts for the NCNs matching its role, subrole and first-node settings.
+// An empty role or subrole matches any NCN.  Merge decides whether the scripts replace the built-in runcmd,
+// which is the default, or run before (prepend) or after (append) it.
+type RunCMDEntry struct {
+	Role      string   `yaml:"role"`
+	Subrole   string   `yaml:"subrole"`
+	FirstNode bool     `yaml:"first-node"`
+	Merge     string   `yaml:"merge"`
+	RunCMD    []string `yaml:"runcmd"`
+}
+
+// RunCMDConfig is the file named by runcmd-config.  The first matching entry wins, so the more
+// specific entries belong at the top.
+//
+//	first-node-suffix: "001"
+//	runcmd:
+//	  - subrole: Storage
+//	    first-node: true
+//	    runcmd:
+//	      - /srv/cray/scripts/common/storage-ceph-cloudinit.sh
+//	  - subrole: Storage
+//	    runcmd:
+//	      - /srv/cray/scripts/metal/install.sh
+//	  - role: Management
+//	    merge: append
+//	    runcmd:
+//	      - /srv/cray/scripts/site/post-boot.sh
+type RunCMDConfig struct {
+	FirstNodeSuffix string        `yaml:"first-node-suffix"`
+	Entries         []RunCMDEntry `yaml:"runcmd"`
+}
+
+// DefaultRunCMDConfig is the built-in runcmd used when no runcmd-config is given
+func DefaultRunCMDConfig() RunCMDConfig {
+	return RunCMDConfig{
+		FirstNodeSuffix: DefaultFirstNodeSuffix,
+		Entries: []RunCMDEntry{
+			{Subrole: "Storage", FirstNode: true, RunCMD: cephRunCMD},
+			{Subrole: "Storage", RunCMD: cephWorkerRunCMD},
+			{Subrole: "Master", RunCMD: masterRunCMD},
+			{Subrole: "Worker", RunCMD: workerRunCMD},
+			{RunCMD: k8sRunCMD},
+		},
+	}
+}
+
+// LoadRunCMDConfig reads and validates a runcmd configuration file
+func LoadRunCMDConfig(path string) (RunCMDConfig, error) {
+	var config RunCMDConfig
+	if err := csiFiles.ReadYAMLConfig(path, &config); err != nil {
+		return config, fmt.Errorf("unable to read %s: %v", path, err)
+	}
+	if config.FirstNodeSuffix == "" {
+		config.FirstNodeSuffix = DefaultFirstNodeSuffix
+	}
+	if len(config.Entries) == 0 {
+		return config, fmt.Errorf("%s does not define any runcmd entries", path)
+	}
+	for i, entry := range config.Entries {
+		if len(entry.RunCMD) == 0 {
+			return config, fmt.Errorf("runcmd entry %d (role %q, subrole %q) in %s has no commands", i+1, entry.Role, entry.Subrole, path)
+		}
+		switch entry.Merge {
+		case "", RunCMDMergeReplace, RunCMDMergePrepend, RunCMDMergeAppend:
+		default:
+			return config, fmt.Errorf("runcmd entry %d (role %q, subrole %q) in %s has merge %q, expected %s, %s or %s",
+				i+1, entry.Role, entry.Subrole, path, entry.Merge, RunCMDMergeReplace, RunCMDMergePrepend, RunCMDMergeAppend)
+		}
+	}
+	return config, nil
+}
+
+// matches reports whether the entry applies to an NCN
+func (entry RunCMDEntry) matches(role, subrole string, firstNode bool) bool {
+	if entry.Role != "" && !strings.EqualFold(entry.Role, role) {
+		return false
+	}
+	if entry.Subrole != "" && !strings.EqualFold(entry.Subrole, subrole) {
+		return false
+	}
+	return !entry.FirstNode || firstNode
+}
+
+// RunCMDForNCN returns the runcmd of the first entry that matches the NCN, merged with the built-in runcmd
+// as the entry asks.  NCNs that no entry matches get the built-in runcmd.  The result is always a copy, so
+// the caller may change it without touching the configuration or the defaults.
+func (config RunCMDConfig) RunCMDForNCN(role, subrole, hostname string) []string {
+	suffix := config.FirstNodeSuffix
+	if suffix == "" {
+		suffix = DefaultFirstNodeSuffix
+	}
+	firstNode := strings.HasSuffix(hostname, suffix)
+	for _, entry := range config.Entries {
+		if !entry.matches(role, subrole, firstNode) {
+			continue
+		}
+		switch entry.Merge {
+		case RunCMDMergePrepend:
+			return append(append([]string{}, entry.RunCMD...), defaultRunCMDForNCN(role, subrole, firstNode)...)
+		case RunCMDMergeAppend:
+			return append(defaultRunCMDForNCN(role, subrole, firstNode), entry.RunCMD...)
+		}
+		return append([]string{}, entry.RunCMD...)
+	}
+	return defaultRunCMDForNCN(role, subrole, firstNode)
+}
+
+// defaultRunCMDForNCN returns a copy of the runcmd DefaultRunCMDConfig gives the NCN.  firstNode is decided
+// by the suffix of the configuration in use, not DefaultFirstNodeSuffix.
+func defaultRunCMDForNCN(role, subrole string, firstNode bool) []string {
+	for _, entry := range DefaultRunCMDConfig().Entries {
+		if entry.matches(role, subrole, firstNode) {
+			return append([]string{}, entry.RunCMD...)
+		}
+	}
+	return append([]string{}, k8sRunCMD...)
+}
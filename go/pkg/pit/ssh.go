@@ -0,0 +1,74 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"fmt"
+	"text/template"
+
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+	"github.com/Cray-HPE/csm-common/go/pkg/csi"
+)
+
+// SSHConfigTemplate manages an ssh_config fragment for reaching the NCNs
+var SSHConfigTemplate = []byte(`# Management NCNs
+{{- range .}}
+
+Host {{.Host}}{{range .Aliases}} {{.}}{{end}}
+    HostName {{.HostName}}
+{{- end}}
+`)
+
+// SSHHost is a single Host block in an ssh_config fragment
+type SSHHost struct {
+	Host     string
+	HostName string
+	Aliases  []string
+}
+
+// MakeSSHConfigHosts builds ssh Host entries for each NCN from its NMN bootstrap_dhcp reservation
+func MakeSSHConfigHosts(ncns []csi.LogicalNCN, shastaNetworks map[string]*csi.IPV4Network) ([]SSHHost, error) {
+	nmnNetwork, ok := shastaNetworks["NMN"]
+	if !ok {
+		return nil, fmt.Errorf("couldn't find the NMN network")
+	}
+	nmnSubnet, err := nmnNetwork.LookUpSubnet("bootstrap_dhcp")
+	if err != nil {
+		return nil, err
+	}
+
+	var hosts []SSHHost
+	for _, ncn := range ncns {
+		rsrv := nmnSubnet.LookupReservation(ncn.Hostname)
+		if rsrv.IPAddress == nil {
+			return nil, fmt.Errorf("couldn't find an NMN reservation for %s", ncn.Hostname)
+		}
+		aliases := []string{}
+		for _, alias := range rsrv.Aliases {
+			if alias != ncn.Hostname {
+				aliases = append(aliases, alias)
+			}
+		}
+		if ncn.Xname != "" && !stringInSlice(ncn.Xname, aliases) {
+			aliases = append(aliases, ncn.Xname)
+		}
+		hosts = append(hosts, SSHHost{
+			Host:     ncn.Hostname,
+			HostName: rsrv.IPAddress.String(),
+			Aliases:  aliases,
+		})
+	}
+	return hosts, nil
+}
+
+// WriteSSHConfig writes an ssh_config fragment with a Host block for each NCN
+func WriteSSHConfig(path string, ncns []csi.LogicalNCN, shastaNetworks map[string]*csi.IPV4Network) error {
+	hosts, err := MakeSSHConfigHosts(ncns, shastaNetworks)
+	if err != nil {
+		return err
+	}
+	tpl, _ := template.New("sshconfig").Parse(string(SSHConfigTemplate))
+	return csiFiles.WriteTemplate(path, tpl, hosts)
+}
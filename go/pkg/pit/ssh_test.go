@@ -0,0 +1,64 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"text/template"
+
+	"github.com/Cray-HPE/csm-common/go/pkg/csi"
+	"github.com/stretchr/testify/suite"
+)
+
+type SSHConfigTestSuite struct {
+	suite.Suite
+}
+
+func testNMNNetworks() map[string]*csi.IPV4Network {
+	_, bootstrapNet, _ := net.ParseCIDR("10.252.1.0/24")
+	bootstrap := &csi.IPV4Subnet{
+		Name:    "bootstrap_dhcp",
+		CIDR:    *bootstrapNet,
+		Gateway: net.ParseIP("10.252.0.1"),
+	}
+	_, err := bootstrap.AddReservationWithIP("ncn-m001", "10.252.1.10", "x3000c0s1b0n0")
+	if err != nil {
+		panic(err)
+	}
+	bootstrap.IPReservations[0].Aliases = []string{"ncn-m001.nmn", "ncn-m001"}
+	return map[string]*csi.IPV4Network{
+		"NMN": {
+			Name:    "NMN",
+			CIDR:    "10.252.0.0/17",
+			Subnets: []*csi.IPV4Subnet{bootstrap},
+		},
+	}
+}
+
+func (suite *SSHConfigTestSuite) TestSSHConfigHostBlock() {
+	ncns := []csi.LogicalNCN{{Xname: "x3000c0s1b0n0", Hostname: "ncn-m001"}}
+	hosts, err := MakeSSHConfigHosts(ncns, testNMNNetworks())
+	suite.NoError(err)
+
+	var bs bytes.Buffer
+	tpl, _ := template.New("sshconfig").Parse(string(SSHConfigTemplate))
+	suite.NoError(tpl.Execute(&bs, hosts))
+	suite.Equal("# Management NCNs\n\nHost ncn-m001 ncn-m001.nmn x3000c0s1b0n0\n    HostName 10.252.1.10\n", bs.String())
+}
+
+func (suite *SSHConfigTestSuite) TestSSHConfigMissingReservation() {
+	ncns := []csi.LogicalNCN{{Xname: "x3000c0s2b0n0", Hostname: "ncn-m002"}}
+	_, err := MakeSSHConfigHosts(ncns, testNMNNetworks())
+	suite.EqualError(err, "couldn't find an NMN reservation for ncn-m002")
+}
+
+func TestSSHConfigTestSuite(t *testing.T) {
+	suite.Run(t, new(SSHConfigTestSuite))
+}
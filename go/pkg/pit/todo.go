@@ -0,0 +1,93 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+	"github.com/Cray-HPE/csm-common/go/pkg/csi"
+)
+
+// PlaceholderValue marks a generated value that must be replaced by hand
+const PlaceholderValue = "~FIXME~"
+
+// TodoTemplate renders the manual steps as a markdown checklist
+var TodoTemplate = []byte(`# Manual steps required after generation
+{{range .}}
+- [ ] **{{.Source}}**: {{.Description}}
+{{- end}}
+`)
+
+// ManualStep is a follow-up action an operator must take after the configuration has been generated
+type ManualStep struct {
+	Source      string `json:"source"`
+	Description string `json:"description"`
+}
+
+// ManualSteps collects the manual steps encountered while generating the configuration
+type ManualSteps struct {
+	Steps []ManualStep
+}
+
+// Add records a manual step
+func (m *ManualSteps) Add(source, format string, args ...interface{}) {
+	m.Steps = append(m.Steps, ManualStep{Source: source, Description: fmt.Sprintf(format, args...)})
+}
+
+// CheckCredential records a manual step when a credential is empty or still a placeholder
+func (m *ManualSteps) CheckCredential(name string, credential PasswordCredential) {
+	if credential.Username == "" || strings.Contains(credential.Username, PlaceholderValue) {
+		m.Add(name, "set the username, it is currently a placeholder")
+	}
+	if credential.Password == "" || strings.Contains(credential.Password, PlaceholderValue) {
+		m.Add(name, "set the password, it is currently a placeholder")
+	}
+}
+
+// CheckBasecampGlobals records a manual step for every basecamp global that still holds a placeholder
+func (m *ManualSteps) CheckBasecampGlobals(global map[string]interface{}) {
+	var keys []string
+	for key, value := range global {
+		if str, ok := value.(string); ok && strings.Contains(str, PlaceholderValue) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		m.Add("data.json", "replace the placeholder value of %s", key)
+	}
+}
+
+// CheckApplicationNodeConfig records a manual step for every application node prefix without a subrole
+func (m *ManualSteps) CheckApplicationNodeConfig(applicationNodeConfig csi.SLSGeneratorApplicationNodeConfig) {
+	var prefixes []string
+	for prefix, subrole := range applicationNodeConfig.PrefixHSMSubroles {
+		if subrole == csi.SubrolePlaceHolder {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		m.Add("application_node_config.yaml", "replace the %s subrole placeholder of the %s prefix", csi.SubrolePlaceHolder, prefix)
+	}
+}
+
+// Write writes the manual steps to todo.json and todo.md in the directory
+func (m *ManualSteps) Write(dir string) error {
+	steps := m.Steps
+	if steps == nil {
+		steps = []ManualStep{}
+	}
+	if err := csiFiles.WriteJSONConfig(filepath.Join(dir, "todo.json"), steps); err != nil {
+		return err
+	}
+	tpl, _ := template.New("todo").Parse(string(TodoTemplate))
+	return csiFiles.WriteTemplate(filepath.Join(dir, "todo.md"), tpl, steps)
+}
@@ -0,0 +1,65 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Cray-HPE/csm-common/go/pkg/csi"
+	"github.com/stretchr/testify/suite"
+)
+
+type ManualStepsTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ManualStepsTestSuite) TestCheckCredential_Placeholder() {
+	var steps ManualSteps
+	steps.CheckCredential("bootstrap-ncn-bmc", PasswordCredential{Username: "root", Password: PlaceholderValue})
+	suite.Equal([]ManualStep{{Source: "bootstrap-ncn-bmc", Description: "set the password, it is currently a placeholder"}}, steps.Steps)
+}
+
+func (suite *ManualStepsTestSuite) TestWrite() {
+	var steps ManualSteps
+	steps.CheckCredential("bootstrap-ncn-bmc", PasswordCredential{Username: "root"})
+	steps.CheckBasecampGlobals(map[string]interface{}{
+		"system-name":       "drax",
+		"site-domain":       "~FIXME~",
+		"num_storage_nodes": 3,
+	})
+	steps.CheckApplicationNodeConfig(csi.SLSGeneratorApplicationNodeConfig{
+		PrefixHSMSubroles: map[string]string{"vn": csi.SubrolePlaceHolder, "uan": "UAN"},
+	})
+
+	dir, err := ioutil.TempDir("", "todo")
+	suite.NoError(err)
+	defer os.RemoveAll(dir)
+	suite.NoError(steps.Write(dir))
+
+	var written []ManualStep
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "todo.json"))
+	suite.NoError(err)
+	suite.NoError(json.Unmarshal(contents, &written))
+	suite.Equal([]ManualStep{
+		{Source: "bootstrap-ncn-bmc", Description: "set the password, it is currently a placeholder"},
+		{Source: "data.json", Description: "replace the placeholder value of site-domain"},
+		{Source: "application_node_config.yaml", Description: "replace the ~fixme~ subrole placeholder of the vn prefix"},
+	}, written)
+
+	markdown, err := ioutil.ReadFile(filepath.Join(dir, "todo.md"))
+	suite.NoError(err)
+	suite.Contains(string(markdown), "- [ ] **bootstrap-ncn-bmc**: set the password, it is currently a placeholder")
+}
+
+func TestManualStepsTestSuite(t *testing.T) {
+	suite.Run(t, new(ManualStepsTestSuite))
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path"
+	"strconv"
+
+	csiFiles "github.com/Cray-HPE/csm-common/go/internal/files"
+)
+
+// WriteFilesEncodings are the content encodings understood by the cloud-init write_files module
+var WriteFilesEncodings = []string{"", "text/plain", "b64", "base64", "gzip", "gz", "gz+b64", "gzip+base64", "gz+base64", "gzip+b64"}
+
+// SiteWriteFile is a site provided write_files entry, limited to the listed subroles when there are any
+type SiteWriteFile struct {
+	WriteFiles `yaml:",inline"`
+	Subroles   []string `yaml:"subroles"`
+}
+
+// SiteWriteFilesConfig is the file named by write-files-config
+//
+//	write_files:
+//	  - path: /etc/pki/trust/anchors/site.crt
+//	    content: LS0tLS1CRUdJTi...
+//	    encoding: b64
+//	    owner: root:root
+//	    permissions: "0644"
+//	    subroles: [Worker]
+type SiteWriteFilesConfig struct {
+	WriteFiles []SiteWriteFile `yaml:"write_files"`
+}
+
+// LoadSiteWriteFiles reads and validates the site write_files entries
+func LoadSiteWriteFiles(path string) ([]SiteWriteFile, error) {
+	var config SiteWriteFilesConfig
+	if err := csiFiles.ReadYAMLConfig(path, &config); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %v", path, err)
+	}
+	for _, file := range config.WriteFiles {
+		if err := file.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return config.WriteFiles, nil
+}
+
+// Validate checks that the entry has an absolute path, a known encoding and octal permissions
+func (file SiteWriteFile) Validate() error {
+	if !path.IsAbs(file.Path) || path.Clean(file.Path) != file.Path {
+		return fmt.Errorf("write_files path %q must be a clean absolute path", file.Path)
+	}
+	if !stringInSlice(file.Encoding, WriteFilesEncodings) {
+		return fmt.Errorf("write_files encoding %q of %s is not supported by cloud-init", file.Encoding, file.Path)
+	}
+	if file.Encoding == "b64" || file.Encoding == "base64" {
+		if _, err := base64.StdEncoding.DecodeString(file.Content); err != nil {
+			return fmt.Errorf("write_files content of %s is not valid base64: %v", file.Path, err)
+		}
+	}
+	if file.Permissions != "" {
+		if _, err := strconv.ParseUint(file.Permissions, 8, 32); err != nil {
+			return fmt.Errorf("write_files permissions %q of %s must be octal", file.Permissions, file.Path)
+		}
+	}
+	return nil
+}
+
+// SiteWriteFilesForSubrole returns the entries that apply to an NCN of the subrole
+func SiteWriteFilesForSubrole(files []SiteWriteFile, subrole string) []WriteFiles {
+	var writeFiles []WriteFiles
+	for _, file := range files {
+		if len(file.Subroles) == 0 || stringInSlice(subrole, file.Subroles) {
+			writeFiles = append(writeFiles, file.WriteFiles)
+		}
+	}
+	return writeFiles
+}
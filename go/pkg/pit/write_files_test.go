@@ -0,0 +1,58 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package pit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type WriteFilesTestSuite struct {
+	suite.Suite
+}
+
+func (suite *WriteFilesTestSuite) TestSiteWriteFilesForSubrole() {
+	files := []SiteWriteFile{
+		{WriteFiles: WriteFiles{Path: "/etc/motd"}},
+		{WriteFiles: WriteFiles{Path: "/etc/ceph/site.conf"}, Subroles: []string{"Storage"}},
+	}
+	suite.Equal([]WriteFiles{{Path: "/etc/motd"}}, SiteWriteFilesForSubrole(files, "Worker"))
+	suite.Equal([]WriteFiles{{Path: "/etc/motd"}, {Path: "/etc/ceph/site.conf"}}, SiteWriteFilesForSubrole(files, "Storage"))
+}
+
+func (suite *WriteFilesTestSuite) TestValidate_Invalid() {
+	tests := []struct {
+		file          WriteFiles
+		expectedError error
+	}{{
+		file:          WriteFiles{Path: "etc/motd"},
+		expectedError: errors.New(`write_files path "etc/motd" must be a clean absolute path`),
+	}, {
+		file:          WriteFiles{Path: "/etc/../root/.ssh/authorized_keys"},
+		expectedError: errors.New(`write_files path "/etc/../root/.ssh/authorized_keys" must be a clean absolute path`),
+	}, {
+		file:          WriteFiles{Path: "/etc/motd", Encoding: "base32"},
+		expectedError: errors.New(`write_files encoding "base32" of /etc/motd is not supported by cloud-init`),
+	}, {
+		file:          WriteFiles{Path: "/etc/motd", Encoding: "b64", Content: "not base64!"},
+		expectedError: errors.New("write_files content of /etc/motd is not valid base64: illegal base64 data at input byte 3"),
+	}, {
+		file:          WriteFiles{Path: "/etc/motd", Permissions: "0689"},
+		expectedError: errors.New(`write_files permissions "0689" of /etc/motd must be octal`),
+	}}
+
+	for _, test := range tests {
+		suite.Equal(test.expectedError, SiteWriteFile{WriteFiles: test.file}.Validate())
+	}
+}
+
+func TestWriteFilesTestSuite(t *testing.T) {
+	suite.Run(t, new(WriteFilesTestSuite))
+}
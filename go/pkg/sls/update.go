@@ -0,0 +1,68 @@
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package sls
+
+import (
+	"fmt"
+	"sort"
+
+	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
+)
+
+// The sections of an SLS state that UpdateSection can push on their own
+const (
+	SectionNetworks = "networks"
+	SectionCabinets = "cabinets"
+	SectionAll      = "all"
+)
+
+// UpdateSection - PUTs the networks, the cabinets or both from state into a running SLS, one object at a
+// time, leaving everything else in SLS as it is.
+func (utilsClient *UtilsClient) UpdateSection(state sls_common.SLSState, section string) error {
+	switch section {
+	case SectionNetworks:
+		return utilsClient.updateNetworks(state)
+	case SectionCabinets:
+		return utilsClient.updateCabinets(state)
+	case SectionAll:
+		if err := utilsClient.updateNetworks(state); err != nil {
+			return err
+		}
+		return utilsClient.updateCabinets(state)
+	}
+	return fmt.Errorf("unknown SLS section %q, expected %s, %s or %s", section, SectionNetworks, SectionCabinets, SectionAll)
+}
+
+func (utilsClient *UtilsClient) updateNetworks(state sls_common.SLSState) error {
+	var names []string
+	for name := range state.Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := utilsClient.PutNetwork(state.Networks[name]); err != nil {
+			return fmt.Errorf("failed to update the %s network: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (utilsClient *UtilsClient) updateCabinets(state sls_common.SLSState) error {
+	var xnames []string
+	for xname, hardware := range state.Hardware {
+		if hardware.Type == sls_common.Cabinet {
+			xnames = append(xnames, xname)
+		}
+	}
+	sort.Strings(xnames)
+
+	for _, xname := range xnames {
+		if err := utilsClient.PutHardware(state.Hardware[xname]); err != nil {
+			return fmt.Errorf("failed to update cabinet %s: %w", xname, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+//go:build !integration && !shcd
+// +build !integration,!shcd
+
+/*
+Copyright 2022 Hewlett Packard Enterprise Development LP
+*/
+
+package sls
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
+	"github.com/stretchr/testify/suite"
+)
+
+type SLSUpdateTestSuite struct {
+	suite.Suite
+}
+
+func testSLSState() sls_common.SLSState {
+	return sls_common.SLSState{
+		Hardware: map[string]sls_common.GenericHardware{
+			"x3000":         {Xname: "x3000", Type: sls_common.Cabinet, Class: sls_common.ClassRiver},
+			"x3000c0s1b0n0": {Xname: "x3000c0s1b0n0", Type: sls_common.Node, Class: sls_common.ClassRiver},
+		},
+		Networks: map[string]sls_common.Network{
+			"NMN": {Name: "NMN", FullName: "Node Management Network", IPRanges: []string{"10.252.0.0/17"}},
+			"HMN": {Name: "HMN", FullName: "Hardware Management Network", IPRanges: []string{"10.254.0.0/17"}},
+		},
+	}
+}
+
+func (suite *SLSUpdateTestSuite) TestUpdateSection_Networks() {
+	var paths []string
+	var pushed []sls_common.Network
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		var network sls_common.Network
+		suite.NoError(json.NewDecoder(r.Body).Decode(&network))
+		pushed = append(pushed, network)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewSLSClient(server.URL, server.Client(), "")
+	suite.NoError(client.UpdateSection(testSLSState(), SectionNetworks))
+	suite.Equal([]string{"PUT /v1/networks/HMN", "PUT /v1/networks/NMN"}, paths)
+	suite.Equal("Hardware Management Network", pushed[0].FullName)
+	suite.Equal([]string{"10.252.0.0/17"}, pushed[1].IPRanges)
+}
+
+func (suite *SLSUpdateTestSuite) TestUpdateSection_All() {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewSLSClient(server.URL, server.Client(), "")
+	suite.NoError(client.UpdateSection(testSLSState(), SectionAll))
+	suite.Equal([]string{"PUT /v1/networks/HMN", "PUT /v1/networks/NMN", "PUT /v1/hardware/x3000"}, paths)
+}
+
+func (suite *SLSUpdateTestSuite) TestUpdateSection_Failure() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("bad cabinet"))
+	}))
+	defer server.Close()
+
+	client := NewSLSClient(server.URL, server.Client(), "")
+	suite.EqualError(client.UpdateSection(testSLSState(), SectionCabinets),
+		"failed to update cabinet x3000: unexpected status code 400 from SLS: bad cabinet")
+}
+
+func (suite *SLSUpdateTestSuite) TestUpdateSection_Unknown() {
+	client := NewSLSClient("http://localhost", nil, "")
+	suite.Equal(errors.New(`unknown SLS section "hardware", expected networks, cabinets or all`),
+		client.UpdateSection(testSLSState(), "hardware"))
+}
+
+func (suite *SLSUpdateTestSuite) TestGetState_ErrorStatus() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"detail":"database unavailable"}`))
+	}))
+	defer server.Close()
+
+	client := NewSLSClient(server.URL, server.Client(), "")
+	_, err := client.GetDumpState()
+	suite.EqualError(err, `unexpected status code 503 from SLS: {"detail":"database unavailable"}`)
+	_, err = client.GetNetworks()
+	suite.EqualError(err, `unexpected status code 503 from SLS: {"detail":"database unavailable"}`)
+}
+
+func TestSLSUpdateTestSuite(t *testing.T) {
+	suite.Run(t, new(SLSUpdateTestSuite))
+}
@@ -1,12 +1,13 @@
 package sls
 
 import (
-	"crypto/tls"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 
+	"github.com/Cray-HPE/csm-common/go/pkg/httpretry"
 	sls_common "github.com/Cray-HPE/hms-sls/pkg/sls-common"
 )
 
@@ -17,14 +18,12 @@ type UtilsClient struct {
 	token      string
 }
 
-// NewSLSClient - Creates a new SLS client.
-func NewSLSClient(baseURL string, httpClient *http.Client, token string) *UtilsClient {
+// NewSLSClient - Creates a new SLS client. Without an httpClient the requests time out and are retried
+// as httpretry.NewClient describes, the options set the timeout and retries, e.g. from --http-timeout and
+// --http-retries.
+func NewSLSClient(baseURL string, httpClient *http.Client, token string, options ...httpretry.Option) *UtilsClient {
 	if httpClient == nil {
-		transport := http.DefaultTransport.(*http.Transport).Clone()
-		transport.TLSClientConfig = &tls.Config{
-			InsecureSkipVerify: true,
-		}
-		httpClient = &http.Client{Transport: transport}
+		httpClient = httpretry.NewClient(options...)
 	}
 
 	return &UtilsClient{
@@ -60,6 +59,10 @@ func (utilsClient *UtilsClient) GetManagementNCNs() (managementNCNs []sls_common
 	defer resp.Body.Close()
 
 	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("unexpected status code %d from SLS: %s", resp.StatusCode, string(body))
+		return
+	}
 	err = json.Unmarshal(body, &managementNCNs)
 	if err != nil {
 		err = fmt.Errorf("failed to unmarshal body: %w", err)
@@ -93,6 +96,10 @@ func (utilsClient *UtilsClient) GetNetworks() (networks sls_common.NetworkArray,
 	defer resp.Body.Close()
 
 	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("unexpected status code %d from SLS: %s", resp.StatusCode, string(body))
+		return
+	}
 	err = json.Unmarshal(body, &networks)
 	if err != nil {
 		err = fmt.Errorf("failed to unmarshal body: %w", err)
@@ -100,3 +107,120 @@ func (utilsClient *UtilsClient) GetNetworks() (networks sls_common.NetworkArray,
 
 	return
 }
+
+// GetDumpState - Returns the full state of SLS.
+func (utilsClient *UtilsClient) GetDumpState() (state sls_common.SLSState, err error) {
+	url := fmt.Sprintf("%s/v1/dumpstate", utilsClient.baseURL)
+	req, err := http.NewRequest("GET", url, nil)
+
+	if err != nil {
+		err = fmt.Errorf("failed to create new request: %w", err)
+		return
+	}
+
+	// Indicates whether to close the connection after sending the request
+	req.Close = true
+
+	if utilsClient.token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", utilsClient.token))
+	}
+
+	resp, err := utilsClient.httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("failed to do request: %w", err)
+		return
+	}
+
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("unexpected status code %d from SLS: %s", resp.StatusCode, string(body))
+		return
+	}
+	err = json.Unmarshal(body, &state)
+	if err != nil {
+		err = fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+
+	return
+}
+
+// PutNetwork - Creates or replaces a network in SLS.
+func (utilsClient *UtilsClient) PutNetwork(network sls_common.Network) (err error) {
+	payload, err := json.Marshal(network)
+	if err != nil {
+		err = fmt.Errorf("failed to marshal network: %w", err)
+		return
+	}
+
+	url := fmt.Sprintf("%s/v1/networks/%s", utilsClient.baseURL, network.Name)
+	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(payload))
+
+	if err != nil {
+		err = fmt.Errorf("failed to create new request: %w", err)
+		return
+	}
+
+	// Indicates whether to close the connection after sending the request
+	req.Close = true
+
+	req.Header.Set("Content-Type", "application/json")
+	if utilsClient.token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", utilsClient.token))
+	}
+
+	resp, err := utilsClient.httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("failed to do request: %w", err)
+		return
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := ioutil.ReadAll(resp.Body)
+		err = fmt.Errorf("unexpected status code %d from SLS: %s", resp.StatusCode, string(body))
+	}
+
+	return
+}
+
+// PutHardware - Creates or replaces a piece of hardware in SLS.
+func (utilsClient *UtilsClient) PutHardware(hardware sls_common.GenericHardware) (err error) {
+	payload, err := json.Marshal(hardware)
+	if err != nil {
+		err = fmt.Errorf("failed to marshal hardware: %w", err)
+		return
+	}
+
+	url := fmt.Sprintf("%s/v1/hardware/%s", utilsClient.baseURL, hardware.Xname)
+	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(payload))
+	if err != nil {
+		err = fmt.Errorf("failed to create new request: %w", err)
+		return
+	}
+
+	// Indicates whether to close the connection after sending the request
+	req.Close = true
+
+	req.Header.Set("Content-Type", "application/json")
+	if utilsClient.token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", utilsClient.token))
+	}
+
+	resp, err := utilsClient.httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("failed to do request: %w", err)
+		return
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := ioutil.ReadAll(resp.Body)
+		err = fmt.Errorf("unexpected status code %d from SLS: %s", resp.StatusCode, string(body))
+	}
+
+	return
+}